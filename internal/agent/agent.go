@@ -3,11 +3,13 @@ package agent
 import (
 	"fmt"
 	"os"
+	"time"
 
 	"github.com/taaha3244/potus/internal/config"
 	"github.com/taaha3244/potus/internal/context"
 	"github.com/taaha3244/potus/internal/permissions"
 	"github.com/taaha3244/potus/internal/providers"
+	"github.com/taaha3244/potus/internal/session"
 	"github.com/taaha3244/potus/internal/tools"
 	gocontext "context"
 )
@@ -15,32 +17,46 @@ import (
 const MaxToolIterations = 10
 
 type Agent struct {
-	provider       providers.Provider
-	toolRegistry   *tools.Registry
-	memory         *Memory
-	executor       *Executor
-	contextManager *context.Manager
-	systemPrompt   string
-	maxTokens      int
-	temperature    float64
-	model          string
-	confirmChan    chan Decision
-	settings       *permissions.Settings
-	workDir        string
+	provider        providers.Provider
+	toolRegistry    *tools.Registry
+	memory          *Memory
+	executor        *Executor
+	contextManager  *context.Manager
+	systemPrompt    string
+	maxTokens       int
+	temperature     float64
+	reasoningEffort string
+	thinkingBudget  int
+	responseFormat  *providers.ResponseFormat
+	toolChoice      providers.ToolChoice
+	model           string
+	confirmChan     chan Decision
+	settings        *permissions.Settings
+	workDir         string
+	session         *session.Session
+	sessionStore    *session.Store
 }
 
 type Config struct {
-	Provider      providers.Provider
-	ToolRegistry  *tools.Registry
-	SystemPrompt  string
-	MaxTokens     int
-	Temperature   float64
-	Model         string
-	ContextConfig *config.ContextConfig
-	ModelInfo     *providers.Model
-	WorkDir       string
-	ConfirmChan   chan Decision
-	Settings      *permissions.Settings
+	Provider        providers.Provider
+	ToolRegistry    *tools.Registry
+	SystemPrompt    string
+	MaxTokens       int
+	Temperature     float64
+	Model           string
+	ReasoningEffort string
+	ThinkingBudget  int
+	ResponseFormat  *providers.ResponseFormat // constrains the final answer to JSON
+	ToolChoice      providers.ToolChoice
+	ContextConfig   *config.ContextConfig
+	ModelInfo       *providers.Model
+	WorkDir         string
+	ConfirmChan     chan Decision
+	ConfirmFn       ConfirmFunc
+	Settings        *permissions.Settings
+	Permissions     *config.PermissionConfig
+	Session         *session.Session
+	SessionStore    *session.Store
 }
 
 func New(cfg *Config) *Agent {
@@ -72,6 +88,7 @@ func New(cfg *Config) *Agent {
 				cfg.ModelInfo.Pricing.InputPer1M,
 				cfg.ModelInfo.Pricing.OutputPer1M,
 			)
+			ctxManager.SetCachedPricing(cfg.ModelInfo.Pricing.CachedPer1M, cfg.ModelInfo.Pricing.CacheWritePer1M)
 		}
 
 		if cfg.ContextConfig.LoadProjectContext {
@@ -101,30 +118,50 @@ func New(cfg *Config) *Agent {
 		memory.SetSystemTokens(context.EstimateSystemPrompt(systemPrompt))
 	}
 
+	// Resume a saved conversation
+	if cfg.Session != nil && len(cfg.Session.Messages) > 0 {
+		msgs := make([]providers.Message, len(cfg.Session.Messages))
+		copy(msgs, cfg.Session.Messages)
+		memory.ReplaceMessages(msgs)
+
+		if ctxManager != nil {
+			usage := cfg.Session.Usage
+			ctxManager.RestoreUsage(usage.InputTokens, usage.OutputTokens, usage.CacheReadTokens, usage.CacheWriteTokens, usage.Cost)
+		}
+	}
+
 	workDir := cfg.WorkDir
 	if workDir == "" {
 		workDir, _ = os.Getwd()
 	}
 
 	executor := NewExecutorWithConfig(&ExecutorConfig{
-		Registry: cfg.ToolRegistry,
-		Settings: cfg.Settings,
-		WorkDir:  workDir,
+		Registry:    cfg.ToolRegistry,
+		ConfirmFn:   cfg.ConfirmFn,
+		Settings:    cfg.Settings,
+		Permissions: cfg.Permissions,
+		WorkDir:     workDir,
 	})
 
 	return &Agent{
-		provider:       cfg.Provider,
-		toolRegistry:   cfg.ToolRegistry,
-		memory:         memory,
-		executor:       executor,
-		contextManager: ctxManager,
-		systemPrompt:   systemPrompt,
-		maxTokens:      cfg.MaxTokens,
-		temperature:    cfg.Temperature,
-		model:          cfg.Model,
-		confirmChan:    cfg.ConfirmChan,
-		settings:       cfg.Settings,
-		workDir:        workDir,
+		provider:        cfg.Provider,
+		toolRegistry:    cfg.ToolRegistry,
+		memory:          memory,
+		executor:        executor,
+		contextManager:  ctxManager,
+		systemPrompt:    systemPrompt,
+		maxTokens:       cfg.MaxTokens,
+		temperature:     cfg.Temperature,
+		reasoningEffort: cfg.ReasoningEffort,
+		thinkingBudget:  cfg.ThinkingBudget,
+		responseFormat:  cfg.ResponseFormat,
+		toolChoice:      cfg.ToolChoice,
+		model:           cfg.Model,
+		confirmChan:     cfg.ConfirmChan,
+		settings:        cfg.Settings,
+		workDir:         workDir,
+		session:         cfg.Session,
+		sessionStore:    cfg.SessionStore,
 	}
 }
 
@@ -136,6 +173,14 @@ func (a *Agent) ProcessMessage(ctx gocontext.Context, userMessage string) (<-cha
 
 func (a *Agent) processLoop(ctx gocontext.Context, userMessage string, eventChan chan<- Event) {
 	defer close(eventChan)
+	defer func() {
+		if err := a.SaveSession(); err != nil {
+			eventChan <- Event{
+				Type:  EventTypeError,
+				Error: err,
+			}
+		}
+	}()
 
 	// Set up the confirmation function that bridges to TUI
 	if a.confirmChan != nil {
@@ -183,12 +228,24 @@ func (a *Agent) processLoop(ctx gocontext.Context, userMessage string, eventChan
 		}
 
 		req := &providers.ChatRequest{
-			Messages:    messages,
-			Tools:       a.toolRegistry.ToProviderTools(),
-			MaxTokens:   a.maxTokens,
-			Temperature: a.temperature,
-			Model:       a.model,
-			System:      a.systemPrompt,
+			Messages:        messages,
+			MaxTokens:       a.maxTokens,
+			Temperature:     a.temperature,
+			Model:           a.model,
+			System:          a.systemPrompt,
+			ReasoningEffort: a.reasoningEffort,
+			ThinkingBudget:  a.thinkingBudget,
+			ResponseFormat:  a.responseFormat,
+		}
+		if a.provider.SupportsTools() {
+			req.Tools = a.toolRegistry.ToProviderTools()
+			req.ToolChoice = a.toolChoice
+			// Forcing a tool call on every request would never let the
+			// model answer, so only the first request of a turn is forced
+			forced := a.toolChoice == providers.ToolChoiceRequired || a.toolChoice.ForcedTool() != ""
+			if i > 0 && forced {
+				req.ToolChoice = providers.ToolChoiceAuto
+			}
 		}
 
 		chatEvents, err := a.provider.Chat(ctx, req)
@@ -217,6 +274,32 @@ func (a *Agent) processLoop(ctx gocontext.Context, userMessage string, eventChan
 					Content: chatEvent.Content,
 				}
 
+			case providers.EventTypeReasoningDelta, providers.EventTypeThinkingDelta:
+				eventChan <- Event{
+					Type:    EventTypeReasoningDelta,
+					Content: chatEvent.Content,
+				}
+
+			case providers.EventTypeReasoning, providers.EventTypeThinking:
+				if textBuffer != "" {
+					assistantMessage.Content = append(assistantMessage.Content, &providers.TextContent{
+						Text: textBuffer,
+					})
+					textBuffer = ""
+				}
+				if chatEvent.Reasoning != nil {
+					assistantMessage.Content = append(assistantMessage.Content, chatEvent.Reasoning)
+				}
+				if chatEvent.Thinking != nil {
+					assistantMessage.Content = append(assistantMessage.Content, chatEvent.Thinking)
+				}
+
+			case providers.EventTypeRetry:
+				eventChan <- Event{
+					Type:    EventTypeRetry,
+					Content: chatEvent.Content,
+				}
+
 			case providers.EventTypeToolUse:
 				if textBuffer != "" {
 					assistantMessage.Content = append(assistantMessage.Content, &providers.TextContent{
@@ -243,11 +326,13 @@ func (a *Agent) processLoop(ctx gocontext.Context, userMessage string, eventChan
 
 				if chatEvent.Usage != nil && a.contextManager != nil {
 					a.contextManager.RecordUsage(chatEvent.Usage.InputTokens, chatEvent.Usage.OutputTokens)
+					a.contextManager.RecordCacheUsage(chatEvent.Usage.CacheCreationInputTokens, chatEvent.Usage.CacheReadInputTokens)
 				}
 
 				eventChan <- Event{
 					Type:  EventTypeMessageDone,
 					Usage: chatEvent.Usage,
+					Model: chatEvent.Model,
 				}
 
 			case providers.EventTypeError:
@@ -263,6 +348,14 @@ func (a *Agent) processLoop(ctx gocontext.Context, userMessage string, eventChan
 		a.emitTokenUpdate(eventChan)
 
 		if len(toolCalls) == 0 {
+			if a.responseFormat != nil {
+				if err := a.responseFormat.Validate(assistantText(*assistantMessage)); err != nil {
+					eventChan <- Event{
+						Type:  EventTypeError,
+						Error: err,
+					}
+				}
+			}
 			break
 		}
 
@@ -280,6 +373,13 @@ func (a *Agent) processLoop(ctx gocontext.Context, userMessage string, eventChan
 			} else {
 				toolResult.Content = result.Output
 				toolResult.IsError = !result.Success
+				if len(result.Images) > 0 {
+					if a.provider.SupportsVision() {
+						toolResult.Images = result.Images
+					} else {
+						toolResult.Content += "\n[image not shown: the model does not accept images]"
+					}
+				}
 			}
 
 			toolResults = append(toolResults, toolResult)
@@ -304,24 +404,141 @@ func (a *Agent) processLoop(ctx gocontext.Context, userMessage string, eventChan
 }
 
 func (a *Agent) emitTokenUpdate(eventChan chan<- Event) {
-	if a.contextManager == nil {
+	info := a.TokenInfo()
+	if info == nil {
 		return
 	}
 
+	eventChan <- Event{
+		Type:      EventTypeTokenUpdate,
+		TokenInfo: info,
+	}
+}
+
+// TokenInfo returns the current context usage, or nil without a context
+// manager.
+func (a *Agent) TokenInfo() *TokenUpdateInfo {
+	if a.contextManager == nil {
+		return nil
+	}
+
 	currentTokens := a.memory.GetTotalTokens()
 	snapshot := a.contextManager.GetBudgetSnapshot(currentTokens)
 
-	eventChan <- Event{
-		Type: EventTypeTokenUpdate,
-		TokenInfo: &TokenUpdateInfo{
-			CurrentTokens: snapshot.CurrentContextTokens,
-			MaxTokens:     snapshot.MaxContextTokens,
-			UsagePercent:  snapshot.UsagePercent,
-			SessionTokens: snapshot.SessionInputTokens + snapshot.SessionOutputTokens,
-			Cost:          snapshot.SessionCost,
-			AtWarning:     snapshot.AtWarningLevel,
-		},
+	return &TokenUpdateInfo{
+		CurrentTokens: snapshot.CurrentContextTokens,
+		MaxTokens:     snapshot.MaxContextTokens,
+		UsagePercent:  snapshot.UsagePercent,
+		SessionTokens: snapshot.SessionInputTokens + snapshot.SessionOutputTokens,
+		Cost:          snapshot.SessionCost,
+		AtWarning:     snapshot.AtWarningLevel,
+	}
+}
+
+// UserTurns returns the text of each user turn, oldest first.
+func (a *Agent) UserTurns() []string {
+	msgs := a.memory.GetMessages()
+	turns := a.memory.UserTurns()
+
+	texts := make([]string, len(turns))
+	for i, index := range turns {
+		texts[i] = userText(msgs[index])
+	}
+	return texts
+}
+
+// Rewind removes user turn n (1-based) and everything after it, returning
+// that turn's text so it can be edited and sent again. When recording a
+// session the conversation continues in a fork, keeping the original.
+func (a *Agent) Rewind(turn int) (string, error) {
+	turns := a.memory.UserTurns()
+	if turn < 1 || turn > len(turns) {
+		return "", fmt.Errorf("no user turn %d (conversation has %d)", turn, len(turns))
+	}
+	index := turns[turn-1]
+
+	text := userText(a.memory.GetMessages()[index])
+
+	if a.session != nil {
+		if err := a.SaveSession(); err != nil {
+			return "", err
+		}
+
+		fork, err := session.Fork(a.session, index)
+		if err != nil {
+			return "", err
+		}
+		a.session = fork
+	}
+
+	a.memory.Truncate(index)
+	return text, nil
+}
+
+// userText returns the typed text of a user message, skipping attachments
+// which precede it.
+func userText(msg providers.Message) string {
+	for i := len(msg.Content) - 1; i >= 0; i-- {
+		if text, ok := msg.Content[i].(*providers.TextContent); ok {
+			return text.Text
+		}
+	}
+	return ""
+}
+
+// assistantText returns all text of an assistant message, without
+// reasoning or tool calls.
+func assistantText(msg providers.Message) string {
+	var text string
+	for _, block := range msg.Content {
+		if t, ok := block.(*providers.TextContent); ok {
+			text += t.Text
+		}
 	}
+	return text
+}
+
+// SaveSession writes the conversation and usage totals to the session
+// store. It does nothing if the agent has no session or no messages yet.
+func (a *Agent) SaveSession() error {
+	if a.session == nil || a.sessionStore == nil || a.memory.Count() == 0 {
+		return nil
+	}
+
+	a.session.Messages = a.memory.GetMessages()
+	a.session.UpdatedAt = time.Now()
+
+	if a.contextManager != nil {
+		snapshot := a.contextManager.GetBudgetSnapshot(a.memory.GetTotalTokens())
+		a.session.Usage = session.Usage{
+			InputTokens:      snapshot.SessionInputTokens,
+			OutputTokens:     snapshot.SessionOutputTokens,
+			ContextTokens:    snapshot.CurrentContextTokens,
+			Cost:             snapshot.SessionCost,
+			CacheReadTokens:  snapshot.CacheReadTokens,
+			CacheWriteTokens: snapshot.CacheWriteTokens,
+		}
+	}
+
+	if err := a.sessionStore.Save(a.session); err != nil {
+		return fmt.Errorf("failed to save session: %w", err)
+	}
+	return nil
+}
+
+// Session returns the session the conversation is recorded in, or nil.
+func (a *Agent) Session() *session.Session {
+	return a.session
+}
+
+// WorkDir returns the directory that relative tool paths resolve against.
+func (a *Agent) WorkDir() string {
+	return a.workDir
+}
+
+// SupportsVision reports whether the model accepts images.
+func (a *Agent) SupportsVision() bool {
+	return a.provider.SupportsVision()
 }
 
 func (a *Agent) GetMemory() *Memory {
@@ -343,27 +560,30 @@ type Event struct {
 	ToolResult *providers.ToolResultContent
 	TokenInfo  *TokenUpdateInfo
 	Usage      *providers.Usage
+	Model      string // the fallback model that answered, on message_done
 	Error      error
 }
 
 type EventType string
 
 const (
-	EventTypeTextDelta     EventType = "text_delta"
-	EventTypeToolCall      EventType = "tool_call"
-	EventTypeToolResult    EventType = "tool_result"
-	EventTypeMessageDone   EventType = "message_done"
-	EventTypeError         EventType = "error"
-	EventTypeTokenUpdate   EventType = "token_update"
-	EventTypeContextUpdate EventType = "context_update"
-	EventTypeToolPreview   EventType = "tool_preview"
+	EventTypeTextDelta      EventType = "text_delta"
+	EventTypeReasoningDelta EventType = "reasoning_delta"
+	EventTypeToolCall       EventType = "tool_call"
+	EventTypeToolResult     EventType = "tool_result"
+	EventTypeMessageDone    EventType = "message_done"
+	EventTypeError          EventType = "error"
+	EventTypeTokenUpdate    EventType = "token_update"
+	EventTypeContextUpdate  EventType = "context_update"
+	EventTypeToolPreview    EventType = "tool_preview"
+	EventTypeRetry          EventType = "retry"
 )
 
 type TokenUpdateInfo struct {
-	CurrentTokens int
-	MaxTokens     int
-	UsagePercent  float64
-	SessionTokens int
-	Cost          float64
-	AtWarning     bool
+	CurrentTokens int     `json:"current_tokens"`
+	MaxTokens     int     `json:"max_tokens"`
+	UsagePercent  float64 `json:"usage_percent"`
+	SessionTokens int     `json:"session_tokens"`
+	Cost          float64 `json:"cost"`
+	AtWarning     bool    `json:"at_warning"`
 }
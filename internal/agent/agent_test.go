@@ -5,10 +5,12 @@ import (
 	"errors"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/taaha3244/potus/internal/config"
 	"github.com/taaha3244/potus/internal/providers"
+	"github.com/taaha3244/potus/internal/session"
 	"github.com/taaha3244/potus/internal/tools"
 )
 
@@ -18,15 +20,23 @@ type mockProvider struct {
 	currentResp int
 	shouldError bool
 	errorMsg    string
+	noTools     bool
+	noVision    bool
+	lastRequest *providers.ChatRequest
+	requests    []*providers.ChatRequest
 }
 
 type mockResponse struct {
-	text     string
-	toolUses []*providers.ToolUseContent
-	usage    *providers.Usage
+	reasoning *providers.ReasoningContent
+	thinking  *providers.ThinkingContent
+	text      string
+	toolUses  []*providers.ToolUseContent
+	usage     *providers.Usage
 }
 
 func (m *mockProvider) Chat(ctx context.Context, req *providers.ChatRequest) (<-chan providers.ChatEvent, error) {
+	m.lastRequest = req
+	m.requests = append(m.requests, req)
 	if m.shouldError {
 		return nil, errors.New(m.errorMsg)
 	}
@@ -51,6 +61,30 @@ func (m *mockProvider) Chat(ctx context.Context, req *providers.ChatRequest) (<-
 		resp := m.responses[m.currentResp]
 		m.currentResp++
 
+		// Send reasoning
+		if resp.reasoning != nil {
+			events <- providers.ChatEvent{
+				Type:    providers.EventTypeReasoningDelta,
+				Content: resp.reasoning.Text,
+			}
+			events <- providers.ChatEvent{
+				Type:      providers.EventTypeReasoning,
+				Reasoning: resp.reasoning,
+			}
+		}
+
+		// Send thinking
+		if resp.thinking != nil {
+			events <- providers.ChatEvent{
+				Type:    providers.EventTypeThinkingDelta,
+				Content: resp.thinking.Thinking,
+			}
+			events <- providers.ChatEvent{
+				Type:     providers.EventTypeThinking,
+				Thinking: resp.thinking,
+			}
+		}
+
 		// Send text
 		if resp.text != "" {
 			events <- providers.ChatEvent{
@@ -83,8 +117,8 @@ func (m *mockProvider) ListModels(ctx context.Context) ([]providers.Model, error
 	}, nil
 }
 
-func (m *mockProvider) SupportsTools() bool { return true }
-func (m *mockProvider) SupportsVision() bool { return true }
+func (m *mockProvider) SupportsTools() bool { return !m.noTools }
+func (m *mockProvider) SupportsVision() bool { return !m.noVision }
 func (m *mockProvider) Name() string { return "mock" }
 
 // mockTool implements tools.Tool for testing
@@ -92,6 +126,7 @@ type mockTool struct {
 	name        string
 	shouldError bool
 	output      string
+	images      []*providers.ImageContent
 }
 
 func (t *mockTool) Name() string        { return t.name }
@@ -103,7 +138,9 @@ func (t *mockTool) Execute(ctx context.Context, params map[string]interface{}) (
 	if t.shouldError {
 		return nil, errors.New("tool execution failed")
 	}
-	return tools.NewResult(t.output), nil
+	result := tools.NewResult(t.output)
+	result.Images = t.images
+	return result, nil
 }
 
 func TestNew(t *testing.T) {
@@ -540,6 +577,423 @@ func TestAgent_GetTokenSummary(t *testing.T) {
 	}
 }
 
+func TestAgent_ToolsGatedByProvider(t *testing.T) {
+	registry := tools.NewRegistry()
+	registry.Register(&mockTool{name: "file_read"})
+
+	for _, noTools := range []bool{false, true} {
+		provider := &mockProvider{
+			noTools:   noTools,
+			responses: []mockResponse{{text: "ok"}},
+		}
+		ag := New(&Config{
+			Provider:     provider,
+			ToolRegistry: registry,
+			Model:        "test-model",
+		})
+
+		events, _ := ag.ProcessMessage(context.Background(), "Hello")
+		for range events {
+		}
+
+		if got := len(provider.lastRequest.Tools); (got == 0) != noTools {
+			t.Errorf("noTools=%v: request has %d tools", noTools, got)
+		}
+	}
+}
+
+func TestAgent_Reasoning(t *testing.T) {
+	reasoning := &providers.ReasoningContent{ID: "rs_1", Text: "Plan the answer", Encrypted: "gAAAA"}
+	ag := New(&Config{
+		Provider: &mockProvider{
+			responses: []mockResponse{{reasoning: reasoning, text: "Done"}},
+		},
+		ToolRegistry: tools.NewRegistry(),
+		Model:        "test-model",
+	})
+
+	events, _ := ag.ProcessMessage(context.Background(), "Hello")
+
+	var summary string
+	for event := range events {
+		if event.Type == EventTypeReasoningDelta {
+			summary += event.Content
+		}
+	}
+	if summary != "Plan the answer" {
+		t.Errorf("reasoning delta = %q", summary)
+	}
+
+	messages := ag.GetMemory().GetMessages()
+	assistant := messages[len(messages)-1]
+	if len(assistant.Content) != 2 {
+		t.Fatalf("assistant message has %d blocks, want 2", len(assistant.Content))
+	}
+	if assistant.Content[0] != reasoning {
+		t.Errorf("first block = %#v, want the reasoning block", assistant.Content[0])
+	}
+}
+
+func TestAgent_Thinking(t *testing.T) {
+	thinking := &providers.ThinkingContent{Thinking: "Check go.mod", Signature: "EqQBCkYIBxgC"}
+	provider := &mockProvider{
+		responses: []mockResponse{
+			{thinking: thinking, toolUses: []*providers.ToolUseContent{{ID: "t1", Name: "noop", Input: map[string]interface{}{}}}},
+			{text: "Done"},
+		},
+	}
+	ag := New(&Config{
+		Provider:       provider,
+		ToolRegistry:   tools.NewRegistry(),
+		Model:          "test-model",
+		ThinkingBudget: 4096,
+	})
+
+	events, _ := ag.ProcessMessage(context.Background(), "Hello")
+
+	var shown string
+	for event := range events {
+		if event.Type == EventTypeReasoningDelta {
+			shown += event.Content
+		}
+	}
+	if shown != "Check go.mod" {
+		t.Errorf("reasoning delta = %q", shown)
+	}
+
+	if provider.lastRequest.ThinkingBudget != 4096 {
+		t.Errorf("ThinkingBudget = %d, want 4096", provider.lastRequest.ThinkingBudget)
+	}
+
+	// The signed block must precede the tool call it led to
+	assistant := ag.GetMemory().GetMessages()[1]
+	if len(assistant.Content) != 2 || assistant.Content[0] != thinking {
+		t.Errorf("assistant content = %#v, want thinking then tool use", assistant.Content)
+	}
+}
+
+func TestAgent_ToolChoice(t *testing.T) {
+	tests := []struct {
+		choice providers.ToolChoice
+		want   []providers.ToolChoice
+	}{
+		{"", []providers.ToolChoice{"", ""}},
+		{providers.ToolChoiceNone, []providers.ToolChoice{providers.ToolChoiceNone, providers.ToolChoiceNone}},
+		{providers.ToolChoiceRequired, []providers.ToolChoice{providers.ToolChoiceRequired, providers.ToolChoiceAuto}},
+		{"noop", []providers.ToolChoice{"noop", providers.ToolChoiceAuto}},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.choice), func(t *testing.T) {
+			provider := &mockProvider{
+				responses: []mockResponse{
+					{toolUses: []*providers.ToolUseContent{{ID: "t1", Name: "noop", Input: map[string]interface{}{}}}},
+					{text: "Done"},
+				},
+			}
+			ag := New(&Config{
+				Provider:     provider,
+				ToolRegistry: tools.NewRegistry(),
+				Model:        "test-model",
+				ToolChoice:   tt.choice,
+			})
+
+			events, _ := ag.ProcessMessage(context.Background(), "Hello")
+			for range events {
+			}
+
+			if len(provider.requests) != len(tt.want) {
+				t.Fatalf("got %d requests, want %d", len(provider.requests), len(tt.want))
+			}
+			for i, want := range tt.want {
+				if got := provider.requests[i].ToolChoice; got != want {
+					t.Errorf("request %d: ToolChoice = %q, want %q", i, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestAgent_ToolResultImages(t *testing.T) {
+	img := &providers.ImageContent{Source: providers.ImageSource{Type: "base64", MediaType: "image/png", Data: "iVBORw0KGgo="}}
+
+	tests := []struct {
+		name        string
+		noVision    bool
+		wantImages  int
+		wantContent string
+	}{
+		{"vision", false, 1, "Image shot.png"},
+		{"no vision", true, 0, "Image shot.png\n[image not shown: the model does not accept images]"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			registry := tools.NewRegistry()
+			registry.Register(&mockTool{name: "screenshot", output: "Image shot.png", images: []*providers.ImageContent{img}})
+
+			provider := &mockProvider{
+				noVision: tt.noVision,
+				responses: []mockResponse{
+					{toolUses: []*providers.ToolUseContent{{ID: "t1", Name: "screenshot", Input: map[string]interface{}{}}}},
+					{text: "Done"},
+				},
+			}
+			ag := New(&Config{
+				Provider:     provider,
+				ToolRegistry: registry,
+				Model:        "test-model",
+			})
+
+			events, _ := ag.ProcessMessage(context.Background(), "Take a screenshot")
+			for range events {
+			}
+
+			var result *providers.ToolResultContent
+			for _, msg := range ag.GetMemory().GetMessages() {
+				for _, block := range msg.Content {
+					if tr, ok := block.(*providers.ToolResultContent); ok {
+						result = tr
+					}
+				}
+			}
+			if result == nil {
+				t.Fatal("expected a tool result in memory")
+			}
+			if len(result.Images) != tt.wantImages {
+				t.Errorf("got %d images, want %d", len(result.Images), tt.wantImages)
+			}
+			if result.Content != tt.wantContent {
+				t.Errorf("Content = %q, want %q", result.Content, tt.wantContent)
+			}
+		})
+	}
+}
+
+func TestAgent_ResponseFormat(t *testing.T) {
+	format := &providers.ResponseFormat{
+		Name: "answer",
+		Schema: map[string]interface{}{
+			"type":     "object",
+			"required": []string{"count"},
+		},
+	}
+
+	tests := []struct {
+		name    string
+		text    string
+		wantErr string
+	}{
+		{"valid answer", `{"count": 3}`, ""},
+		{"missing property", `{"total": 3}`, `missing required property "count"`},
+		{"prose", "There are 3.", "response is not valid JSON"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			provider := &mockProvider{responses: []mockResponse{{text: tt.text}}}
+			ag := New(&Config{
+				Provider:       provider,
+				ToolRegistry:   tools.NewRegistry(),
+				Model:          "test-model",
+				ResponseFormat: format,
+			})
+
+			events, _ := ag.ProcessMessage(context.Background(), "How many?")
+
+			var gotErr error
+			for event := range events {
+				if event.Type == EventTypeError {
+					gotErr = event.Error
+				}
+			}
+
+			if provider.lastRequest.ResponseFormat != format {
+				t.Error("response format not passed to the provider")
+			}
+			if tt.wantErr == "" {
+				if gotErr != nil {
+					t.Errorf("unexpected error: %v", gotErr)
+				}
+				return
+			}
+			if gotErr == nil || !strings.Contains(gotErr.Error(), tt.wantErr) {
+				t.Errorf("error = %v, want it to contain %q", gotErr, tt.wantErr)
+			}
+		})
+	}
+}
+
+// TestAgent_ReplayToolLoop runs a recorded three-request tool loop: a
+// search, a file read and the answer.
+func TestAgent_ReplayToolLoop(t *testing.T) {
+	registry := tools.NewRegistry()
+	registry.Register(&mockTool{name: "search_content", output: "cmd/potus/main.go:9:\tversion = \"dev\""})
+	registry.Register(&mockTool{name: "file_read", output: "package main\n\nvar (\n\tversion = \"dev\"\n)\n"})
+
+	agent := New(&Config{
+		Provider:     providers.NewReplay(),
+		ToolRegistry: registry,
+		SystemPrompt: "You are helpful",
+		MaxTokens:    1024,
+		Model:        filepath.Join("testdata", "tool_loop"),
+	})
+
+	events, err := agent.ProcessMessage(context.Background(), "Where is the version defined?")
+	if err != nil {
+		t.Fatalf("ProcessMessage() error = %v", err)
+	}
+
+	var toolCalls []string
+	var toolResults, done int
+	var text string
+	for event := range events {
+		switch event.Type {
+		case EventTypeToolCall:
+			toolCalls = append(toolCalls, event.ToolUse.Name)
+		case EventTypeToolResult:
+			toolResults++
+		case EventTypeTextDelta:
+			text += event.Content
+		case EventTypeMessageDone:
+			done++
+		case EventTypeError:
+			t.Fatalf("Unexpected error: %v", event.Error)
+		}
+	}
+
+	if len(toolCalls) != 2 || toolCalls[0] != "search_content" || toolCalls[1] != "file_read" {
+		t.Errorf("tool calls = %v, want search_content then file_read", toolCalls)
+	}
+	if toolResults != 2 || done != 3 {
+		t.Errorf("got %d tool results and %d responses, want 2 and 3", toolResults, done)
+	}
+	if !strings.HasSuffix(text, "defaults to `dev` in `cmd/potus/main.go`.") {
+		t.Errorf("text = %q", text)
+	}
+
+	// user, then assistant and tool result twice, then the answer
+	if count := agent.GetMemory().Count(); count != 6 {
+		t.Errorf("memory has %d messages, want 6", count)
+	}
+}
+
+func TestAgent_Session(t *testing.T) {
+	store := session.NewStoreWithDir(t.TempDir())
+	contextConfig := &config.ContextConfig{
+		MaxTokens:          100000,
+		ReserveForResponse: 8192,
+	}
+
+	sess := session.New("mock/test-model")
+	first := New(&Config{
+		Provider: &mockProvider{
+			responses: []mockResponse{
+				{text: "Hi there", usage: &providers.Usage{InputTokens: 100, OutputTokens: 20, CacheCreationInputTokens: 30, CacheReadInputTokens: 50}},
+			},
+		},
+		ToolRegistry:  tools.NewRegistry(),
+		Model:         "test-model",
+		ContextConfig: contextConfig,
+		Session:       sess,
+		SessionStore:  store,
+	})
+
+	events, _ := first.ProcessMessage(context.Background(), "Hello")
+	for event := range events {
+		if event.Type == EventTypeError {
+			t.Fatalf("Unexpected error: %v", event.Error)
+		}
+	}
+
+	saved, err := store.Load(sess.ID)
+	if err != nil {
+		t.Fatalf("session was not saved: %v", err)
+	}
+	if len(saved.Messages) != 2 || saved.Title != "Hello" {
+		t.Fatalf("saved = %+v", saved)
+	}
+	if saved.Usage.InputTokens != 180 || saved.Usage.OutputTokens != 20 || saved.Usage.CacheWriteTokens != 30 || saved.Usage.CacheReadTokens != 50 {
+		t.Errorf("usage = %+v", saved.Usage)
+	}
+
+	resumed := New(&Config{
+		Provider:      &mockProvider{},
+		ToolRegistry:  tools.NewRegistry(),
+		Model:         "test-model",
+		ContextConfig: contextConfig,
+		Session:       saved,
+		SessionStore:  store,
+	})
+
+	if resumed.GetMemory().Count() != 2 {
+		t.Errorf("resumed memory has %d messages, want 2", resumed.GetMemory().Count())
+	}
+	snapshot := resumed.GetContextManager().GetBudgetSnapshot(0)
+	if snapshot.SessionInputTokens != 180 || snapshot.SessionOutputTokens != 20 {
+		t.Errorf("restored usage = %d/%d, want 180/20", snapshot.SessionInputTokens, snapshot.SessionOutputTokens)
+	}
+	if snapshot.CacheWriteTokens != 30 || snapshot.CacheReadTokens != 50 {
+		t.Errorf("restored cache usage = %d written / %d read, want 30 / 50", snapshot.CacheWriteTokens, snapshot.CacheReadTokens)
+	}
+}
+
+func TestAgent_Rewind(t *testing.T) {
+	store := session.NewStoreWithDir(t.TempDir())
+	sess := session.New("mock/test-model")
+
+	agent := New(&Config{
+		Provider: &mockProvider{
+			responses: []mockResponse{
+				{text: "Answer one"},
+				{text: "Answer two"},
+			},
+		},
+		ToolRegistry: tools.NewRegistry(),
+		Model:        "test-model",
+		ContextConfig: &config.ContextConfig{
+			MaxTokens:          100000,
+			ReserveForResponse: 8192,
+		},
+		Session:      sess,
+		SessionStore: store,
+	})
+
+	for _, msg := range []string{"Question one", "Question two"} {
+		events, _ := agent.ProcessMessage(context.Background(), msg)
+		for range events {
+		}
+	}
+
+	if _, err := agent.Rewind(3); err == nil {
+		t.Error("expected error for a turn that does not exist")
+	}
+
+	text, err := agent.Rewind(2)
+	if err != nil {
+		t.Fatalf("Rewind() error = %v", err)
+	}
+	if text != "Question two" {
+		t.Errorf("Rewind() text = %q, want 'Question two'", text)
+	}
+	if agent.GetMemory().Count() != 2 {
+		t.Errorf("expected 2 messages after rewind, got %d", agent.GetMemory().Count())
+	}
+
+	fork := agent.Session()
+	if fork.ID == sess.ID || fork.ForkedFrom != sess.ID {
+		t.Errorf("rewind should continue in a fork, got %+v", fork)
+	}
+
+	original, err := store.Load(sess.ID)
+	if err != nil {
+		t.Fatalf("original session missing: %v", err)
+	}
+	if len(original.Messages) != 4 {
+		t.Errorf("original session should keep 4 messages, has %d", len(original.Messages))
+	}
+}
+
 func TestTokenUpdateInfo(t *testing.T) {
 	info := &TokenUpdateInfo{
 		CurrentTokens: 50000,
@@ -7,6 +7,8 @@ import (
 	"path/filepath"
 	"strings"
 
+	"github.com/taaha3244/potus/internal/config"
+	"github.com/taaha3244/potus/internal/mcp"
 	"github.com/taaha3244/potus/internal/permissions"
 	"github.com/taaha3244/potus/internal/providers"
 	"github.com/taaha3244/potus/internal/tools"
@@ -23,17 +25,19 @@ const (
 type ConfirmFunc func(toolName, action, preview string) (Decision, error)
 
 type Executor struct {
-	registry  *tools.Registry
-	confirmFn ConfirmFunc
-	settings  *permissions.Settings
-	workDir   string
+	registry    *tools.Registry
+	confirmFn   ConfirmFunc
+	settings    *permissions.Settings
+	permissions *config.PermissionConfig
+	workDir     string
 }
 
 type ExecutorConfig struct {
-	Registry  *tools.Registry
-	ConfirmFn ConfirmFunc
-	Settings  *permissions.Settings
-	WorkDir   string
+	Registry    *tools.Registry
+	ConfirmFn   ConfirmFunc
+	Settings    *permissions.Settings
+	Permissions *config.PermissionConfig
+	WorkDir     string
 }
 
 func NewExecutor(registry *tools.Registry) *Executor {
@@ -44,10 +48,11 @@ func NewExecutor(registry *tools.Registry) *Executor {
 
 func NewExecutorWithConfig(cfg *ExecutorConfig) *Executor {
 	return &Executor{
-		registry:  cfg.Registry,
-		confirmFn: cfg.ConfirmFn,
-		settings:  cfg.Settings,
-		workDir:   cfg.WorkDir,
+		registry:    cfg.Registry,
+		confirmFn:   cfg.ConfirmFn,
+		settings:    cfg.Settings,
+		permissions: cfg.Permissions,
+		workDir:     cfg.WorkDir,
 	}
 }
 
@@ -57,6 +62,10 @@ func (e *Executor) Execute(ctx context.Context, toolUse *providers.ToolUseConten
 		return nil, fmt.Errorf("tool not found: %s", toolUse.Name)
 	}
 
+	if mcp.IsToolName(toolUse.Name) && e.mcpPermission() == config.PermissionDeny {
+		return tools.NewErrorResult(fmt.Errorf("MCP tools are denied by configuration")), nil
+	}
+
 	if e.needsConfirmation(toolUse.Name) && e.confirmFn != nil {
 		preview := e.generatePreview(toolUse)
 		action := e.describeAction(toolUse)
@@ -90,6 +99,10 @@ func (e *Executor) needsConfirmation(name string) bool {
 		return false
 	}
 
+	if mcp.IsToolName(name) {
+		return e.mcpPermission() != config.PermissionAllow
+	}
+
 	switch name {
 	case "file_write", "file_edit", "file_delete", "bash":
 		return true
@@ -98,6 +111,13 @@ func (e *Executor) needsConfirmation(name string) bool {
 	}
 }
 
+func (e *Executor) mcpPermission() config.Permission {
+	if e.permissions == nil || e.permissions.MCP == "" {
+		return config.PermissionAsk
+	}
+	return e.permissions.MCP
+}
+
 func (e *Executor) generatePreview(toolUse *providers.ToolUseContent) string {
 	switch toolUse.Name {
 	case "file_write":
@@ -139,6 +159,9 @@ func (e *Executor) describeAction(toolUse *providers.ToolUseContent) string {
 		}
 		return "Execute bash command"
 	default:
+		if server, tool, ok := mcp.ParseToolName(toolUse.Name); ok {
+			return fmt.Sprintf("Call MCP tool %s on %s", tool, server)
+		}
 		return toolUse.Name
 	}
 }
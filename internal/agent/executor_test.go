@@ -4,6 +4,7 @@ import (
 	"context"
 	"testing"
 
+	"github.com/taaha3244/potus/internal/config"
 	"github.com/taaha3244/potus/internal/providers"
 	"github.com/taaha3244/potus/internal/tools"
 )
@@ -100,3 +101,58 @@ func TestExecutor_Execute(t *testing.T) {
 		})
 	}
 }
+
+func TestExecutor_MCPPermission(t *testing.T) {
+	registry := tools.NewRegistry()
+	registry.Register(&testTool{name: "mcp__docs__search"})
+
+	toolUse := &providers.ToolUseContent{
+		ID:    "1",
+		Name:  "mcp__docs__search",
+		Input: map[string]interface{}{"query": "install"},
+	}
+
+	tests := []struct {
+		name        string
+		permission  config.Permission
+		wantConfirm bool
+		wantSuccess bool
+	}{
+		{name: "allow skips confirmation", permission: config.PermissionAllow, wantConfirm: false, wantSuccess: true},
+		{name: "ask requires confirmation", permission: config.PermissionAsk, wantConfirm: true, wantSuccess: true},
+		{name: "unset defaults to ask", permission: "", wantConfirm: true, wantSuccess: true},
+		{name: "deny blocks the call", permission: config.PermissionDeny, wantConfirm: false, wantSuccess: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			confirmed := false
+			var action string
+
+			executor := NewExecutorWithConfig(&ExecutorConfig{
+				Registry:    registry,
+				Permissions: &config.PermissionConfig{MCP: tt.permission},
+				ConfirmFn: func(toolName, a, preview string) (Decision, error) {
+					confirmed = true
+					action = a
+					return DecisionApprove, nil
+				},
+			})
+
+			result, err := executor.Execute(context.Background(), toolUse)
+			if err != nil {
+				t.Fatalf("Execute() error = %v", err)
+			}
+
+			if confirmed != tt.wantConfirm {
+				t.Errorf("confirmation requested = %v, want %v", confirmed, tt.wantConfirm)
+			}
+			if confirmed && action != "Call MCP tool search on docs" {
+				t.Errorf("action = %q", action)
+			}
+			if result.Success != tt.wantSuccess {
+				t.Errorf("Success = %v, want %v", result.Success, tt.wantSuccess)
+			}
+		})
+	}
+}
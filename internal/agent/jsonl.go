@@ -0,0 +1,58 @@
+package agent
+
+import (
+	"encoding/json"
+	"io"
+
+	"github.com/taaha3244/potus/internal/providers"
+)
+
+// EventSchemaVersion is bumped whenever a field of EventRecord is removed
+// or changes meaning. Adding fields does not change the version.
+const EventSchemaVersion = 1
+
+// EventRecord is the JSON form of an Event, written one per line by
+// EventEncoder.
+type EventRecord struct {
+	Version    int                          `json:"version"`
+	Type       EventType                    `json:"type"`
+	Content    string                       `json:"content,omitempty"`
+	ToolUse    *providers.ToolUseContent    `json:"tool_use,omitempty"`
+	ToolResult *providers.ToolResultContent `json:"tool_result,omitempty"`
+	TokenInfo  *TokenUpdateInfo             `json:"token_info,omitempty"`
+	Usage      *providers.Usage             `json:"usage,omitempty"`
+	Model      string                       `json:"model,omitempty"`
+	Error      string                       `json:"error,omitempty"`
+}
+
+func NewEventRecord(event Event) EventRecord {
+	record := EventRecord{
+		Version:    EventSchemaVersion,
+		Type:       event.Type,
+		Content:    event.Content,
+		ToolUse:    event.ToolUse,
+		ToolResult: event.ToolResult,
+		TokenInfo:  event.TokenInfo,
+		Usage:      event.Usage,
+		Model:      event.Model,
+	}
+	if event.Error != nil {
+		record.Error = event.Error.Error()
+	}
+	return record
+}
+
+// EventEncoder writes events as JSON Lines.
+type EventEncoder struct {
+	enc *json.Encoder
+}
+
+func NewEventEncoder(w io.Writer) *EventEncoder {
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	return &EventEncoder{enc: enc}
+}
+
+func (e *EventEncoder) Encode(event Event) error {
+	return e.enc.Encode(NewEventRecord(event))
+}
@@ -0,0 +1,54 @@
+package agent
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/taaha3244/potus/internal/providers"
+)
+
+func TestEventEncoder(t *testing.T) {
+	var buf bytes.Buffer
+	enc := NewEventEncoder(&buf)
+
+	events := []Event{
+		{Type: EventTypeTextDelta, Content: "a <b>"},
+		{Type: EventTypeToolCall, ToolUse: &providers.ToolUseContent{ID: "t1", Name: "bash", Input: map[string]interface{}{"command": "ls"}}},
+		{Type: EventTypeToolResult, ToolResult: &providers.ToolResultContent{ToolUseID: "t1", Content: "ok"}},
+		{Type: EventTypeTokenUpdate, TokenInfo: &TokenUpdateInfo{CurrentTokens: 10, MaxTokens: 100, UsagePercent: 10}},
+		{Type: EventTypeMessageDone, Usage: &providers.Usage{InputTokens: 5, OutputTokens: 7}},
+		{Type: EventTypeError, Error: errors.New("boom")},
+	}
+	for _, event := range events {
+		if err := enc.Encode(event); err != nil {
+			t.Fatalf("Encode() error = %v", err)
+		}
+	}
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != len(events) {
+		t.Fatalf("expected %d lines, got %d", len(events), len(lines))
+	}
+
+	want := []string{
+		`{"version":1,"type":"text_delta","content":"a <b>"}`,
+		`{"version":1,"type":"tool_call","tool_use":{"id":"t1","name":"bash","input":{"command":"ls"}}}`,
+		`{"version":1,"type":"tool_result","tool_result":{"tool_use_id":"t1","content":"ok","is_error":false}}`,
+		`{"version":1,"type":"token_update","token_info":{"current_tokens":10,"max_tokens":100,"usage_percent":10,"session_tokens":0,"cost":0,"at_warning":false}}`,
+		`{"version":1,"type":"message_done","usage":{"input_tokens":5,"output_tokens":7,"total_tokens":0}}`,
+		`{"version":1,"type":"error","error":"boom"}`,
+	}
+	for i, line := range lines {
+		if line != want[i] {
+			t.Errorf("line %d:\n got  %s\n want %s", i, line, want[i])
+		}
+
+		var record EventRecord
+		if err := json.Unmarshal([]byte(line), &record); err != nil {
+			t.Errorf("line %d is not valid JSON: %v", i, err)
+		}
+	}
+}
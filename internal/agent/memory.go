@@ -14,6 +14,7 @@ type Memory struct {
 	estimator    context.TokenEstimator
 	totalTokens  int
 	systemTokens int
+	attachments  []providers.ContentBlock
 }
 
 func NewMemory(estimator context.TokenEstimator) *Memory {
@@ -27,16 +28,47 @@ func NewMemory(estimator context.TokenEstimator) *Memory {
 	}
 }
 
+// AddUserMessage records a user turn. Any pending attachments are sent
+// ahead of the text as separate content blocks and then cleared.
 func (m *Memory) AddUserMessage(content string) int {
+	m.mu.Lock()
+	attachments := m.attachments
+	m.attachments = nil
+	m.mu.Unlock()
+
+	blocks := make([]providers.ContentBlock, 0, len(attachments)+1)
+	blocks = append(blocks, attachments...)
+	blocks = append(blocks, &providers.TextContent{Text: content})
+
 	msg := providers.Message{
-		Role: providers.RoleUser,
-		Content: []providers.ContentBlock{
-			&providers.TextContent{Text: content},
-		},
+		Role:    providers.RoleUser,
+		Content: blocks,
 	}
 	return m.AddMessage(&msg)
 }
 
+// Attach queues text, such as an MCP resource, to be included with the
+// next user message.
+func (m *Memory) Attach(text string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.attachments = append(m.attachments, &providers.TextContent{Text: text})
+}
+
+// AttachImage queues an image, such as a pasted screenshot, to be included
+// with the next user message.
+func (m *Memory) AttachImage(img *providers.ImageContent) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.attachments = append(m.attachments, img)
+}
+
+func (m *Memory) PendingAttachments() int {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return len(m.attachments)
+}
+
 func (m *Memory) AddMessage(msg *providers.Message) int {
 	m.mu.Lock()
 	defer m.mu.Unlock()
@@ -122,6 +154,37 @@ func (m *Memory) ReplaceMessages(msgs []providers.Message) {
 	}
 }
 
+// Truncate drops the message at index n and everything after it.
+func (m *Memory) Truncate(n int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if n < 0 || n >= len(m.messages) {
+		return
+	}
+
+	m.messages = m.messages[:n]
+	m.tokenInfo = m.tokenInfo[:n]
+	m.totalTokens = 0
+	for _, info := range m.tokenInfo {
+		m.totalTokens += info.Tokens
+	}
+}
+
+// UserTurns returns the indices of the messages typed by the user, in order.
+func (m *Memory) UserTurns() []int {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var turns []int
+	for i, msg := range m.messages {
+		if msg.Role == providers.RoleUser {
+			turns = append(turns, i)
+		}
+	}
+	return turns
+}
+
 func (m *Memory) Clear() {
 	m.mu.Lock()
 	defer m.mu.Unlock()
@@ -129,6 +192,7 @@ func (m *Memory) Clear() {
 	m.messages = make([]providers.Message, 0)
 	m.tokenInfo = make([]context.TokenInfo, 0)
 	m.totalTokens = 0
+	m.attachments = nil
 }
 
 func (m *Memory) Count() int {
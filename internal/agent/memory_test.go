@@ -59,6 +59,61 @@ func TestMemory_AddUserMessage(t *testing.T) {
 	}
 }
 
+func TestMemory_Attach(t *testing.T) {
+	mem := NewMemory(nil)
+
+	mem.Attach("[resource: docs://guide]\n# Guide")
+	if mem.PendingAttachments() != 1 {
+		t.Fatalf("expected 1 pending attachment, got %d", mem.PendingAttachments())
+	}
+
+	mem.AddUserMessage("Summarize the guide")
+
+	if mem.PendingAttachments() != 0 {
+		t.Errorf("attachments should be cleared after use, got %d", mem.PendingAttachments())
+	}
+
+	msgs := mem.GetMessages()
+	if len(msgs[0].Content) != 2 {
+		t.Fatalf("expected 2 content blocks, got %d", len(msgs[0].Content))
+	}
+
+	attached := msgs[0].Content[0].(*providers.TextContent)
+	if attached.Text != "[resource: docs://guide]\n# Guide" {
+		t.Errorf("unexpected attachment block: %q", attached.Text)
+	}
+
+	text := msgs[0].Content[1].(*providers.TextContent)
+	if text.Text != "Summarize the guide" {
+		t.Errorf("unexpected text block: %q", text.Text)
+	}
+
+	mem.AddUserMessage("Next")
+	msgs = mem.GetMessages()
+	if len(msgs[1].Content) != 1 {
+		t.Errorf("expected attachment to be sent only once, got %d blocks", len(msgs[1].Content))
+	}
+}
+
+func TestMemory_AttachImage(t *testing.T) {
+	mem := NewMemory(nil)
+
+	img := &providers.ImageContent{Source: providers.ImageSource{Type: "base64", MediaType: "image/png", Data: "iVBORw0KGgo="}}
+	mem.AttachImage(img)
+	mem.AddUserMessage("What does this show?")
+
+	msgs := mem.GetMessages()
+	if len(msgs[0].Content) != 2 {
+		t.Fatalf("expected 2 content blocks, got %d", len(msgs[0].Content))
+	}
+	if msgs[0].Content[0] != img {
+		t.Errorf("expected the image first, got %T", msgs[0].Content[0])
+	}
+	if text, ok := msgs[0].Content[1].(*providers.TextContent); !ok || text.Text != "What does this show?" {
+		t.Errorf("unexpected text block: %#v", msgs[0].Content[1])
+	}
+}
+
 func TestMemory_AddMessage(t *testing.T) {
 	mem := NewMemory(nil)
 
@@ -246,6 +301,49 @@ func TestMemory_ReplaceMessages(t *testing.T) {
 	}
 }
 
+func TestMemory_Truncate(t *testing.T) {
+	mem := NewMemory(nil)
+
+	mem.AddUserMessage("First question")
+	mem.AddMessage(&providers.Message{
+		Role: providers.RoleAssistant,
+		Content: []providers.ContentBlock{
+			&providers.TextContent{Text: "First answer"},
+		},
+	})
+	firstTurnTokens := mem.GetMessageTokens()
+
+	mem.AddUserMessage("Second question with a lot more words in it")
+	mem.AddMessage(&providers.Message{
+		Role: providers.RoleAssistant,
+		Content: []providers.ContentBlock{
+			&providers.TextContent{Text: "Second answer"},
+		},
+	})
+
+	turns := mem.UserTurns()
+	if len(turns) != 2 || turns[0] != 0 || turns[1] != 2 {
+		t.Fatalf("UserTurns() = %v, want [0 2]", turns)
+	}
+
+	mem.Truncate(2)
+
+	if mem.Count() != 2 {
+		t.Errorf("expected 2 messages after truncate, got %d", mem.Count())
+	}
+	if len(mem.GetTokenInfo()) != 2 {
+		t.Errorf("expected 2 token info entries, got %d", len(mem.GetTokenInfo()))
+	}
+	if mem.GetMessageTokens() != firstTurnTokens {
+		t.Errorf("message tokens = %d, want %d", mem.GetMessageTokens(), firstTurnTokens)
+	}
+
+	mem.Truncate(10)
+	if mem.Count() != 2 {
+		t.Error("truncating past the end should be a no-op")
+	}
+}
+
 func TestMemory_Clear(t *testing.T) {
 	mem := NewMemory(nil)
 
@@ -0,0 +1,47 @@
+package agent
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ConfirmPolicy decides tool confirmations when no user is present to
+// answer them, e.g. in headless runs.
+type ConfirmPolicy string
+
+const (
+	PolicyDenyAll     ConfirmPolicy = "deny-all"
+	PolicyAllowListed ConfirmPolicy = "allow-listed"
+	PolicyAllowAll    ConfirmPolicy = "allow-all"
+)
+
+func ParseConfirmPolicy(s string) (ConfirmPolicy, error) {
+	switch policy := ConfirmPolicy(strings.ToLower(s)); policy {
+	case PolicyDenyAll, PolicyAllowListed, PolicyAllowAll:
+		return policy, nil
+	default:
+		return "", fmt.Errorf("unknown confirmation policy %q (use deny-all, allow-listed or allow-all)", s)
+	}
+}
+
+// PolicyConfirmFunc answers every confirmation according to the policy.
+// Under PolicyAllowListed only tools named in allowed are approved; tools
+// already allowed in the permission settings never reach the function.
+func PolicyConfirmFunc(policy ConfirmPolicy, allowed []string) ConfirmFunc {
+	allowSet := make(map[string]bool, len(allowed))
+	for _, name := range allowed {
+		allowSet[strings.ToLower(name)] = true
+	}
+
+	return func(toolName, action, preview string) (Decision, error) {
+		switch policy {
+		case PolicyAllowAll:
+			return DecisionApprove, nil
+		case PolicyAllowListed:
+			if allowSet[strings.ToLower(toolName)] {
+				return DecisionApprove, nil
+			}
+		}
+		return DecisionDeny, nil
+	}
+}
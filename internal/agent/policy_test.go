@@ -0,0 +1,85 @@
+package agent
+
+import (
+	"context"
+	"testing"
+
+	"github.com/taaha3244/potus/internal/providers"
+	"github.com/taaha3244/potus/internal/tools"
+)
+
+func TestParseConfirmPolicy(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    ConfirmPolicy
+		wantErr bool
+	}{
+		{"deny-all", PolicyDenyAll, false},
+		{"allow-listed", PolicyAllowListed, false},
+		{"Allow-All", PolicyAllowAll, false},
+		{"ask", "", true},
+		{"", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := ParseConfirmPolicy(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseConfirmPolicy(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseConfirmPolicy(%q) = %q, want %q", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPolicyConfirmFunc(t *testing.T) {
+	tests := []struct {
+		name    string
+		policy  ConfirmPolicy
+		allowed []string
+		tool    string
+		want    Decision
+	}{
+		{"deny-all denies", PolicyDenyAll, []string{"bash"}, "bash", DecisionDeny},
+		{"allow-all approves", PolicyAllowAll, nil, "file_delete", DecisionApprove},
+		{"allow-listed approves listed", PolicyAllowListed, []string{"BASH"}, "bash", DecisionApprove},
+		{"allow-listed denies others", PolicyAllowListed, []string{"bash"}, "file_write", DecisionDeny},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fn := PolicyConfirmFunc(tt.policy, tt.allowed)
+			got, err := fn(tt.tool, "", "")
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("decision = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExecutor_PolicyDeny(t *testing.T) {
+	registry := tools.NewRegistry()
+	registry.Register(&testTool{name: "bash"})
+
+	executor := NewExecutorWithConfig(&ExecutorConfig{
+		Registry:  registry,
+		ConfirmFn: PolicyConfirmFunc(PolicyDenyAll, nil),
+	})
+
+	result, err := executor.Execute(context.Background(), &providers.ToolUseContent{
+		ID:    "1",
+		Name:  "bash",
+		Input: map[string]interface{}{"command": "rm -rf /tmp/x"},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.Success {
+		t.Error("expected denied tool to fail")
+	}
+}
@@ -19,6 +19,8 @@ var knownProviders = []struct {
 }{
 	{"anthropic", "ANTHROPIC_API_KEY"},
 	{"openai", "OPENAI_API_KEY"},
+	{"gemini", "GEMINI_API_KEY"},
+	{"azure", "AZURE_OPENAI_API_KEY"},
 }
 
 func newAuthCmd() *cobra.Command {
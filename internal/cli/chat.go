@@ -1,6 +1,7 @@
 package cli
 
 import (
+	"encoding/json"
 	"fmt"
 	"os"
 
@@ -8,11 +9,10 @@ import (
 	"github.com/taaha3244/potus/internal/agent"
 	"github.com/taaha3244/potus/internal/auth"
 	"github.com/taaha3244/potus/internal/config"
+	"github.com/taaha3244/potus/internal/mcp"
 	"github.com/taaha3244/potus/internal/permissions"
 	"github.com/taaha3244/potus/internal/providers"
-	"github.com/taaha3244/potus/internal/providers/anthropic"
-	"github.com/taaha3244/potus/internal/providers/ollama"
-	"github.com/taaha3244/potus/internal/providers/openai"
+	"github.com/taaha3244/potus/internal/session"
 	"github.com/taaha3244/potus/internal/tools"
 	"github.com/taaha3244/potus/internal/tools/bash"
 	"github.com/taaha3244/potus/internal/tools/file"
@@ -23,12 +23,95 @@ import (
 )
 
 func runChat(cmd *cobra.Command, args []string) error {
+	// Create confirmation channel for tool approval
+	confirmChan := make(chan agent.Decision, 1)
+
+	setup, err := setupAgent(cmd, agentOptions{
+		confirmChan:  confirmChan,
+		loadSettings: true,
+	})
+	if err != nil {
+		return err
+	}
+	defer setup.mcp.Close()
+
+	err = tui.Run(setup.agent, setup.model, confirmChan, setup.mcp)
+	printSessionHint(setup.agent)
+	return err
+}
+
+// printSessionHint tells the user how to get back to the conversation once
+// it has been saved.
+func printSessionHint(ag *agent.Agent) {
+	if sess := ag.Session(); sess != nil && ag.GetMemory().Count() > 0 {
+		fmt.Fprintf(os.Stderr, "Session %s saved. Resume with: potus --resume %s\n", sess.ID, sess.ID)
+	}
+}
+
+// agentOptions controls how tool confirmations are resolved for an agent
+// built by setupAgent, and the format of its answers.
+type agentOptions struct {
+	confirmChan    chan agent.Decision
+	confirmFn      agent.ConfirmFunc
+	loadSettings   bool
+	responseFormat *providers.ResponseFormat
+}
+
+type agentSetup struct {
+	agent *agent.Agent
+	mcp   *mcp.Manager
+	model string
+}
+
+// agentPreset returns the named entry of the agents section. Presets
+// other than default take its model and max_tokens unless they set their
+// own.
+func agentPreset(cfg *config.Config, name string) (config.AgentConfig, error) {
+	preset, ok := cfg.Agents[name]
+	if !ok {
+		return config.AgentConfig{}, fmt.Errorf("unknown agent preset %q (see the agents section of the config)", name)
+	}
+	if preset.Model == "" {
+		preset.Model = cfg.Agents["default"].Model
+	}
+	if preset.MaxTokens == 0 {
+		preset.MaxTokens = cfg.Agents["default"].MaxTokens
+	}
+	return preset, nil
+}
+
+// setupAgent loads the configuration, resolves the provider and model,
+// registers the built-in and MCP tools, and builds the agent. The caller
+// must close the returned MCP manager.
+func setupAgent(cmd *cobra.Command, opts agentOptions) (*agentSetup, error) {
 	modelFlag, _ := cmd.Flags().GetString("model")
 	dirFlag, _ := cmd.Flags().GetString("dir")
+	resumeFlag, _ := cmd.Flags().GetString("resume")
+	continueFlag, _ := cmd.Flags().GetBool("continue")
+	recordFlag, _ := cmd.Flags().GetString("record")
+	agentFlag, _ := cmd.Flags().GetString("agent")
+
+	if resumeFlag != "" && continueFlag {
+		return nil, fmt.Errorf("--resume and --continue are mutually exclusive")
+	}
 
 	cfg, err := config.Load(cfgFile)
 	if err != nil {
-		return fmt.Errorf("failed to load config: %w", err)
+		return nil, fmt.Errorf("failed to load config: %w", err)
+	}
+
+	preset, err := agentPreset(cfg, agentFlag)
+	if err != nil {
+		return nil, err
+	}
+
+	// Checked before MCP servers are started, which would otherwise have
+	// to be stopped again
+	var schema []byte
+	if opts.responseFormat != nil {
+		if schema, err = json.Marshal(opts.responseFormat.Schema); err != nil {
+			return nil, fmt.Errorf("invalid schema: %w", err)
+		}
 	}
 
 	workDir := dirFlag
@@ -36,37 +119,38 @@ func runChat(cmd *cobra.Command, args []string) error {
 		workDir, _ = os.Getwd()
 	} else {
 		if err := os.Chdir(dirFlag); err != nil {
-			return fmt.Errorf("failed to change directory: %w", err)
+			return nil, fmt.Errorf("failed to change directory: %w", err)
 		}
 		workDir, _ = os.Getwd()
 	}
 
-	providerRegistry := providers.NewRegistry()
-	authStore := auth.NewStore()
-
-	if apiKey := auth.ResolveAPIKey(authStore, "anthropic", cfg.Providers["anthropic"].APIKeyEnv); apiKey != "" {
-		anthropicClient, err := anthropic.New(apiKey)
-		if err == nil {
-			providerRegistry.Register("anthropic", anthropicClient)
-		}
+	// Load a saved session to resume, if requested
+	sessionStore := session.NewStore(workDir)
+	var sess *session.Session
+	switch {
+	case resumeFlag != "":
+		sess, err = sessionStore.Load(resumeFlag)
+	case continueFlag:
+		sess, err = sessionStore.Latest()
 	}
-
-	if apiKey := auth.ResolveAPIKey(authStore, "openai", cfg.Providers["openai"].APIKeyEnv); apiKey != "" {
-		openaiClient, err := openai.New(apiKey, cfg.Providers["openai"].Organization)
-		if err == nil {
-			providerRegistry.Register("openai", openaiClient)
-		}
+	if err != nil {
+		return nil, err
 	}
 
-	ollamaClient, err := ollama.New(cfg.Providers["ollama"].Endpoint)
-	if err == nil {
-		providerRegistry.Register("ollama", ollamaClient)
-	}
+	providerRegistry := newProviderRegistry(cfg, auth.NewStore())
 
 	modelStr := modelFlag
+	if modelStr == "" && sess != nil {
+		modelStr = sess.Model
+	}
 	if modelStr == "" {
-		modelStr = cfg.Agents["default"].Model
+		modelStr = preset.Model
+	}
+
+	if sess == nil {
+		sess = session.New(modelStr)
 	}
+	sess.Model = modelStr
 
 	providerName, modelName := providers.ParseModelString(modelStr)
 	if providerName == "" {
@@ -76,25 +160,47 @@ func runChat(cmd *cobra.Command, args []string) error {
 
 	provider, err := providerRegistry.Get(providerName)
 	if err != nil {
-		return fmt.Errorf("provider not available: %w", err)
+		return nil, fmt.Errorf("provider not available: %w", err)
+	}
+
+	if chain := preset.Fallback; len(chain) > 0 {
+		primary := providers.FallbackEntry{
+			Label:    providerName + "/" + modelName,
+			Provider: provider,
+			Model:    modelName,
+		}
+		provider, err = newFallback(providerRegistry, primary, chain)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if recordFlag != "" {
+		provider = providers.NewRecorder(provider, recordFlag)
+	}
+
+	if selector, ok := provider.(providers.ModelSelector); ok {
+		if err := selector.SelectModel(cmd.Context(), modelName); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: could not read capabilities of %s: %v\n", modelStr, err)
+		}
+	}
+	if !provider.SupportsTools() {
+		fmt.Fprintf(os.Stderr, "Warning: %s does not support tools; running without them\n", modelStr)
 	}
 
 	// Get model info for context size and pricing
 	var modelInfo *providers.Model
 	if models, err := provider.ListModels(cmd.Context()); err == nil {
-		for _, m := range models {
-			if m.ID == modelName || m.Name == modelName {
-				modelInfo = &m
-				break
-			}
+		if m, ok := providers.FindModel(models, modelName); ok {
+			modelInfo = &m
 		}
 	}
 
 	// Load permission settings
-	permSettings := permissions.LoadSettings(workDir)
-
-	// Create confirmation channel for tool approval
-	confirmChan := make(chan agent.Decision, 1)
+	var permSettings *permissions.Settings
+	if opts.loadSettings {
+		permSettings = permissions.LoadSettings(workDir)
+	}
 
 	// Register tools
 	toolRegistry := tools.NewRegistry()
@@ -113,6 +219,19 @@ func runChat(cmd *cobra.Command, args []string) error {
 	toolRegistry.Register(web.NewFetchTool())
 	toolRegistry.Register(web.NewSearchTool())
 
+	// Connect MCP servers and register their tools
+	mcpManager := mcp.NewManager()
+	for name, err := range mcpManager.Start(cmd.Context(), cfg.MCPServers, toolRegistry) {
+		fmt.Fprintf(os.Stderr, "Warning: MCP server %s unavailable: %v\n", name, err)
+	}
+
+	if name := providers.ToolChoice(preset.ToolChoice).ForcedTool(); name != "" {
+		if _, err := toolRegistry.Get(name); err != nil {
+			mcpManager.Close()
+			return nil, fmt.Errorf("agent %s: tool_choice names unknown tool %q", agentFlag, name)
+		}
+	}
+
 	systemPrompt := `You are POTUS (Power Of The Universal Shell), an AI coding assistant.
 
 You have access to tools to read, write, and edit files, execute bash commands, work with git repositories, search code, and fetch web content.
@@ -143,21 +262,41 @@ When fetching web content:
 - Use web_fetch to retrieve documentation or web pages
 - Use web_search to search for information online
 
+Tools named mcp__<server>__<tool> are provided by external MCP servers configured by the user.
+
 Be helpful, accurate, and concise in your responses.`
 
+	// Not every provider can enforce the format while tools are offered,
+	// so the model is told about it as well
+	if opts.responseFormat != nil {
+		systemPrompt += "\n\nGive your final answer as JSON matching this JSON Schema, with no other text:\n" + string(schema)
+	}
+
 	ag := agent.New(&agent.Config{
-		Provider:      provider,
-		ToolRegistry:  toolRegistry,
-		SystemPrompt:  systemPrompt,
-		MaxTokens:     cfg.Agents["default"].MaxTokens,
-		Temperature:   cfg.Agents["default"].Temperature,
-		Model:         modelName,
-		ContextConfig: &cfg.Context,
-		ModelInfo:     modelInfo,
-		WorkDir:       workDir,
-		ConfirmChan:   confirmChan,
-		Settings:      permSettings,
+		Provider:        provider,
+		ToolRegistry:    toolRegistry,
+		SystemPrompt:    systemPrompt,
+		MaxTokens:       preset.MaxTokens,
+		Temperature:     preset.Temperature,
+		Model:           modelName,
+		ReasoningEffort: preset.ReasoningEffort,
+		ThinkingBudget:  preset.ThinkingBudget,
+		ResponseFormat:  opts.responseFormat,
+		ToolChoice:      providers.ToolChoice(preset.ToolChoice),
+		ContextConfig:   &cfg.Context,
+		ModelInfo:       modelInfo,
+		WorkDir:         workDir,
+		ConfirmChan:     opts.confirmChan,
+		ConfirmFn:       opts.confirmFn,
+		Settings:        permSettings,
+		Permissions:     &cfg.Permissions,
+		Session:         sess,
+		SessionStore:    sessionStore,
 	})
 
-	return tui.Run(ag, modelStr, confirmChan)
+	return &agentSetup{
+		agent: ag,
+		mcp:   mcpManager,
+		model: modelStr,
+	}, nil
 }
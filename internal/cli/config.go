@@ -279,10 +279,17 @@ func setConfigDefaults(v *viper.Viper) {
 	v.SetDefault("providers.openai.default_model", "gpt-5.2")
 	v.SetDefault("providers.openai.max_tokens", 4096)
 
+	v.SetDefault("providers.gemini.api_key_env", "GEMINI_API_KEY")
+	v.SetDefault("providers.gemini.default_model", "gemini-2.5-pro")
+	v.SetDefault("providers.gemini.max_tokens", 8192)
+
 	v.SetDefault("providers.ollama.endpoint", "http://localhost:11434")
 	v.SetDefault("providers.ollama.default_model", "qwen2.5-coder:32b")
 	v.SetDefault("providers.ollama.max_tokens", 4096)
 
+	v.SetDefault("providers.bedrock.default_model", "us.anthropic.claude-sonnet-4-5-20250929-v1:0")
+	v.SetDefault("providers.bedrock.max_tokens", 8192)
+
 	v.SetDefault("agents.default.model", "anthropic/claude-sonnet-4-5")
 	v.SetDefault("agents.default.max_tokens", 8192)
 	v.SetDefault("agents.default.temperature", 0.7)
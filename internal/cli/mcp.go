@@ -1,51 +1,383 @@
 package cli
 
 import (
+	"bufio"
+	"context"
 	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
 
 	"github.com/spf13/cobra"
+	"github.com/taaha3244/potus/internal/config"
+	"github.com/taaha3244/potus/internal/mcp"
+	"gopkg.in/yaml.v3"
 )
 
+var validServerName = regexp.MustCompile(`^[a-z0-9_-]+$`)
+
 func newMCPCmd() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "mcp",
 		Short: "Manage MCP servers",
+		Long:  "Add, remove, list, and test Model Context Protocol servers whose tools are made available to the agent.",
 	}
 
-	cmd.AddCommand(&cobra.Command{
+	cmd.AddCommand(newMCPListCmd())
+	cmd.AddCommand(newMCPAddCmd())
+	cmd.AddCommand(newMCPRemoveCmd())
+	cmd.AddCommand(newMCPTestCmd())
+
+	return cmd
+}
+
+func newMCPListCmd() *cobra.Command {
+	return &cobra.Command{
 		Use:   "list",
 		Short: "List configured MCP servers",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return fmt.Errorf("not implemented")
+			cfg, err := config.Load(cfgFile)
+			if err != nil {
+				return fmt.Errorf("failed to load config: %w", err)
+			}
+
+			if len(cfg.MCPServers) == 0 {
+				fmt.Println("No MCP servers configured. Add one with 'potus mcp add <name>'.")
+				return nil
+			}
+
+			names := make([]string, 0, len(cfg.MCPServers))
+			for name := range cfg.MCPServers {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+
+			w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+			fmt.Fprintln(w, "NAME\tENABLED\tTRANSPORT\tTARGET")
+			fmt.Fprintln(w, "----\t-------\t---------\t------")
+
+			for _, name := range names {
+				server := cfg.MCPServers[name]
+				target := server.URL
+				if target == "" {
+					target = strings.TrimSpace(server.Command + " " + strings.Join(server.Args, " "))
+				}
+				fmt.Fprintf(w, "%s\t%v\t%s\t%s\n", name, server.Enabled, mcp.TransportName(server), truncate(target, 60))
+			}
+
+			w.Flush()
+			return nil
 		},
-	})
+	}
+}
+
+func newMCPAddCmd() *cobra.Command {
+	var (
+		command   string
+		cmdArgs   []string
+		env       map[string]string
+		url       string
+		transport string
+		headers   map[string]string
+		timeout   string
+		disabled  bool
+	)
 
-	cmd.AddCommand(&cobra.Command{
+	cmd := &cobra.Command{
 		Use:   "add [server-name]",
 		Short: "Add MCP server interactively",
-		Args:  cobra.ExactArgs(1),
+		Long: `Add an MCP server to the configuration file. Any value not given as a
+flag is prompted for.
+
+Examples:
+  potus mcp add docs
+  potus mcp add fs --command npx --arg -y --arg @modelcontextprotocol/server-filesystem --arg .
+  potus mcp add github --command github-mcp-server --env GITHUB_TOKEN=ghp_...
+  potus mcp add docs --url https://mcp.example.com/mcp --header 'Authorization=Bearer ${DOCS_TOKEN}'
+  potus mcp add legacy --url https://mcp.example.com/sse --transport sse --timeout 2m`,
+		Args: cobra.ExactArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return fmt.Errorf("not implemented")
+			name := strings.ToLower(args[0])
+			if !validServerName.MatchString(name) {
+				return fmt.Errorf("invalid server name %q (use letters, digits, '-' and '_')", args[0])
+			}
+
+			if command != "" && url != "" {
+				return fmt.Errorf("--command and --url are mutually exclusive")
+			}
+			if url == "" && (transport != "" || len(headers) > 0) {
+				return fmt.Errorf("--transport and --header require --url")
+			}
+			if timeout != "" {
+				if _, err := time.ParseDuration(timeout); err != nil {
+					return fmt.Errorf("invalid timeout %q: %w", timeout, err)
+				}
+			}
+
+			if command == "" && url == "" {
+				reader := bufio.NewReader(os.Stdin)
+
+				fmt.Print("Command: ")
+				input, _ := reader.ReadString('\n')
+				command = strings.TrimSpace(input)
+				if command == "" {
+					return fmt.Errorf("command is required")
+				}
+
+				fmt.Print("Arguments (space separated, optional): ")
+				input, _ = reader.ReadString('\n')
+				cmdArgs = strings.Fields(input)
+
+				fmt.Print("Environment (KEY=VALUE, comma separated, optional): ")
+				input, _ = reader.ReadString('\n')
+				parsed, err := parseEnvList(input)
+				if err != nil {
+					return err
+				}
+				env = parsed
+			}
+
+			server := map[string]interface{}{"enabled": !disabled}
+			if url != "" {
+				server["url"] = url
+				if transport != "" {
+					server["transport"] = strings.ToLower(transport)
+				}
+				if len(headers) > 0 {
+					server["headers"] = headers
+				}
+			} else {
+				server["command"] = command
+				if len(cmdArgs) > 0 {
+					server["args"] = cmdArgs
+				}
+				if len(env) > 0 {
+					server["env"] = env
+				}
+			}
+			if timeout != "" {
+				server["timeout"] = timeout
+			}
+
+			if _, err := mcp.NewTransport(config.MCPServerConfig{URL: url, Transport: transport, Command: command}); err != nil {
+				return err
+			}
+
+			return saveMCPServer(name, server)
 		},
-	})
+	}
 
-	cmd.AddCommand(&cobra.Command{
+	cmd.Flags().StringVar(&command, "command", "", "executable that starts the server")
+	cmd.Flags().StringArrayVar(&cmdArgs, "arg", nil, "argument passed to the command (repeatable)")
+	cmd.Flags().StringToStringVar(&env, "env", nil, "environment variable for the server, KEY=VALUE (repeatable)")
+	cmd.Flags().StringVar(&url, "url", "", "endpoint of a remote server (instead of --command)")
+	cmd.Flags().StringVar(&transport, "transport", "", "remote transport: http (streamable HTTP, default) or sse")
+	cmd.Flags().StringToStringVar(&headers, "header", nil, "HTTP header sent to a remote server, NAME=VALUE; ${VAR} is expanded (repeatable)")
+	cmd.Flags().StringVar(&timeout, "timeout", "", "per-request timeout, e.g. 30s (default 60s)")
+	cmd.Flags().BoolVar(&disabled, "disabled", false, "add the server without enabling it")
+
+	return cmd
+}
+
+func newMCPRemoveCmd() *cobra.Command {
+	return &cobra.Command{
 		Use:   "remove [server-name]",
 		Short: "Remove MCP server",
 		Args:  cobra.ExactArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return fmt.Errorf("not implemented")
+			name := strings.ToLower(args[0])
+
+			configPath, err := getConfigPath()
+			if err != nil {
+				return err
+			}
+
+			data, err := readConfigFile(configPath)
+			if err != nil {
+				return err
+			}
+
+			servers, _ := data["mcp_servers"].(map[string]interface{})
+			if _, ok := servers[name]; !ok {
+				return fmt.Errorf("MCP server not found in %s: %s", configPath, name)
+			}
+
+			delete(servers, name)
+			if len(servers) == 0 {
+				delete(data, "mcp_servers")
+			}
+
+			if err := writeConfigFile(configPath, data); err != nil {
+				return err
+			}
+
+			fmt.Printf("✓ Removed MCP server %s\n", name)
+			return nil
 		},
-	})
+	}
+}
 
-	cmd.AddCommand(&cobra.Command{
+func newMCPTestCmd() *cobra.Command {
+	return &cobra.Command{
 		Use:   "test [server-name]",
 		Short: "Test MCP server connection",
 		Args:  cobra.ExactArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return fmt.Errorf("not implemented")
+			name := strings.ToLower(args[0])
+
+			cfg, err := config.Load(cfgFile)
+			if err != nil {
+				return fmt.Errorf("failed to load config: %w", err)
+			}
+
+			server, ok := cfg.MCPServers[name]
+			if !ok {
+				return fmt.Errorf("unknown MCP server: %s", name)
+			}
+
+			fmt.Printf("Testing connection to %s over %s...\n", name, mcp.TransportName(server))
+
+			ctx, cancel := context.WithTimeout(cmd.Context(), mcp.DefaultConnectTimeout)
+			defer cancel()
+
+			start := time.Now()
+			client, err := mcp.Connect(ctx, name, server)
+			if err != nil {
+				return fmt.Errorf("connection failed: %w", err)
+			}
+			defer client.Close()
+			latency := time.Since(start)
+
+			info := client.ServerInfo()
+			fmt.Printf("✓ Connected to %s %s (protocol %s) in %s\n",
+				info.ServerInfo.Name, info.ServerInfo.Version, info.ProtocolVersion, latency.Round(time.Millisecond))
+			fmt.Printf("  Capabilities: %s\n", formatCapabilities(info.Capabilities))
+
+			toolInfos, err := client.ListTools(ctx)
+			if err != nil {
+				return fmt.Errorf("failed to list tools: %w", err)
+			}
+
+			if len(toolInfos) == 0 {
+				fmt.Println("Server exposes no tools.")
+				return nil
+			}
+
+			fmt.Printf("\nFound %d tools:\n", len(toolInfos))
+			w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+			fmt.Fprintln(w, "TOOL\tDESCRIPTION")
+			fmt.Fprintln(w, "----\t-----------")
+			for _, t := range toolInfos {
+				fmt.Fprintf(w, "%s\t%s\n", mcp.ToolName(name, t.Name), truncate(t.Description, 60))
+			}
+			w.Flush()
+
+			return nil
 		},
-	})
+	}
+}
 
-	return cmd
+func saveMCPServer(name string, server map[string]interface{}) error {
+	configPath, err := getConfigPath()
+	if err != nil {
+		return err
+	}
+
+	data, err := readConfigFile(configPath)
+	if err != nil {
+		return err
+	}
+
+	servers, _ := data["mcp_servers"].(map[string]interface{})
+	if servers == nil {
+		servers = make(map[string]interface{})
+	}
+
+	servers[name] = server
+	data["mcp_servers"] = servers
+
+	if err := writeConfigFile(configPath, data); err != nil {
+		return err
+	}
+
+	fmt.Printf("✓ Added MCP server %s (test it with 'potus mcp test %s')\n", name, name)
+	return nil
+}
+
+func formatCapabilities(caps mcp.ServerCapabilities) string {
+	var names []string
+	if caps.Tools != nil {
+		names = append(names, "tools")
+	}
+	if caps.Resources != nil {
+		names = append(names, "resources")
+	}
+	if caps.Prompts != nil {
+		names = append(names, "prompts")
+	}
+	if caps.Logging != nil {
+		names = append(names, "logging")
+	}
+	if len(names) == 0 {
+		return "none"
+	}
+	return strings.Join(names, ", ")
+}
+
+func parseEnvList(input string) (map[string]string, error) {
+	env := make(map[string]string)
+	for _, pair := range strings.Split(input, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		key, value, ok := strings.Cut(pair, "=")
+		if !ok || key == "" {
+			return nil, fmt.Errorf("invalid environment entry %q (expected KEY=VALUE)", pair)
+		}
+		env[key] = value
+	}
+	return env, nil
+}
+
+func readConfigFile(path string) (map[string]interface{}, error) {
+	data := make(map[string]interface{})
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return data, nil
+		}
+		return nil, fmt.Errorf("failed to read config: %w", err)
+	}
+
+	if err := yaml.Unmarshal(content, &data); err != nil {
+		return nil, fmt.Errorf("failed to parse config: %w", err)
+	}
+	if data == nil {
+		data = make(map[string]interface{})
+	}
+
+	return data, nil
+}
+
+func writeConfigFile(path string, data map[string]interface{}) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create config directory: %w", err)
+	}
+
+	content, err := yaml.Marshal(data)
+	if err != nil {
+		return fmt.Errorf("failed to marshal config: %w", err)
+	}
+
+	if err := os.WriteFile(path, content, 0644); err != nil {
+		return fmt.Errorf("failed to write config: %w", err)
+	}
+
+	return nil
 }
@@ -4,13 +4,18 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"slices"
+	"sort"
+	"strings"
 	"text/tabwriter"
+	"time"
 
 	"github.com/spf13/cobra"
 	"github.com/taaha3244/potus/internal/auth"
 	"github.com/taaha3244/potus/internal/config"
 	"github.com/taaha3244/potus/internal/providers"
 	"github.com/taaha3244/potus/internal/providers/anthropic"
+	"github.com/taaha3244/potus/internal/providers/gemini"
 	"github.com/taaha3244/potus/internal/providers/ollama"
 	"github.com/taaha3244/potus/internal/providers/openai"
 )
@@ -70,6 +75,16 @@ func newProvidersListCmd() *cobra.Command {
 				cfg.Providers["openai"].DefaultModel,
 				endpoint)
 
+			// Check Gemini
+			geminiStatus := "not configured"
+			if apiKey := auth.ResolveAPIKey(authStore, "gemini", cfg.Providers["gemini"].APIKeyEnv); apiKey != "" {
+				geminiStatus = "ready"
+			}
+			fmt.Fprintf(w, "gemini\t%s\t%s\t%s\n",
+				geminiStatus,
+				cfg.Providers["gemini"].DefaultModel,
+				"generativelanguage.googleapis.com")
+
 			// Check Ollama
 			ollamaStatus := "checking..."
 			ollamaEndpoint := cfg.Providers["ollama"].Endpoint
@@ -93,12 +108,323 @@ func newProvidersListCmd() *cobra.Command {
 				cfg.Providers["ollama"].DefaultModel,
 				ollamaEndpoint)
 
+			// Check Azure OpenAI
+			azureStatus := "not configured"
+			if apiKey := auth.ResolveAPIKey(authStore, "azure", cfg.Providers["azure"].APIKeyEnv); apiKey != "" && cfg.Providers["azure"].Endpoint != "" {
+				azureStatus = "ready"
+			}
+			fmt.Fprintf(w, "azure\t%s\t%s\t%s\n",
+				azureStatus,
+				cfg.Providers["azure"].Deployment,
+				cfg.Providers["azure"].Endpoint)
+
+			// Check AWS Bedrock
+			bedrockStatus := "not configured"
+			bedrockRegion := awsRegion(cfg.Providers["bedrock"])
+			// The region is checked first; looking for credentials may
+			// probe instance metadata
+			if bedrockRegion != "" {
+				if _, err := anthropic.LoadAWSCredentials(); err == nil {
+					bedrockStatus = "ready"
+				}
+			}
+			fmt.Fprintf(w, "bedrock\t%s\t%s\t%s\n",
+				bedrockStatus,
+				cfg.Providers["bedrock"].DefaultModel,
+				bedrockRegion)
+
+			// Providers declared in config.yaml
+			for _, name := range customProviderNames(cfg) {
+				pc := cfg.Providers[name]
+				status := "error"
+				if provider, err := newProvider(cfg, authStore, name); err == nil {
+					ctx, cancel := context.WithTimeout(cmd.Context(), providers.DefaultTimeout)
+					if _, err := provider.ListModels(ctx); err == nil {
+						status = "ready"
+					} else {
+						status = "unreachable"
+					}
+					cancel()
+				}
+				fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", name, status, pc.DefaultModel, pc.Endpoint)
+			}
+
 			w.Flush()
 			return nil
 		},
 	}
 }
 
+var builtinProviders = []string{"anthropic", "openai", "gemini", "ollama", "azure", "bedrock", "replay"}
+
+// newProvider creates the named provider from the configuration, with
+// failed requests retried as configured. Names other than the built-in
+// providers must be declared under providers in config.yaml with a type.
+func newProvider(cfg *config.Config, authStore *auth.Store, name string) (providers.Provider, error) {
+	retry, err := retryConfig(cfg.Providers[name].Retry)
+	if err != nil {
+		return nil, fmt.Errorf("provider %s: %w", name, err)
+	}
+
+	provider, err := newClient(cfg, authStore, name)
+	if err != nil {
+		return nil, err
+	}
+	return providers.WithRetry(provider, retry), nil
+}
+
+// retryConfig applies the retry settings of a provider over the defaults.
+func retryConfig(rc config.RetryConfig) (providers.RetryConfig, error) {
+	retry := providers.DefaultRetryConfig()
+	if rc.MaxRetries != nil {
+		retry.MaxRetries = *rc.MaxRetries
+	}
+	if rc.InitialDelay != "" {
+		delay, err := time.ParseDuration(rc.InitialDelay)
+		if err != nil {
+			return retry, fmt.Errorf("invalid retry.initial_delay %q: %w", rc.InitialDelay, err)
+		}
+		retry.InitialDelay = delay
+	}
+	if rc.MaxDelay != "" {
+		delay, err := time.ParseDuration(rc.MaxDelay)
+		if err != nil {
+			return retry, fmt.Errorf("invalid retry.max_delay %q: %w", rc.MaxDelay, err)
+		}
+		retry.MaxDelay = delay
+	}
+	return retry, nil
+}
+
+func newClient(cfg *config.Config, authStore *auth.Store, name string) (providers.Provider, error) {
+	pc := cfg.Providers[name]
+
+	switch pc.Type {
+	case "":
+	case config.ProviderTypeOpenAICompatible:
+		headers := make(map[string]string, len(pc.Headers))
+		for k, v := range pc.Headers {
+			headers[k] = os.ExpandEnv(v)
+		}
+
+		provider, err := openai.NewCompatible(openai.CompatibleConfig{
+			Name:    name,
+			BaseURL: pc.Endpoint,
+			APIKey:  auth.ResolveAPIKey(authStore, name, pc.APIKeyEnv),
+			Headers: headers,
+			Models:  configuredModels(pc),
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to create %s client: %w", name, err)
+		}
+		if err := setAPI(provider, pc); err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		return provider, nil
+	default:
+		return nil, fmt.Errorf("provider %s has unknown type %q (supported: %s)", name, pc.Type, config.ProviderTypeOpenAICompatible)
+	}
+
+	switch name {
+	case "anthropic":
+		apiKey := auth.ResolveAPIKey(authStore, "anthropic", pc.APIKeyEnv)
+		if apiKey == "" {
+			return nil, fmt.Errorf("API key not configured for anthropic (run 'potus auth login')")
+		}
+		provider, err := anthropic.New(apiKey)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create anthropic client: %w", err)
+		}
+		provider.SetModelCache(newModelCache())
+		return provider, nil
+
+	case "openai":
+		apiKey := auth.ResolveAPIKey(authStore, "openai", pc.APIKeyEnv)
+		if apiKey == "" {
+			return nil, fmt.Errorf("API key not configured for openai (run 'potus auth login')")
+		}
+		provider, err := openai.New(apiKey, pc.Organization)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create openai client: %w", err)
+		}
+		provider.SetModelCache(newModelCache())
+		if err := setAPI(provider, pc); err != nil {
+			return nil, fmt.Errorf("openai: %w", err)
+		}
+		return provider, nil
+
+	case "gemini":
+		apiKey := auth.ResolveAPIKey(authStore, "gemini", pc.APIKeyEnv)
+		if apiKey == "" {
+			return nil, fmt.Errorf("API key not configured for gemini (run 'potus auth login gemini')")
+		}
+		provider, err := gemini.New(apiKey)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create gemini client: %w", err)
+		}
+		provider.SetModelCache(newModelCache())
+		return provider, nil
+
+	case "ollama":
+		endpoint := pc.Endpoint
+		if endpoint == "" {
+			endpoint = "http://localhost:11434"
+		}
+		provider, err := ollama.New(endpoint)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create ollama client: %w", err)
+		}
+		return provider, nil
+
+	case "azure":
+		apiKey := auth.ResolveAPIKey(authStore, "azure", pc.APIKeyEnv)
+		if apiKey == "" {
+			return nil, fmt.Errorf("API key not configured for azure (run 'potus auth login azure')")
+		}
+		provider, err := openai.NewAzure(openai.AzureConfig{
+			Endpoint:   pc.Endpoint,
+			APIKey:     apiKey,
+			Deployment: pc.Deployment,
+			APIVersion: pc.APIVersion,
+			Models:     configuredModels(pc),
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to create azure client: %w", err)
+		}
+		if err := setAPI(provider, pc); err != nil {
+			return nil, fmt.Errorf("azure: %w", err)
+		}
+		return provider, nil
+
+	case "replay":
+		return providers.NewReplay(), nil
+
+	case "bedrock":
+		region := awsRegion(pc)
+		if region == "" {
+			return nil, fmt.Errorf("no AWS region configured for bedrock (set providers.bedrock.region or AWS_REGION)")
+		}
+		creds, err := anthropic.LoadAWSCredentials()
+		if err != nil {
+			return nil, fmt.Errorf("bedrock: %w", err)
+		}
+		provider, err := anthropic.NewBedrock(anthropic.BedrockConfig{
+			Region:      region,
+			Credentials: creds,
+			Endpoint:    pc.Endpoint,
+			Models:      configuredModels(pc),
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to create bedrock client: %w", err)
+		}
+		return provider, nil
+	}
+
+	available := append(append([]string{}, builtinProviders...), customProviderNames(cfg)...)
+	return nil, fmt.Errorf("unknown provider: %s (available: %s)", name, strings.Join(available, ", "))
+}
+
+// awsRegion is the configured region, or the one the AWS CLI would use.
+func awsRegion(pc config.ProviderConfig) string {
+	if pc.Region != "" {
+		return pc.Region
+	}
+	if region := os.Getenv("AWS_REGION"); region != "" {
+		return region
+	}
+	return os.Getenv("AWS_DEFAULT_REGION")
+}
+
+// newModelCache keeps the model lists of providers that can list their
+// models, so the API is asked at most once a day.
+func newModelCache() *providers.ModelCache {
+	return providers.NewModelCache(providers.DefaultModelCacheDir(), providers.DefaultModelCacheTTL)
+}
+
+// setAPI applies the api settings of the provider and its models.
+func setAPI(provider *openai.Client, pc config.ProviderConfig) error {
+	modelAPIs := make(map[string]string)
+	for _, m := range pc.Models {
+		if m.API != "" {
+			modelAPIs[m.ID] = m.API
+		}
+	}
+	return provider.SetAPI(pc.API, modelAPIs)
+}
+
+func configuredModels(pc config.ProviderConfig) []providers.Model {
+	models := make([]providers.Model, 0, len(pc.Models))
+	for _, m := range pc.Models {
+		models = append(models, providers.Model{
+			ID:          m.ID,
+			Name:        m.Name,
+			ContextSize: m.ContextSize,
+			Pricing: providers.ModelPricing{
+				InputPer1M:      m.InputPer1M,
+				OutputPer1M:     m.OutputPer1M,
+				CachedPer1M:     m.CachedPer1M,
+				CacheWritePer1M: m.CacheWritePer1M,
+			},
+		})
+	}
+	return models
+}
+
+// newProviderRegistry registers every provider that can be created from the
+// configuration. Providers that aren't usable, e.g. for lack of an API key,
+// are left out.
+func newProviderRegistry(cfg *config.Config, authStore *auth.Store) *providers.Registry {
+	registry := providers.NewRegistry()
+	for _, name := range append(append([]string{}, builtinProviders...), customProviderNames(cfg)...) {
+		if provider, err := newProvider(cfg, authStore, name); err == nil {
+			registry.Register(name, provider)
+		}
+	}
+	return registry
+}
+
+// newFallback chains the primary model with the provider/model entries of
+// agents.default.fallback. Entries whose provider isn't available are
+// skipped with a warning.
+func newFallback(registry *providers.Registry, primary providers.FallbackEntry, chain []string) (providers.Provider, error) {
+	entries := []providers.FallbackEntry{primary}
+	for _, modelStr := range chain {
+		providerName, modelName := providers.ParseModelString(modelStr)
+		if providerName == "" {
+			return nil, fmt.Errorf("fallback model %q must be written as provider/model", modelStr)
+		}
+
+		provider, err := registry.Get(providerName)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: skipping fallback %s: %v\n", modelStr, err)
+			continue
+		}
+		entries = append(entries, providers.FallbackEntry{
+			Label:    modelStr,
+			Provider: provider,
+			Model:    modelName,
+		})
+	}
+
+	if len(entries) == 1 {
+		return primary.Provider, nil
+	}
+	return providers.NewFallback(entries...)
+}
+
+// customProviderNames returns the sorted names of providers declared in
+// config.yaml that aren't built in.
+func customProviderNames(cfg *config.Config) []string {
+	var names []string
+	for name := range cfg.Providers {
+		if !slices.Contains(builtinProviders, name) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
 func newProvidersTestCmd() *cobra.Command {
 	return &cobra.Command{
 		Use:   "test [provider-name]",
@@ -108,7 +434,9 @@ func newProvidersTestCmd() *cobra.Command {
 Examples:
   potus providers test anthropic
   potus providers test openai
-  potus providers test ollama`,
+  potus providers test gemini
+  potus providers test ollama
+  potus providers test vllm`,
 		Args: cobra.ExactArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			providerName := args[0]
@@ -118,42 +446,9 @@ Examples:
 				return fmt.Errorf("failed to load config: %w", err)
 			}
 
-			authStore := auth.NewStore()
-			var provider providers.Provider
-
-			switch providerName {
-			case "anthropic":
-				apiKey := auth.ResolveAPIKey(authStore, "anthropic", cfg.Providers["anthropic"].APIKeyEnv)
-				if apiKey == "" {
-					return fmt.Errorf("API key not configured for anthropic (run 'potus auth login')")
-				}
-				provider, err = anthropic.New(apiKey)
-				if err != nil {
-					return fmt.Errorf("failed to create anthropic client: %w", err)
-				}
-
-			case "openai":
-				apiKey := auth.ResolveAPIKey(authStore, "openai", cfg.Providers["openai"].APIKeyEnv)
-				if apiKey == "" {
-					return fmt.Errorf("API key not configured for openai (run 'potus auth login')")
-				}
-				provider, err = openai.New(apiKey, cfg.Providers["openai"].Organization)
-				if err != nil {
-					return fmt.Errorf("failed to create openai client: %w", err)
-				}
-
-			case "ollama":
-				endpoint := cfg.Providers["ollama"].Endpoint
-				if endpoint == "" {
-					endpoint = "http://localhost:11434"
-				}
-				provider, err = ollama.New(endpoint)
-				if err != nil {
-					return fmt.Errorf("failed to create ollama client: %w", err)
-				}
-
-			default:
-				return fmt.Errorf("unknown provider: %s (available: anthropic, openai, ollama)", providerName)
+			provider, err := newProvider(cfg, auth.NewStore(), providerName)
+			if err != nil {
+				return err
 			}
 
 			fmt.Printf("Testing connection to %s...\n", providerName)
@@ -181,7 +476,9 @@ func newProvidersModelsCmd() *cobra.Command {
 Examples:
   potus providers models anthropic
   potus providers models openai
-  potus providers models ollama`,
+  potus providers models gemini
+  potus providers models ollama
+  potus providers models vllm`,
 		Args: cobra.ExactArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			providerName := args[0]
@@ -191,42 +488,9 @@ Examples:
 				return fmt.Errorf("failed to load config: %w", err)
 			}
 
-			authStore := auth.NewStore()
-			var provider providers.Provider
-
-			switch providerName {
-			case "anthropic":
-				apiKey := auth.ResolveAPIKey(authStore, "anthropic", cfg.Providers["anthropic"].APIKeyEnv)
-				if apiKey == "" {
-					return fmt.Errorf("API key not configured for anthropic (run 'potus auth login')")
-				}
-				provider, err = anthropic.New(apiKey)
-				if err != nil {
-					return fmt.Errorf("failed to create anthropic client: %w", err)
-				}
-
-			case "openai":
-				apiKey := auth.ResolveAPIKey(authStore, "openai", cfg.Providers["openai"].APIKeyEnv)
-				if apiKey == "" {
-					return fmt.Errorf("API key not configured for openai (run 'potus auth login')")
-				}
-				provider, err = openai.New(apiKey, cfg.Providers["openai"].Organization)
-				if err != nil {
-					return fmt.Errorf("failed to create openai client: %w", err)
-				}
-
-			case "ollama":
-				endpoint := cfg.Providers["ollama"].Endpoint
-				if endpoint == "" {
-					endpoint = "http://localhost:11434"
-				}
-				provider, err = ollama.New(endpoint)
-				if err != nil {
-					return fmt.Errorf("failed to create ollama client: %w", err)
-				}
-
-			default:
-				return fmt.Errorf("unknown provider: %s (available: anthropic, openai, ollama)", providerName)
+			provider, err := newProvider(cfg, auth.NewStore(), providerName)
+			if err != nil {
+				return err
 			}
 
 			ctx, cancel := context.WithTimeout(cmd.Context(), providers.DefaultTimeout)
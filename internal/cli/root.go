@@ -4,6 +4,7 @@ import (
 	"fmt"
 
 	"github.com/taaha3244/potus/internal/config"
+	"github.com/taaha3244/potus/internal/mcp"
 	"github.com/spf13/cobra"
 )
 
@@ -13,6 +14,8 @@ var (
 )
 
 func Execute(version, commit, date string) error {
+	mcp.SetClientVersion(version)
+
 	rootCmd := &cobra.Command{
 		Use:   "potus",
 		Short: "Power Of The Universal Shell - AI Coding Agent",
@@ -24,15 +27,20 @@ func Execute(version, commit, date string) error {
 
 	rootCmd.PersistentFlags().StringVar(&cfgFile, "config", "", "config file (default: $HOME/.config/potus/config.yaml)")
 	rootCmd.PersistentFlags().String("model", "", "model to use (e.g., anthropic/claude-sonnet-4-5)")
-	rootCmd.PersistentFlags().String("provider", "", "provider to use (anthropic, openai, ollama)")
+	rootCmd.PersistentFlags().String("provider", "", "provider to use (anthropic, openai, gemini, ollama)")
 	rootCmd.PersistentFlags().String("agent", "default", "agent preset to use")
 	rootCmd.PersistentFlags().String("dir", ".", "working directory")
+	rootCmd.PersistentFlags().String("resume", "", "resume a saved session by ID")
+	rootCmd.PersistentFlags().Bool("continue", false, "continue the most recent session")
+	rootCmd.PersistentFlags().String("record", "", "append provider requests and responses to a fixture file for replay/<fixture>")
 
 	rootCmd.AddCommand(newAuthCmd())
 	rootCmd.AddCommand(newConfigCmd())
 	rootCmd.AddCommand(newProvidersCmd())
 	rootCmd.AddCommand(newToolsCmd())
 	rootCmd.AddCommand(newMCPCmd())
+	rootCmd.AddCommand(newRunCmd())
+	rootCmd.AddCommand(newSessionsCmd())
 
 	return rootCmd.Execute()
 }
@@ -0,0 +1,238 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/taaha3244/potus/internal/agent"
+	"github.com/taaha3244/potus/internal/providers"
+)
+
+func newRunCmd() *cobra.Command {
+	var (
+		confirm string
+		allowed []string
+		output  string
+		schema  string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "run [prompt]",
+		Short: "Run a single prompt without the TUI",
+		Long: `Send one prompt to the agent and stream the response to stdout. Tool
+activity is written to stderr. The prompt is read from stdin when no
+argument is given or the argument is "-".
+
+With --output jsonl every agent event is written to stdout as one JSON
+object per line, for consumption by other programs.
+
+With --schema the answer is JSON matching the given JSON Schema, read from
+a file or given inline. Only the answer is written to stdout; the command
+fails if the answer does not match.
+
+Tools that normally ask for confirmation are resolved by --confirm:
+  deny-all      deny every such tool, ignoring .potus/settings.json
+  allow-listed  allow tools in .potus/settings.json or --allow, deny the rest
+  allow-all     allow everything
+
+Examples:
+  potus run "explain the build process"
+  git diff | potus run "review this change"
+  potus run --confirm allow-listed --allow bash "run the tests and fix failures"
+  potus run --output jsonl "list the packages" | jq -r 'select(.type=="text_delta").content'
+  potus run --schema '{"type":"object","properties":{"packages":{"type":"array"}}}' "list the packages"`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			policy, err := agent.ParseConfirmPolicy(confirm)
+			if err != nil {
+				return err
+			}
+			if output != "text" && output != "jsonl" {
+				return fmt.Errorf("unknown output format %q (use text or jsonl)", output)
+			}
+
+			var format *providers.ResponseFormat
+			if schema != "" {
+				if format, err = loadResponseFormat(schema); err != nil {
+					return err
+				}
+			}
+
+			prompt, err := readPrompt(args, cmd.InOrStdin())
+			if err != nil {
+				return err
+			}
+
+			setup, err := setupAgent(cmd, agentOptions{
+				confirmFn:      agent.PolicyConfirmFunc(policy, allowed),
+				loadSettings:   policy != agent.PolicyDenyAll,
+				responseFormat: format,
+			})
+			if err != nil {
+				return err
+			}
+			defer setup.mcp.Close()
+
+			ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt)
+			defer stop()
+
+			if output == "jsonl" {
+				return runJSONL(ctx, setup.agent, prompt, cmd.OutOrStdout())
+			}
+			err = runHeadless(ctx, setup.agent, prompt, format != nil, cmd.OutOrStdout(), cmd.ErrOrStderr())
+			printSessionHint(setup.agent)
+			return err
+		},
+	}
+
+	cmd.Flags().StringVar(&confirm, "confirm", string(agent.PolicyAllowListed), "confirmation policy: deny-all, allow-listed, allow-all")
+	cmd.Flags().StringVarP(&output, "output", "o", "text", "output format: text, jsonl")
+	cmd.Flags().StringSliceVar(&allowed, "allow", nil, "tool to approve under the allow-listed policy (repeatable)")
+	cmd.Flags().StringVar(&schema, "schema", "", "JSON Schema the answer must match, as a file or inline JSON")
+
+	return cmd
+}
+
+func readPrompt(args []string, stdin io.Reader) (string, error) {
+	if len(args) == 1 && args[0] != "-" {
+		return args[0], nil
+	}
+
+	data, err := io.ReadAll(stdin)
+	if err != nil {
+		return "", fmt.Errorf("failed to read prompt from stdin: %w", err)
+	}
+
+	prompt := strings.TrimSpace(string(data))
+	if prompt == "" {
+		return "", fmt.Errorf("prompt is empty")
+	}
+	return prompt, nil
+}
+
+// loadResponseFormat reads a JSON Schema from a file, or from the value
+// itself when it is a JSON object.
+func loadResponseFormat(value string) (*providers.ResponseFormat, error) {
+	data := []byte(value)
+	if !strings.HasPrefix(strings.TrimSpace(value), "{") {
+		var err error
+		if data, err = os.ReadFile(value); err != nil {
+			return nil, fmt.Errorf("failed to read schema: %w", err)
+		}
+	}
+
+	var schema map[string]interface{}
+	if err := json.Unmarshal(data, &schema); err != nil {
+		return nil, fmt.Errorf("invalid schema: %w", err)
+	}
+	return &providers.ResponseFormat{Name: "response", Schema: schema}, nil
+}
+
+// runHeadless streams one agent turn. It returns an error if the agent
+// reported one, so the process exits non-zero. A structured answer is held
+// back until it has been validated: text written before a tool call goes
+// to stderr, and only a valid final answer goes to stdout.
+func runHeadless(ctx context.Context, ag *agent.Agent, prompt string, structured bool, stdout, stderr io.Writer) error {
+	events, err := ag.ProcessMessage(ctx, prompt)
+	if err != nil {
+		return err
+	}
+
+	var runErr error
+	var answer strings.Builder
+	endsWithNewline := true
+	reasoning := false
+
+	for event := range events {
+		// Finish the streamed reasoning summary before anything else
+		if reasoning && event.Type != agent.EventTypeReasoningDelta {
+			fmt.Fprintln(stderr)
+			reasoning = false
+		}
+
+		switch event.Type {
+		case agent.EventTypeTextDelta:
+			if structured {
+				answer.WriteString(event.Content)
+				continue
+			}
+			fmt.Fprint(stdout, event.Content)
+			if event.Content != "" {
+				endsWithNewline = strings.HasSuffix(event.Content, "\n")
+			}
+
+		case agent.EventTypeReasoningDelta:
+			fmt.Fprint(stderr, event.Content)
+			reasoning = true
+
+		case agent.EventTypeToolCall:
+			if answer.Len() > 0 {
+				fmt.Fprintln(stderr, strings.TrimSpace(answer.String()))
+				answer.Reset()
+			}
+			fmt.Fprintf(stderr, "-> %s\n", event.ToolUse.Name)
+
+		case agent.EventTypeToolResult:
+			status := "ok"
+			if event.ToolResult.IsError {
+				status = "error"
+			}
+			fmt.Fprintf(stderr, "   [%s] %s\n", status, truncate(strings.ReplaceAll(event.ToolResult.Content, "\n", " "), 200))
+
+		case agent.EventTypeContextUpdate, agent.EventTypeRetry:
+			fmt.Fprintf(stderr, "[System] %s\n", event.Content)
+
+		case agent.EventTypeError:
+			runErr = event.Error
+		}
+	}
+
+	if !endsWithNewline {
+		fmt.Fprintln(stdout)
+	}
+
+	if answer.Len() > 0 {
+		if runErr == nil {
+			fmt.Fprintln(stdout, strings.TrimSpace(answer.String()))
+		} else {
+			fmt.Fprintln(stderr, strings.TrimSpace(answer.String()))
+		}
+	}
+
+	if runErr == nil {
+		runErr = ctx.Err()
+	}
+	return runErr
+}
+
+// runJSONL streams one agent turn as JSON Lines. Like runHeadless it
+// returns an error if the agent reported one.
+func runJSONL(ctx context.Context, ag *agent.Agent, prompt string, stdout io.Writer) error {
+	events, err := ag.ProcessMessage(ctx, prompt)
+	if err != nil {
+		return err
+	}
+
+	enc := agent.NewEventEncoder(stdout)
+
+	var runErr error
+	for event := range events {
+		if err := enc.Encode(event); err != nil && runErr == nil {
+			runErr = fmt.Errorf("failed to write event: %w", err)
+		}
+		if event.Type == agent.EventTypeError {
+			runErr = event.Error
+		}
+	}
+
+	if runErr == nil {
+		runErr = ctx.Err()
+	}
+	return runErr
+}
@@ -0,0 +1,235 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	"github.com/taaha3244/potus/internal/providers"
+	"github.com/taaha3244/potus/internal/session"
+)
+
+func newSessionsCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "sessions",
+		Short: "Manage saved conversations",
+		Long: `List, show, fork, and delete conversations saved under .potus/sessions in
+the project directory. Resume one with 'potus --resume <id>' or the most
+recent with 'potus --continue'.`,
+	}
+
+	cmd.AddCommand(newSessionsListCmd())
+	cmd.AddCommand(newSessionsShowCmd())
+	cmd.AddCommand(newSessionsForkCmd())
+	cmd.AddCommand(newSessionsDeleteCmd())
+
+	return cmd
+}
+
+func sessionStoreFromFlags(cmd *cobra.Command) (*session.Store, error) {
+	dirFlag, _ := cmd.Flags().GetString("dir")
+
+	workDir, err := filepath.Abs(dirFlag)
+	if err != nil {
+		return nil, fmt.Errorf("invalid directory: %w", err)
+	}
+	return session.NewStore(workDir), nil
+}
+
+func newSessionsListCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "list",
+		Short: "List saved sessions",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			store, err := sessionStoreFromFlags(cmd)
+			if err != nil {
+				return err
+			}
+
+			sessions, err := store.List()
+			if err != nil {
+				return err
+			}
+
+			if len(sessions) == 0 {
+				fmt.Println("No saved sessions.")
+				return nil
+			}
+
+			w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+			fmt.Fprintln(w, "ID\tUPDATED\tMODEL\tMESSAGES\tCOST\tTITLE")
+			fmt.Fprintln(w, "--\t-------\t-----\t--------\t----\t-----")
+
+			for _, sess := range sessions {
+				fmt.Fprintf(w, "%s\t%s\t%s\t%d\t$%.4f\t%s\n",
+					sess.ID,
+					sess.UpdatedAt.Local().Format("2006-01-02 15:04"),
+					sess.Model,
+					len(sess.Messages),
+					sess.Usage.Cost,
+					truncate(sess.Title, 50))
+			}
+
+			w.Flush()
+			return nil
+		},
+	}
+}
+
+func newSessionsShowCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "show [session-id]",
+		Short: "Show a session transcript",
+		Long: `Print the conversation recorded in a session. A unique prefix of the ID
+is enough.
+
+Examples:
+  potus sessions show 20260102-150405-9f86d0
+  potus sessions show 20260102-1504`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			store, err := sessionStoreFromFlags(cmd)
+			if err != nil {
+				return err
+			}
+
+			sess, err := store.Load(args[0])
+			if err != nil {
+				return err
+			}
+
+			fmt.Printf("Session:  %s\n", sess.ID)
+			fmt.Printf("Title:    %s\n", sess.Title)
+			fmt.Printf("Model:    %s\n", sess.Model)
+			fmt.Printf("Created:  %s\n", sess.CreatedAt.Local().Format("2006-01-02 15:04:05"))
+			fmt.Printf("Updated:  %s\n", sess.UpdatedAt.Local().Format("2006-01-02 15:04:05"))
+			fmt.Printf("Tokens:   %d in / %d out (context %d)\n", sess.Usage.InputTokens, sess.Usage.OutputTokens, sess.Usage.ContextTokens)
+			fmt.Printf("Cost:     $%.4f\n", sess.Usage.Cost)
+			if sess.ForkedFrom != "" {
+				fmt.Printf("Forked:   from %s at message %d\n", sess.ForkedFrom, sess.ForkedAt)
+			}
+
+			for i, msg := range sess.Messages {
+				fmt.Printf("\n#%d %s\n", i, msg.Role)
+				printTranscriptMessage(msg)
+			}
+			return nil
+		},
+	}
+}
+
+func printTranscriptMessage(msg providers.Message) {
+	for _, block := range msg.Content {
+		switch b := block.(type) {
+		case *providers.TextContent:
+			if msg.Role == providers.RoleUser {
+				fmt.Printf("You: %s\n", b.Text)
+			} else {
+				fmt.Printf("POTUS: %s\n", b.Text)
+			}
+		case *providers.ImageContent:
+			fmt.Printf("[image: %s]\n", b.Source.MediaType)
+		case *providers.ReasoningContent:
+			if b.Text != "" {
+				fmt.Printf("(thinking) %s\n", b.Text)
+			}
+		case *providers.ThinkingContent:
+			if b.Redacted() {
+				fmt.Println("(thinking redacted)")
+			} else if b.Thinking != "" {
+				fmt.Printf("(thinking) %s\n", b.Thinking)
+			}
+		case *providers.ToolUseContent:
+			input, _ := json.Marshal(b.Input)
+			fmt.Printf("-> %s %s\n", b.Name, truncate(string(input), 200))
+		case *providers.ToolResultContent:
+			status := "ok"
+			if b.IsError {
+				status = "error"
+			}
+			fmt.Printf("   [%s] %s\n", status, truncate(strings.ReplaceAll(b.Content, "\n", " "), 200))
+		}
+	}
+}
+
+func newSessionsForkCmd() *cobra.Command {
+	var at int
+
+	cmd := &cobra.Command{
+		Use:   "fork [session-id]",
+		Short: "Copy a session into a new one, optionally cut at a message",
+		Long: `Create a new session from an existing one. With --at, only the messages
+before that index are kept, so the conversation can take a different
+direction from that point while the original stays untouched. The index
+must be a user message (see 'potus sessions show').
+
+Examples:
+  potus sessions fork 20260102-150405-9f86d0
+  potus sessions fork 20260102-150405-9f86d0 --at 4`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			store, err := sessionStoreFromFlags(cmd)
+			if err != nil {
+				return err
+			}
+
+			parent, err := store.Load(args[0])
+			if err != nil {
+				return err
+			}
+
+			if !cmd.Flags().Changed("at") {
+				at = len(parent.Messages)
+			}
+			if at < 1 {
+				return fmt.Errorf("--at must keep at least one message")
+			}
+
+			fork, err := session.Fork(parent, at)
+			if err != nil {
+				return err
+			}
+			if err := store.Save(fork); err != nil {
+				return err
+			}
+
+			fmt.Printf("Forked %s into %s with %d messages\n", parent.ID, fork.ID, len(fork.Messages))
+			fmt.Printf("Resume with: potus --resume %s\n", fork.ID)
+			return nil
+		},
+	}
+
+	cmd.Flags().IntVar(&at, "at", 0, "message index to fork at (default: keep all messages)")
+
+	return cmd
+}
+
+func newSessionsDeleteCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "delete [session-id]",
+		Short: "Delete a saved session",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			store, err := sessionStoreFromFlags(cmd)
+			if err != nil {
+				return err
+			}
+
+			sess, err := store.Load(args[0])
+			if err != nil {
+				return err
+			}
+
+			if err := store.Delete(sess.ID); err != nil {
+				return err
+			}
+
+			fmt.Printf("Deleted session %s\n", sess.ID)
+			return nil
+		},
+	}
+}
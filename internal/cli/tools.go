@@ -8,6 +8,7 @@ import (
 
 	"github.com/spf13/cobra"
 	"github.com/taaha3244/potus/internal/config"
+	"github.com/taaha3244/potus/internal/mcp"
 	"github.com/taaha3244/potus/internal/tools"
 	"github.com/taaha3244/potus/internal/tools/bash"
 	"github.com/taaha3244/potus/internal/tools/file"
@@ -152,6 +153,9 @@ func getToolPermission(toolName string, cfg *config.Config) string {
 	case "web_search":
 		return string(cfg.Permissions.WebSearch)
 	default:
+		if mcp.IsToolName(toolName) {
+			return string(cfg.Permissions.MCP)
+		}
 		return "ask"
 	}
 }
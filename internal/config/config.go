@@ -21,22 +21,55 @@ type Config struct {
 }
 
 type ProviderConfig struct {
-	APIKeyEnv    string `mapstructure:"api_key_env"`
-	DefaultModel string `mapstructure:"default_model"`
-	MaxTokens    int    `mapstructure:"max_tokens"`
-	Endpoint     string `mapstructure:"endpoint"`
-	Organization string `mapstructure:"organization"`
-	Region       string `mapstructure:"region"`
-	Deployment   string `mapstructure:"deployment"`
-	APIVersion   string `mapstructure:"api_version"`
+	Type         string            `mapstructure:"type"`
+	APIKeyEnv    string            `mapstructure:"api_key_env"`
+	DefaultModel string            `mapstructure:"default_model"`
+	MaxTokens    int               `mapstructure:"max_tokens"`
+	Endpoint     string            `mapstructure:"endpoint"`
+	Organization string            `mapstructure:"organization"`
+	Region       string            `mapstructure:"region"`
+	Deployment   string            `mapstructure:"deployment"`
+	APIVersion   string            `mapstructure:"api_version"`
+	API          string            `mapstructure:"api"` // chat or responses, for OpenAI-style providers
+	Headers      map[string]string `mapstructure:"headers"`
+	Models       []ModelConfig     `mapstructure:"models"`
+	Retry        RetryConfig       `mapstructure:"retry"`
+}
+
+// Provider types for entries in providers that aren't built in.
+const (
+	ProviderTypeOpenAICompatible = "openai-compatible"
+)
+
+type ModelConfig struct {
+	ID              string  `mapstructure:"id"`
+	Name            string  `mapstructure:"name"`
+	ContextSize     int     `mapstructure:"context_size"`
+	InputPer1M      float64 `mapstructure:"input_per_1m"`
+	OutputPer1M     float64 `mapstructure:"output_per_1m"`
+	CachedPer1M     float64 `mapstructure:"cached_per_1m"`
+	CacheWritePer1M float64 `mapstructure:"cache_write_per_1m"`
+	API             string  `mapstructure:"api"` // overrides the provider's api
+}
+
+// RetryConfig limits retries of failed requests. Unset fields use the
+// defaults; max_retries: 0 disables retrying.
+type RetryConfig struct {
+	MaxRetries   *int   `mapstructure:"max_retries"`
+	InitialDelay string `mapstructure:"initial_delay"`
+	MaxDelay     string `mapstructure:"max_delay"`
 }
 
 type AgentConfig struct {
-	Model        string   `mapstructure:"model"`
-	MaxTokens    int      `mapstructure:"max_tokens"`
-	Temperature  float64  `mapstructure:"temperature"`
-	SystemPrompt string   `mapstructure:"system_prompt"`
-	Tools        []string `mapstructure:"tools"`
+	Model           string   `mapstructure:"model"`
+	MaxTokens       int      `mapstructure:"max_tokens"`
+	Temperature     float64  `mapstructure:"temperature"`
+	ReasoningEffort string   `mapstructure:"reasoning_effort"`
+	ThinkingBudget  int      `mapstructure:"thinking_budget"`
+	Fallback        []string `mapstructure:"fallback"`
+	SystemPrompt    string   `mapstructure:"system_prompt"`
+	Tools           []string `mapstructure:"tools"`
+	ToolChoice      string   `mapstructure:"tool_choice"` // auto, none, required or a tool name
 }
 
 type PermissionConfig struct {
@@ -62,11 +95,14 @@ const (
 )
 
 type MCPServerConfig struct {
-	Command string            `mapstructure:"command"`
-	Args    []string          `mapstructure:"args"`
-	Env     map[string]string `mapstructure:"env"`
-	URL     string            `mapstructure:"url"`
-	Enabled bool              `mapstructure:"enabled"`
+	Command   string            `mapstructure:"command"`
+	Args      []string          `mapstructure:"args"`
+	Env       map[string]string `mapstructure:"env"`
+	URL       string            `mapstructure:"url"`
+	Transport string            `mapstructure:"transport"`
+	Headers   map[string]string `mapstructure:"headers"`
+	Timeout   string            `mapstructure:"timeout"`
+	Enabled   bool              `mapstructure:"enabled"`
 }
 
 type LSPConfig struct {
@@ -166,10 +202,21 @@ func setDefaults(v *viper.Viper) {
 	v.SetDefault("providers.openai.default_model", "gpt-5.2")
 	v.SetDefault("providers.openai.max_tokens", 4096)
 
+	v.SetDefault("providers.gemini.api_key_env", "GEMINI_API_KEY")
+	v.SetDefault("providers.gemini.default_model", "gemini-2.5-pro")
+	v.SetDefault("providers.gemini.max_tokens", 8192)
+
 	v.SetDefault("providers.ollama.endpoint", "http://localhost:11434")
 	v.SetDefault("providers.ollama.default_model", "qwen2.5-coder:32b")
 	v.SetDefault("providers.ollama.max_tokens", 4096)
 
+	v.SetDefault("providers.azure.api_key_env", "AZURE_OPENAI_API_KEY")
+	v.SetDefault("providers.azure.api_version", "2024-10-21")
+	v.SetDefault("providers.azure.max_tokens", 4096)
+
+	v.SetDefault("providers.bedrock.default_model", "us.anthropic.claude-sonnet-4-5-20250929-v1:0")
+	v.SetDefault("providers.bedrock.max_tokens", 8192)
+
 	v.SetDefault("agents.default.model", "anthropic/claude-sonnet-4-5")
 	v.SetDefault("agents.default.max_tokens", 8192)
 	v.SetDefault("agents.default.temperature", 0.7)
@@ -76,6 +76,126 @@ context:
 	}
 }
 
+func TestLoad_AgentPresets(t *testing.T) {
+	tmpDir := t.TempDir()
+	cfgFile := filepath.Join(tmpDir, "config.yaml")
+
+	configContent := `
+agents:
+  planner:
+    tool_choice: none
+  executor:
+    model: openai/gpt-5.2
+    tool_choice: required
+`
+	if err := os.WriteFile(cfgFile, []byte(configContent), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := Load(cfgFile)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	if cfg.Agents["planner"].ToolChoice != "none" {
+		t.Errorf("planner tool_choice = %q, want none", cfg.Agents["planner"].ToolChoice)
+	}
+	if cfg.Agents["executor"].ToolChoice != "required" || cfg.Agents["executor"].Model != "openai/gpt-5.2" {
+		t.Errorf("executor = %+v", cfg.Agents["executor"])
+	}
+	if cfg.Agents["default"].Model == "" {
+		t.Error("default preset lost its model")
+	}
+}
+
+func TestLoad_CompatibleProvider(t *testing.T) {
+	tmpDir := t.TempDir()
+	cfgFile := filepath.Join(tmpDir, "config.yaml")
+
+	configContent := `
+providers:
+  vllm:
+    type: openai-compatible
+    endpoint: http://localhost:8000/v1
+    api_key_env: VLLM_API_KEY
+    headers:
+      X-Team: platform
+    models:
+      - id: qwen2.5-coder-32b
+        context_size: 32768
+        input_per_1m: 0.5
+        output_per_1m: 1.5
+        cached_per_1m: 0.25
+        cache_write_per_1m: 0.6
+`
+	if err := os.WriteFile(cfgFile, []byte(configContent), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := Load(cfgFile)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	vllm := cfg.Providers["vllm"]
+	if vllm.Type != ProviderTypeOpenAICompatible {
+		t.Errorf("expected type openai-compatible, got %s", vllm.Type)
+	}
+	if vllm.Endpoint != "http://localhost:8000/v1" {
+		t.Errorf("expected endpoint, got %s", vllm.Endpoint)
+	}
+	if vllm.Headers["x-team"] != "platform" {
+		t.Errorf("expected header x-team, got %v", vllm.Headers)
+	}
+	if len(vllm.Models) != 1 {
+		t.Fatalf("expected 1 model, got %d", len(vllm.Models))
+	}
+	if m := vllm.Models[0]; m.ID != "qwen2.5-coder-32b" || m.ContextSize != 32768 || m.OutputPer1M != 1.5 || m.CachedPer1M != 0.25 || m.CacheWritePer1M != 0.6 {
+		t.Errorf("unexpected model %+v", m)
+	}
+}
+
+func TestLoad_ProviderRetry(t *testing.T) {
+	tmpDir := t.TempDir()
+	cfgFile := filepath.Join(tmpDir, "config.yaml")
+
+	configContent := `
+providers:
+  anthropic:
+    retry:
+      max_retries: 5
+      initial_delay: 500ms
+      max_delay: 1m
+  ollama:
+    retry:
+      max_retries: 0
+`
+	if err := os.WriteFile(cfgFile, []byte(configContent), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := Load(cfgFile)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	retry := cfg.Providers["anthropic"].Retry
+	if retry.MaxRetries == nil || *retry.MaxRetries != 5 {
+		t.Errorf("expected max_retries 5, got %v", retry.MaxRetries)
+	}
+	if retry.InitialDelay != "500ms" || retry.MaxDelay != "1m" {
+		t.Errorf("unexpected delays %q / %q", retry.InitialDelay, retry.MaxDelay)
+	}
+
+	// An explicit zero disables retries, unlike an unset value
+	if r := cfg.Providers["ollama"].Retry.MaxRetries; r == nil || *r != 0 {
+		t.Errorf("expected max_retries 0 for ollama, got %v", r)
+	}
+	if r := cfg.Providers["openai"].Retry.MaxRetries; r != nil {
+		t.Errorf("expected unset max_retries for openai, got %d", *r)
+	}
+}
+
 func TestPermission_Values(t *testing.T) {
 	tests := []struct {
 		name  string
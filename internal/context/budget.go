@@ -12,8 +12,12 @@ type Budget struct {
 	totalInputTokens   int
 	totalOutputTokens  int
 	sessionCost        float64
+	cacheReadTokens    int
+	cacheWriteTokens   int
 	inputPricePer1M    float64
 	outputPricePer1M   float64
+	cachedPricePer1M   float64
+	cacheWritePer1M    float64
 	warnThreshold      float64
 	compactThreshold   float64
 }
@@ -33,6 +37,8 @@ type BudgetSnapshot struct {
 	SessionInputTokens   int
 	SessionOutputTokens  int
 	SessionCost          float64
+	CacheReadTokens      int
+	CacheWriteTokens     int
 	RemainingTokens      int
 	AtWarningLevel       bool
 	AtCompactLevel       bool
@@ -65,6 +71,15 @@ func (b *Budget) SetPricing(inputPer1M, outputPer1M float64) {
 	b.outputPricePer1M = outputPer1M
 }
 
+// SetCachedPricing sets the price of input tokens read from and written to
+// the prompt cache. Either left at 0 is charged as regular input.
+func (b *Budget) SetCachedPricing(cachedPer1M, cacheWritePer1M float64) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.cachedPricePer1M = cachedPer1M
+	b.cacheWritePer1M = cacheWritePer1M
+}
+
 func (b *Budget) RecordUsage(inputTokens, outputTokens int) {
 	b.mu.Lock()
 	defer b.mu.Unlock()
@@ -77,6 +92,30 @@ func (b *Budget) RecordUsage(inputTokens, outputTokens int) {
 	b.sessionCost += inputCost + outputCost
 }
 
+// RecordCacheUsage adds prompt cache activity reported alongside
+// RecordUsage. Both counts are input tokens not included in the regular
+// input count.
+func (b *Budget) RecordCacheUsage(writeTokens, readTokens int) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.totalInputTokens += writeTokens + readTokens
+	b.cacheWriteTokens += writeTokens
+	b.cacheReadTokens += readTokens
+
+	readPrice := b.cachedPricePer1M
+	if readPrice <= 0 {
+		readPrice = b.inputPricePer1M
+	}
+	writePrice := b.cacheWritePer1M
+	if writePrice <= 0 {
+		writePrice = b.inputPricePer1M
+	}
+	writeCost := float64(writeTokens) / 1_000_000 * writePrice
+	readCost := float64(readTokens) / 1_000_000 * readPrice
+	b.sessionCost += writeCost + readCost
+}
+
 func (b *Budget) GetEffectiveLimit() int {
 	b.mu.RLock()
 	defer b.mu.RUnlock()
@@ -105,6 +144,8 @@ func (b *Budget) GetSnapshot(currentContextTokens int) BudgetSnapshot {
 		SessionInputTokens:   b.totalInputTokens,
 		SessionOutputTokens:  b.totalOutputTokens,
 		SessionCost:          b.sessionCost,
+		CacheReadTokens:      b.cacheReadTokens,
+		CacheWriteTokens:     b.cacheWriteTokens,
 		RemainingTokens:      effectiveMax - currentContextTokens,
 		AtWarningLevel:       usagePercent >= b.warnThreshold*100,
 		AtCompactLevel:       usagePercent >= b.compactThreshold*100,
@@ -146,9 +187,22 @@ func (b *Budget) Reset() {
 	defer b.mu.Unlock()
 	b.totalInputTokens = 0
 	b.totalOutputTokens = 0
+	b.cacheReadTokens = 0
+	b.cacheWriteTokens = 0
 	b.sessionCost = 0
 }
 
+// Restore replaces the session totals, e.g. when resuming a saved session.
+func (b *Budget) Restore(inputTokens, outputTokens, cacheReadTokens, cacheWriteTokens int, cost float64) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.totalInputTokens = inputTokens
+	b.totalOutputTokens = outputTokens
+	b.cacheReadTokens = cacheReadTokens
+	b.cacheWriteTokens = cacheWriteTokens
+	b.sessionCost = cost
+}
+
 func (b *Budget) UpdateModelContextSize(size int) {
 	b.mu.Lock()
 	defer b.mu.Unlock()
@@ -142,6 +142,43 @@ func TestBudget_RecordUsage(t *testing.T) {
 	}
 }
 
+func TestBudget_RecordCacheUsage(t *testing.T) {
+	budget := NewBudget(BudgetConfig{MaxTokens: 100000})
+	budget.SetPricing(3.0, 15.0)
+	budget.SetCachedPricing(0.30, 3.75)
+
+	budget.RecordUsage(1000, 0)
+	budget.RecordCacheUsage(10000, 100000)
+
+	input, _ := budget.GetSessionTokens()
+	if input != 111000 {
+		t.Errorf("SessionInputTokens = %d, want 111000", input)
+	}
+
+	// Input: 1000 / 1M * $3 = $0.003
+	// Cache write: 10000 / 1M * $3.75 = $0.0375
+	// Cache read: 100000 / 1M * $0.30 = $0.03
+	if cost := budget.GetSessionCost(); cost < 0.07049 || cost > 0.07051 {
+		t.Errorf("SessionCost = %f, want 0.0705", cost)
+	}
+
+	snapshot := budget.GetSnapshot(0)
+	if snapshot.CacheWriteTokens != 10000 || snapshot.CacheReadTokens != 100000 {
+		t.Errorf("cache tokens = %d written / %d read, want 10000 / 100000", snapshot.CacheWriteTokens, snapshot.CacheReadTokens)
+	}
+}
+
+func TestBudget_RecordCacheUsage_NoCachedPrice(t *testing.T) {
+	budget := NewBudget(BudgetConfig{MaxTokens: 100000})
+	budget.SetPricing(3.0, 15.0)
+
+	budget.RecordCacheUsage(1_000_000, 1_000_000)
+
+	if cost := budget.GetSessionCost(); cost < 5.999 || cost > 6.001 {
+		t.Errorf("SessionCost = %f, want cache reads and writes charged as input ($6)", cost)
+	}
+}
+
 func TestBudget_GetSnapshot(t *testing.T) {
 	budget := NewBudget(BudgetConfig{
 		MaxTokens:          100000,
@@ -323,6 +360,30 @@ func TestBudget_Reset(t *testing.T) {
 	}
 }
 
+func TestBudget_Restore(t *testing.T) {
+	budget := NewBudget(BudgetConfig{
+		MaxTokens:          100000,
+		ReserveForResponse: 8192,
+	})
+
+	budget.SetPricing(3.0, 15.0)
+	budget.Restore(2000, 1000, 1500, 300, 0.021)
+
+	input, output := budget.GetSessionTokens()
+	if input != 2000 || output != 1000 {
+		t.Errorf("tokens = %d/%d, want 2000/1000", input, output)
+	}
+	snapshot := budget.GetSnapshot(0)
+	if snapshot.CacheReadTokens != 1500 || snapshot.CacheWriteTokens != 300 {
+		t.Errorf("cache tokens = %d read / %d written, want 1500 / 300", snapshot.CacheReadTokens, snapshot.CacheWriteTokens)
+	}
+
+	budget.RecordUsage(1_000_000, 0)
+	if cost := budget.GetSessionCost(); cost < 3.020 || cost > 3.022 {
+		t.Errorf("cost = %f, want restored cost plus new usage", cost)
+	}
+}
+
 func TestBudget_UpdateModelContextSize(t *testing.T) {
 	budget := NewBudget(BudgetConfig{
 		MaxTokens:          100000,
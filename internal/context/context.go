@@ -225,10 +225,22 @@ func (m *Manager) RecordUsage(inputTokens, outputTokens int) {
 	m.budget.RecordUsage(inputTokens, outputTokens)
 }
 
+func (m *Manager) RecordCacheUsage(writeTokens, readTokens int) {
+	m.budget.RecordCacheUsage(writeTokens, readTokens)
+}
+
+func (m *Manager) RestoreUsage(inputTokens, outputTokens, cacheReadTokens, cacheWriteTokens int, cost float64) {
+	m.budget.Restore(inputTokens, outputTokens, cacheReadTokens, cacheWriteTokens, cost)
+}
+
 func (m *Manager) SetPricing(inputPer1M, outputPer1M float64) {
 	m.budget.SetPricing(inputPer1M, outputPer1M)
 }
 
+func (m *Manager) SetCachedPricing(cachedPer1M, cacheWritePer1M float64) {
+	m.budget.SetCachedPricing(cachedPer1M, cacheWritePer1M)
+}
+
 func (m *Manager) GetBudgetSnapshot(currentTokens int) BudgetSnapshot {
 	return m.budget.GetSnapshot(currentTokens)
 }
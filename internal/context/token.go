@@ -53,9 +53,18 @@ func (e *SimpleEstimator) EstimateMessage(msg *providers.Message) int {
 		case *providers.ToolResultContent:
 			total += 10
 			total += e.EstimateTokens(b.Content)
+			total += 1500 * len(b.Images)
 
 		case *providers.ImageContent:
 			total += 1500
+
+		case *providers.ReasoningContent:
+			total += e.EstimateTokens(b.Text)
+			total += e.EstimateTokens(b.Encrypted) / 2
+
+		case *providers.ThinkingContent:
+			total += e.EstimateTokens(b.Thinking)
+			total += e.EstimateTokens(b.Data) / 2
 		}
 	}
 
@@ -0,0 +1,78 @@
+package images
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+
+	"github.com/taaha3244/potus/internal/providers"
+)
+
+// ErrNoClipboardImage is returned when the clipboard holds no image.
+var ErrNoClipboardImage = errors.New("the clipboard holds no image")
+
+// Clipboard returns the image on the system clipboard. It relies on
+// pngpaste or osascript on macOS, and wl-paste or xclip on Linux.
+func Clipboard(ctx context.Context) (*providers.ImageContent, error) {
+	commands := clipboardCommands()
+	for _, args := range commands {
+		if _, err := exec.LookPath(args[0]); err != nil {
+			continue
+		}
+
+		out, err := exec.CommandContext(ctx, args[0], args[1:]...).Output()
+		if err != nil || len(out) == 0 {
+			return nil, ErrNoClipboardImage
+		}
+		if args[0] == "osascript" {
+			if out, err = parseAppleScriptData(out); err != nil {
+				return nil, err
+			}
+		}
+		return Encode(out)
+	}
+
+	if len(commands) == 0 {
+		return nil, fmt.Errorf("pasting images is not supported on %s", runtime.GOOS)
+	}
+	return nil, fmt.Errorf("no clipboard tool found (install %s)", commands[0][0])
+}
+
+func clipboardCommands() [][]string {
+	switch runtime.GOOS {
+	case "darwin":
+		return [][]string{
+			{"pngpaste", "-"},
+			{"osascript", "-e", "get the clipboard as «class PNGf»"},
+		}
+	case "linux", "freebsd", "openbsd", "netbsd":
+		wayland := []string{"wl-paste", "--no-newline", "--type", "image/png"}
+		x11 := []string{"xclip", "-selection", "clipboard", "-target", "image/png", "-out"}
+		if os.Getenv("WAYLAND_DISPLAY") != "" {
+			return [][]string{wayland, x11}
+		}
+		return [][]string{x11, wayland}
+	}
+	return nil
+}
+
+// parseAppleScriptData decodes the «data PNGf89504E47...» form in which
+// osascript prints binary clipboard contents.
+func parseAppleScriptData(out []byte) ([]byte, error) {
+	s := strings.TrimSpace(string(out))
+	if !strings.HasPrefix(s, "«data PNGf") || !strings.HasSuffix(s, "»") {
+		return nil, ErrNoClipboardImage
+	}
+	s = strings.TrimSuffix(strings.TrimPrefix(s, "«data PNGf"), "»")
+
+	data, err := hex.DecodeString(s)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode clipboard data: %w", err)
+	}
+	return data, nil
+}
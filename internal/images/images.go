@@ -0,0 +1,182 @@
+// Package images prepares image files for models that accept them: it
+// detects the media type, scales large images down and base64 encodes
+// the result.
+package images
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"image"
+	"image/color"
+	_ "image/gif"
+	"image/jpeg"
+	"image/png"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/taaha3244/potus/internal/providers"
+)
+
+const (
+	// MaxDimension bounds the longer side of an image. Providers scale
+	// larger images down themselves, so sending them only costs time.
+	MaxDimension = 1568
+
+	// MaxBytes bounds the encoded size of an image; Anthropic rejects
+	// larger ones.
+	MaxBytes = 5 * 1024 * 1024
+
+	jpegQuality = 85
+)
+
+var mediaTypes = map[string]string{
+	".png":  "image/png",
+	".jpg":  "image/jpeg",
+	".jpeg": "image/jpeg",
+	".gif":  "image/gif",
+	".webp": "image/webp",
+}
+
+// IsImagePath reports whether the file extension is that of a supported
+// image format.
+func IsImagePath(path string) bool {
+	_, ok := mediaTypes[strings.ToLower(filepath.Ext(path))]
+	return ok
+}
+
+// Load reads an image file and encodes it with Encode.
+func Load(path string) (*providers.ImageContent, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read image: %w", err)
+	}
+	return Encode(data)
+}
+
+// Encode turns PNG, JPEG, GIF or WebP data into an image block. The media
+// type is detected from the data, not trusted from a file name. Images
+// larger than MaxDimension or MaxBytes are scaled down and re-encoded;
+// WebP images can't be decoded and are sent unchanged.
+func Encode(data []byte) (*providers.ImageContent, error) {
+	mediaType := http.DetectContentType(data)
+	switch mediaType {
+	case "image/png", "image/jpeg", "image/gif", "image/webp":
+	default:
+		return nil, fmt.Errorf("unsupported image type %s (use PNG, JPEG, GIF or WebP)", mediaType)
+	}
+
+	data, mediaType, err := fit(data, mediaType)
+	if err != nil {
+		return nil, err
+	}
+
+	return &providers.ImageContent{
+		Source: providers.ImageSource{
+			Type:      "base64",
+			MediaType: mediaType,
+			Data:      base64.StdEncoding.EncodeToString(data),
+		},
+	}, nil
+}
+
+// Size returns the width and height of an encoded image block.
+func Size(img *providers.ImageContent) (int, int, error) {
+	data, err := base64.StdEncoding.DecodeString(img.Source.Data)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid image data: %w", err)
+	}
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to decode image: %w", err)
+	}
+	return cfg.Width, cfg.Height, nil
+}
+
+func fit(data []byte, mediaType string) ([]byte, string, error) {
+	// The standard library has no WebP decoder
+	if mediaType == "image/webp" {
+		if len(data) > MaxBytes {
+			return nil, "", fmt.Errorf("WebP image is larger than %d MB and can't be scaled down", MaxBytes/(1024*1024))
+		}
+		return data, mediaType, nil
+	}
+
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to decode image: %w", err)
+	}
+	if cfg.Width <= MaxDimension && cfg.Height <= MaxDimension && len(data) <= MaxBytes {
+		return data, mediaType, nil
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to decode image: %w", err)
+	}
+	img = downscale(img, MaxDimension)
+
+	// PNG keeps screenshots and diagrams sharp; photos, and anything PNG
+	// can't fit into MaxBytes, become JPEG
+	var buf bytes.Buffer
+	if mediaType != "image/jpeg" {
+		if err := png.Encode(&buf, img); err != nil {
+			return nil, "", fmt.Errorf("failed to encode image: %w", err)
+		}
+		if buf.Len() <= MaxBytes {
+			return buf.Bytes(), "image/png", nil
+		}
+		buf.Reset()
+	}
+	if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: jpegQuality}); err != nil {
+		return nil, "", fmt.Errorf("failed to encode image: %w", err)
+	}
+	if buf.Len() > MaxBytes {
+		return nil, "", fmt.Errorf("image is larger than %d MB even after scaling down", MaxBytes/(1024*1024))
+	}
+	return buf.Bytes(), "image/jpeg", nil
+}
+
+// downscale shrinks img so that neither side exceeds limit, averaging the
+// source pixels that make up each destination pixel.
+func downscale(img image.Image, limit int) image.Image {
+	bounds := img.Bounds()
+	w, h := bounds.Dx(), bounds.Dy()
+	if w <= limit && h <= limit {
+		return img
+	}
+
+	dw, dh := limit, h*limit/w
+	if h > w {
+		dw, dh = w*limit/h, limit
+	}
+	dw, dh = max(dw, 1), max(dh, 1)
+
+	dst := image.NewRGBA64(image.Rect(0, 0, dw, dh))
+	for y := 0; y < dh; y++ {
+		y0 := bounds.Min.Y + y*h/dh
+		y1 := max(bounds.Min.Y+(y+1)*h/dh, y0+1)
+		for x := 0; x < dw; x++ {
+			x0 := bounds.Min.X + x*w/dw
+			x1 := max(bounds.Min.X+(x+1)*w/dw, x0+1)
+
+			var r, g, b, a, n uint64
+			for sy := y0; sy < y1; sy++ {
+				for sx := x0; sx < x1; sx++ {
+					cr, cg, cb, ca := img.At(sx, sy).RGBA()
+					r, g, b, a = r+uint64(cr), g+uint64(cg), b+uint64(cb), a+uint64(ca)
+					n++
+				}
+			}
+			dst.SetRGBA64(x, y, color.RGBA64{
+				R: uint16(r / n),
+				G: uint16(g / n),
+				B: uint16(b / n),
+				A: uint16(a / n),
+			})
+		}
+	}
+	return dst
+}
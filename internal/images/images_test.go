@@ -0,0 +1,157 @@
+package images
+
+import (
+	"bytes"
+	"encoding/base64"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func encodePNG(t *testing.T, w, h int) []byte {
+	t.Helper()
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			img.Set(x, y, color.RGBA{R: uint8(x), G: uint8(y), B: 128, A: 255})
+		}
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestEncode(t *testing.T) {
+	var photo bytes.Buffer
+	if err := jpeg.Encode(&photo, image.NewRGBA(image.Rect(0, 0, 3000, 2000)), nil); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name          string
+		data          []byte
+		wantMediaType string
+		wantW, wantH  int
+		wantErr       string
+	}{
+		{"small png unchanged", encodePNG(t, 64, 32), "image/png", 64, 32, ""},
+		{"wide png scaled", encodePNG(t, 3136, 200), "image/png", 1568, 100, ""},
+		{"tall png scaled", encodePNG(t, 100, 2000), "image/png", 78, 1568, ""},
+		{"large jpeg scaled", photo.Bytes(), "image/jpeg", 1568, 1045, ""},
+		{"not an image", []byte("package main\n"), "", 0, 0, "unsupported image type text/plain"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			img, err := Encode(tt.data)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Encode() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Encode() error = %v", err)
+			}
+
+			if img.Source.Type != "base64" || img.Source.MediaType != tt.wantMediaType {
+				t.Errorf("source = %s %s, want base64 %s", img.Source.Type, img.Source.MediaType, tt.wantMediaType)
+			}
+			w, h, err := Size(img)
+			if err != nil {
+				t.Fatalf("Size() error = %v", err)
+			}
+			if w != tt.wantW || h != tt.wantH {
+				t.Errorf("size = %dx%d, want %dx%d", w, h, tt.wantW, tt.wantH)
+			}
+		})
+	}
+}
+
+func TestEncode_SmallImageKeepsBytes(t *testing.T) {
+	data := encodePNG(t, 16, 16)
+	img, err := Encode(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if img.Source.Data != base64.StdEncoding.EncodeToString(data) {
+		t.Error("an image within the limits should be sent unchanged")
+	}
+}
+
+func TestDownscale_AveragesPixels(t *testing.T) {
+	src := image.NewRGBA(image.Rect(0, 0, 4, 2))
+	for x := 0; x < 4; x++ {
+		for y := 0; y < 2; y++ {
+			if x%2 == 0 {
+				src.Set(x, y, color.RGBA{A: 255})
+			} else {
+				src.Set(x, y, color.RGBA{R: 255, G: 255, B: 255, A: 255})
+			}
+		}
+	}
+
+	dst := downscale(src, 2)
+	if dst.Bounds().Dx() != 2 || dst.Bounds().Dy() != 1 {
+		t.Fatalf("bounds = %v, want 2x1", dst.Bounds())
+	}
+	r, _, _, _ := dst.At(0, 0).RGBA()
+	if r < 0x7000 || r > 0x9000 {
+		t.Errorf("red = %#x, want about half intensity", r)
+	}
+}
+
+func TestLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "shot.png")
+	if err := os.WriteFile(path, encodePNG(t, 8, 8), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	img, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if img.Source.MediaType != "image/png" {
+		t.Errorf("media type = %s", img.Source.MediaType)
+	}
+
+	if _, err := Load(filepath.Join(t.TempDir(), "missing.png")); err == nil {
+		t.Error("expected an error for a missing file")
+	}
+}
+
+func TestIsImagePath(t *testing.T) {
+	for path, want := range map[string]bool{
+		"shot.png":        true,
+		"docs/Photo.JPEG": true,
+		"anim.gif":        true,
+		"icon.webp":       true,
+		"main.go":         false,
+		"png":             false,
+	} {
+		if got := IsImagePath(path); got != want {
+			t.Errorf("IsImagePath(%q) = %v, want %v", path, got, want)
+		}
+	}
+}
+
+func TestParseAppleScriptData(t *testing.T) {
+	data, err := parseAppleScriptData([]byte("«data PNGf89504E47»\n"))
+	if err != nil {
+		t.Fatalf("parseAppleScriptData() error = %v", err)
+	}
+	if !bytes.Equal(data, []byte{0x89, 0x50, 0x4e, 0x47}) {
+		t.Errorf("data = %x", data)
+	}
+
+	if _, err := parseAppleScriptData([]byte("some text")); err != ErrNoClipboardImage {
+		t.Errorf("error = %v, want ErrNoClipboardImage", err)
+	}
+}
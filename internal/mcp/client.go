@@ -0,0 +1,458 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/taaha3244/potus/internal/config"
+)
+
+const clientName = "potus"
+
+const (
+	TransportStdio = "stdio"
+	TransportHTTP  = "http"
+	TransportSSE   = "sse"
+)
+
+// DefaultRequestTimeout applies to every request unless the server config
+// sets its own timeout.
+const DefaultRequestTimeout = 60 * time.Second
+
+var clientVersion = "1.0.0"
+
+// SetClientVersion sets the version reported to servers during initialize.
+func SetClientVersion(version string) {
+	clientVersion = version
+}
+
+type Client struct {
+	name      string
+	transport Transport
+	nextID    atomic.Int64
+	timeout   time.Duration
+
+	mu       sync.Mutex
+	pending  map[string]chan *Message
+	closed   bool
+	closeErr error
+
+	info *InitializeResult
+	done chan struct{}
+}
+
+func NewClient(name string, transport Transport) *Client {
+	c := &Client{
+		name:      name,
+		transport: transport,
+		pending:   make(map[string]chan *Message),
+		done:      make(chan struct{}),
+	}
+	go c.dispatch()
+	return c
+}
+
+// Connect starts the transport described by cfg and performs the
+// initialize handshake.
+func Connect(ctx context.Context, name string, cfg config.MCPServerConfig) (*Client, error) {
+	transport, err := NewTransport(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := transport.Start(ctx); err != nil {
+		transport.Close()
+		return nil, err
+	}
+
+	timeout, err := RequestTimeout(cfg)
+	if err != nil {
+		transport.Close()
+		return nil, err
+	}
+
+	client := NewClient(name, transport)
+	client.SetRequestTimeout(timeout)
+	if _, err := client.Initialize(ctx); err != nil {
+		client.Close()
+		return nil, err
+	}
+
+	return client, nil
+}
+
+// NewTransport picks a transport for a server configuration: a URL selects
+// streamable HTTP (or legacy SSE when transport is "sse"), otherwise the
+// command is run over stdio.
+func NewTransport(cfg config.MCPServerConfig) (Transport, error) {
+	if cfg.URL != "" {
+		headers := make(map[string]string, len(cfg.Headers))
+		for k, v := range cfg.Headers {
+			headers[k] = os.ExpandEnv(v)
+		}
+
+		switch TransportName(cfg) {
+		case TransportHTTP:
+			return NewHTTPTransport(cfg.URL, headers), nil
+		case TransportSSE:
+			return NewSSETransport(cfg.URL, headers), nil
+		default:
+			return nil, fmt.Errorf("unknown transport: %s", cfg.Transport)
+		}
+	}
+
+	if cfg.Command == "" {
+		return nil, fmt.Errorf("no command or url configured")
+	}
+
+	// The config loader lowercases map keys, but environment variable
+	// names are case-sensitive and conventionally upper case.
+	env := make(map[string]string, len(cfg.Env))
+	for k, v := range cfg.Env {
+		env[strings.ToUpper(k)] = v
+	}
+
+	return NewStdioTransport(cfg.Command, cfg.Args, env), nil
+}
+
+// TransportName reports which transport a configuration will use.
+func TransportName(cfg config.MCPServerConfig) string {
+	if cfg.URL == "" {
+		return TransportStdio
+	}
+	switch strings.ToLower(cfg.Transport) {
+	case "", TransportHTTP, "streamable-http":
+		return TransportHTTP
+	default:
+		return strings.ToLower(cfg.Transport)
+	}
+}
+
+// RequestTimeout parses the per-server timeout, falling back to
+// DefaultRequestTimeout.
+func RequestTimeout(cfg config.MCPServerConfig) (time.Duration, error) {
+	if cfg.Timeout == "" {
+		return DefaultRequestTimeout, nil
+	}
+	timeout, err := time.ParseDuration(cfg.Timeout)
+	if err != nil {
+		return 0, fmt.Errorf("invalid timeout %q: %w", cfg.Timeout, err)
+	}
+	return timeout, nil
+}
+
+func (c *Client) Name() string {
+	return c.name
+}
+
+// SetRequestTimeout bounds every request; zero disables the limit.
+func (c *Client) SetRequestTimeout(timeout time.Duration) {
+	c.timeout = timeout
+}
+
+// ServerInfo returns the result of the initialize handshake, or nil if the
+// client has not been initialized.
+func (c *Client) ServerInfo() *InitializeResult {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.info
+}
+
+func (c *Client) Initialize(ctx context.Context) (*InitializeResult, error) {
+	params := InitializeParams{
+		ProtocolVersion: ProtocolVersion,
+		Capabilities:    map[string]interface{}{},
+		ClientInfo: Implementation{
+			Name:    clientName,
+			Version: clientVersion,
+		},
+	}
+
+	var result InitializeResult
+	if err := c.call(ctx, methodInitialize, params, &result); err != nil {
+		return nil, fmt.Errorf("initialize failed: %w", err)
+	}
+
+	if err := c.notify(ctx, methodInitialized, nil); err != nil {
+		return nil, fmt.Errorf("initialize failed: %w", err)
+	}
+
+	c.mu.Lock()
+	c.info = &result
+	c.mu.Unlock()
+
+	return &result, nil
+}
+
+func (c *Client) ListTools(ctx context.Context) ([]ToolInfo, error) {
+	var tools []ToolInfo
+	cursor := ""
+
+	for {
+		var result listToolsResult
+		if err := c.call(ctx, methodToolsList, listParams{Cursor: cursor}, &result); err != nil {
+			return nil, fmt.Errorf("tools/list failed: %w", err)
+		}
+
+		tools = append(tools, result.Tools...)
+
+		if result.NextCursor == "" || result.NextCursor == cursor {
+			break
+		}
+		cursor = result.NextCursor
+	}
+
+	return tools, nil
+}
+
+func (c *Client) CallTool(ctx context.Context, name string, arguments map[string]interface{}) (*CallToolResult, error) {
+	params := callToolParams{
+		Name:      name,
+		Arguments: arguments,
+	}
+
+	var result CallToolResult
+	if err := c.call(ctx, methodToolsCall, params, &result); err != nil {
+		return nil, fmt.Errorf("tools/call failed: %w", err)
+	}
+
+	return &result, nil
+}
+
+// ListResources returns every resource the server publishes. Servers that
+// did not advertise the resources capability report none.
+func (c *Client) ListResources(ctx context.Context) ([]ResourceInfo, error) {
+	if info := c.ServerInfo(); info != nil && info.Capabilities.Resources == nil {
+		return nil, nil
+	}
+
+	var resources []ResourceInfo
+	cursor := ""
+
+	for {
+		var result listResourcesResult
+		if err := c.call(ctx, methodResourcesList, listParams{Cursor: cursor}, &result); err != nil {
+			return nil, fmt.Errorf("resources/list failed: %w", err)
+		}
+
+		resources = append(resources, result.Resources...)
+
+		if result.NextCursor == "" || result.NextCursor == cursor {
+			break
+		}
+		cursor = result.NextCursor
+	}
+
+	return resources, nil
+}
+
+func (c *Client) ReadResource(ctx context.Context, uri string) (*ReadResourceResult, error) {
+	var result ReadResourceResult
+	if err := c.call(ctx, methodResourcesRead, readResourceParams{URI: uri}, &result); err != nil {
+		return nil, fmt.Errorf("resources/read failed: %w", err)
+	}
+	return &result, nil
+}
+
+// ListPrompts returns every prompt template the server publishes. Servers
+// that did not advertise the prompts capability report none.
+func (c *Client) ListPrompts(ctx context.Context) ([]PromptInfo, error) {
+	if info := c.ServerInfo(); info != nil && info.Capabilities.Prompts == nil {
+		return nil, nil
+	}
+
+	var prompts []PromptInfo
+	cursor := ""
+
+	for {
+		var result listPromptsResult
+		if err := c.call(ctx, methodPromptsList, listParams{Cursor: cursor}, &result); err != nil {
+			return nil, fmt.Errorf("prompts/list failed: %w", err)
+		}
+
+		prompts = append(prompts, result.Prompts...)
+
+		if result.NextCursor == "" || result.NextCursor == cursor {
+			break
+		}
+		cursor = result.NextCursor
+	}
+
+	return prompts, nil
+}
+
+func (c *Client) GetPrompt(ctx context.Context, name string, arguments map[string]string) (*GetPromptResult, error) {
+	params := getPromptParams{
+		Name:      name,
+		Arguments: arguments,
+	}
+
+	var result GetPromptResult
+	if err := c.call(ctx, methodPromptsGet, params, &result); err != nil {
+		return nil, fmt.Errorf("prompts/get failed: %w", err)
+	}
+	return &result, nil
+}
+
+func (c *Client) Close() error {
+	err := c.transport.Close()
+	<-c.done
+	return err
+}
+
+func (c *Client) call(ctx context.Context, method string, params interface{}, result interface{}) error {
+	if c.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.timeout)
+		defer cancel()
+	}
+
+	err := c.roundTrip(ctx, method, params, result)
+	if errors.Is(err, ErrSessionExpired) && method != methodInitialize {
+		if _, initErr := c.Initialize(ctx); initErr != nil {
+			return initErr
+		}
+		err = c.roundTrip(ctx, method, params, result)
+	}
+	return err
+}
+
+func (c *Client) roundTrip(ctx context.Context, method string, params interface{}, result interface{}) error {
+	id := strconv.FormatInt(c.nextID.Add(1), 10)
+
+	rawParams, err := json.Marshal(params)
+	if err != nil {
+		return fmt.Errorf("failed to marshal params: %w", err)
+	}
+
+	respChan := make(chan *Message, 1)
+
+	c.mu.Lock()
+	if c.closed {
+		err := c.closeErr
+		c.mu.Unlock()
+		return err
+	}
+	c.pending[id] = respChan
+	c.mu.Unlock()
+
+	defer func() {
+		c.mu.Lock()
+		delete(c.pending, id)
+		c.mu.Unlock()
+	}()
+
+	msg := &Message{
+		JSONRPC: jsonrpcVersion,
+		ID:      json.RawMessage(id),
+		Method:  method,
+		Params:  rawParams,
+	}
+
+	if err := c.transport.Send(ctx, msg); err != nil {
+		return err
+	}
+
+	select {
+	case resp := <-respChan:
+		if resp == nil {
+			c.mu.Lock()
+			err := c.closeErr
+			c.mu.Unlock()
+			return err
+		}
+		if resp.Error != nil {
+			return resp.Error
+		}
+		if result != nil && len(resp.Result) > 0 {
+			if err := json.Unmarshal(resp.Result, result); err != nil {
+				return fmt.Errorf("failed to parse result: %w", err)
+			}
+		}
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (c *Client) notify(ctx context.Context, method string, params interface{}) error {
+	msg := &Message{
+		JSONRPC: jsonrpcVersion,
+		Method:  method,
+	}
+
+	if params != nil {
+		rawParams, err := json.Marshal(params)
+		if err != nil {
+			return fmt.Errorf("failed to marshal params: %w", err)
+		}
+		msg.Params = rawParams
+	}
+
+	return c.transport.Send(ctx, msg)
+}
+
+func (c *Client) dispatch() {
+	defer close(c.done)
+
+	for msg := range c.transport.Incoming() {
+		switch {
+		case msg.IsResponse():
+			c.mu.Lock()
+			respChan, ok := c.pending[string(msg.ID)]
+			c.mu.Unlock()
+			if ok {
+				select {
+				case respChan <- msg:
+				default:
+				}
+			}
+
+		case msg.IsRequest():
+			c.handleServerRequest(msg)
+		}
+	}
+
+	closeErr := fmt.Errorf("connection to MCP server %s closed", c.name)
+	if st, ok := c.transport.(interface{ Stderr() string }); ok {
+		if stderr := st.Stderr(); stderr != "" {
+			closeErr = fmt.Errorf("connection to MCP server %s closed: %s", c.name, stderr)
+		}
+	}
+
+	c.mu.Lock()
+	c.closed = true
+	c.closeErr = closeErr
+	for id, respChan := range c.pending {
+		close(respChan)
+		delete(c.pending, id)
+	}
+	c.mu.Unlock()
+}
+
+func (c *Client) handleServerRequest(msg *Message) {
+	resp := &Message{
+		JSONRPC: jsonrpcVersion,
+		ID:      msg.ID,
+	}
+
+	switch msg.Method {
+	case methodPing:
+		resp.Result = json.RawMessage("{}")
+	default:
+		resp.Error = &RPCError{
+			Code:    codeMethodNotFound,
+			Message: fmt.Sprintf("method not supported: %s", msg.Method),
+		}
+	}
+
+	go c.transport.Send(context.Background(), resp)
+}
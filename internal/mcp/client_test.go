@@ -0,0 +1,202 @@
+package mcp
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/taaha3244/potus/internal/config"
+)
+
+var fakeServerPath string
+
+func TestMain(m *testing.M) {
+	dir, err := os.MkdirTemp("", "potus-mcp-test")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to create temp dir: %v\n", err)
+		os.Exit(1)
+	}
+
+	fakeServerPath = filepath.Join(dir, "fakeserver")
+	if runtime.GOOS == "windows" {
+		fakeServerPath += ".exe"
+	}
+
+	build := exec.Command("go", "build", "-o", fakeServerPath, "./testdata/fakeserver")
+	build.Stderr = os.Stderr
+	if err := build.Run(); err != nil {
+		fmt.Fprintf(os.Stderr, "failed to build fake server: %v\n", err)
+		os.RemoveAll(dir)
+		os.Exit(1)
+	}
+
+	code := m.Run()
+	os.RemoveAll(dir)
+	os.Exit(code)
+}
+
+func fakeServerConfig() config.MCPServerConfig {
+	return config.MCPServerConfig{
+		Command: fakeServerPath,
+		Env:     map[string]string{"fake_mcp_greeting": "hello from env"},
+		Enabled: true,
+	}
+}
+
+func connectFake(t *testing.T) *Client {
+	t.Helper()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	client, err := Connect(ctx, "fake", fakeServerConfig())
+	if err != nil {
+		t.Fatalf("Connect() error = %v", err)
+	}
+	t.Cleanup(func() { client.Close() })
+	return client
+}
+
+func TestConnect(t *testing.T) {
+	client := connectFake(t)
+
+	info := client.ServerInfo()
+	if info == nil {
+		t.Fatal("expected server info after initialize")
+	}
+	if info.ServerInfo.Name != "fakeserver" {
+		t.Errorf("server name = %s, want fakeserver", info.ServerInfo.Name)
+	}
+	if info.ProtocolVersion != ProtocolVersion {
+		t.Errorf("protocol version = %s, want %s", info.ProtocolVersion, ProtocolVersion)
+	}
+	if info.Capabilities.Tools == nil {
+		t.Error("expected tools capability")
+	}
+}
+
+func TestConnect_Errors(t *testing.T) {
+	t.Run("missing command", func(t *testing.T) {
+		_, err := Connect(context.Background(), "empty", config.MCPServerConfig{})
+		if err == nil {
+			t.Error("expected error for missing command")
+		}
+	})
+
+	t.Run("command not found", func(t *testing.T) {
+		_, err := Connect(context.Background(), "missing", config.MCPServerConfig{
+			Command: filepath.Join(t.TempDir(), "does-not-exist"),
+		})
+		if err == nil {
+			t.Error("expected error for missing binary")
+		}
+	})
+
+	t.Run("server exits during handshake", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		_, err := Connect(ctx, "crashy", config.MCPServerConfig{
+			Command: fakeServerPath,
+			Env:     map[string]string{"FAKE_MCP_CRASH": "boom: missing token"},
+		})
+		if err == nil {
+			t.Fatal("expected error when server exits")
+		}
+		if !strings.Contains(err.Error(), "boom: missing token") {
+			t.Errorf("error should include server stderr, got: %v", err)
+		}
+	})
+}
+
+func TestClient_ListTools(t *testing.T) {
+	client := connectFake(t)
+
+	tools, err := client.ListTools(context.Background())
+	if err != nil {
+		t.Fatalf("ListTools() error = %v", err)
+	}
+
+	var names []string
+	for _, tool := range tools {
+		names = append(names, tool.Name)
+	}
+
+	want := []string{"echo", "fail", "env"}
+	if strings.Join(names, ",") != strings.Join(want, ",") {
+		t.Errorf("tools = %v, want %v (both pages)", names, want)
+	}
+
+	if tools[0].InputSchema["type"] != "object" {
+		t.Errorf("echo schema type = %v, want object", tools[0].InputSchema["type"])
+	}
+}
+
+func TestClient_CallTool(t *testing.T) {
+	client := connectFake(t)
+	ctx := context.Background()
+
+	t.Run("text result", func(t *testing.T) {
+		result, err := client.CallTool(ctx, "echo", map[string]interface{}{"text": "ping"})
+		if err != nil {
+			t.Fatalf("CallTool() error = %v", err)
+		}
+		if result.IsError {
+			t.Error("expected success")
+		}
+		if FormatContent(result.Content) != "ping" {
+			t.Errorf("content = %q, want ping", FormatContent(result.Content))
+		}
+	})
+
+	t.Run("environment passed to server", func(t *testing.T) {
+		result, err := client.CallTool(ctx, "env", nil)
+		if err != nil {
+			t.Fatalf("CallTool() error = %v", err)
+		}
+		if FormatContent(result.Content) != "hello from env" {
+			t.Errorf("content = %q, want env value", FormatContent(result.Content))
+		}
+	})
+
+	t.Run("tool error result", func(t *testing.T) {
+		result, err := client.CallTool(ctx, "fail", nil)
+		if err != nil {
+			t.Fatalf("CallTool() error = %v", err)
+		}
+		if !result.IsError {
+			t.Error("expected IsError")
+		}
+	})
+
+	t.Run("protocol error", func(t *testing.T) {
+		_, err := client.CallTool(ctx, "nope", nil)
+		if err == nil {
+			t.Fatal("expected error for unknown tool")
+		}
+		if !strings.Contains(err.Error(), "unknown tool") {
+			t.Errorf("unexpected error: %v", err)
+		}
+	})
+}
+
+func TestClient_CallAfterClose(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	client, err := Connect(ctx, "fake", fakeServerConfig())
+	if err != nil {
+		t.Fatalf("Connect() error = %v", err)
+	}
+	client.Close()
+
+	if _, err := client.ListTools(ctx); err == nil {
+		t.Error("expected error calling a closed client")
+	}
+}
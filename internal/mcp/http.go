@@ -0,0 +1,601 @@
+package mcp
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrSessionExpired is returned by a transport when the server no longer
+// recognizes the session. The client re-initializes and retries once.
+var ErrSessionExpired = errors.New("MCP session expired")
+
+const (
+	sessionHeader     = "Mcp-Session-Id"
+	lastEventIDHeader = "Last-Event-ID"
+
+	maxSendAttempts      = 3
+	maxReconnectAttempts = 5
+	retryBaseDelay       = 500 * time.Millisecond
+)
+
+var errTransportClosed = errors.New("transport is closed")
+
+// HTTPTransport implements the streamable HTTP transport: every message is
+// POSTed to a single endpoint and the server answers with either a JSON
+// body or an event stream.
+type HTTPTransport struct {
+	url     string
+	headers map[string]string
+	client  *http.Client
+
+	mu        sync.Mutex
+	sessionID string
+	closed    bool
+	sends     sync.WaitGroup
+	incoming  chan *Message
+	done      chan struct{}
+}
+
+func NewHTTPTransport(endpoint string, headers map[string]string) *HTTPTransport {
+	return &HTTPTransport{
+		url:      endpoint,
+		headers:  headers,
+		client:   &http.Client{},
+		incoming: make(chan *Message, 16),
+		done:     make(chan struct{}),
+	}
+}
+
+func (t *HTTPTransport) Start(ctx context.Context) error {
+	if _, err := url.ParseRequestURI(t.url); err != nil {
+		return fmt.Errorf("invalid url: %w", err)
+	}
+	return nil
+}
+
+func (t *HTTPTransport) Incoming() <-chan *Message {
+	return t.incoming
+}
+
+func (t *HTTPTransport) Send(ctx context.Context, msg *Message) error {
+	t.mu.Lock()
+	if t.closed {
+		t.mu.Unlock()
+		return errTransportClosed
+	}
+	t.sends.Add(1)
+	t.mu.Unlock()
+	defer t.sends.Done()
+
+	body, err := json.Marshal(msg)
+	if err != nil {
+		return fmt.Errorf("failed to marshal message: %w", err)
+	}
+
+	var lastErr error
+	for attempt := 0; attempt < maxSendAttempts; attempt++ {
+		if attempt > 0 {
+			if err := sleepContext(ctx, retryDelay(attempt)); err != nil {
+				return err
+			}
+		}
+
+		resp, session, err := t.post(ctx, body)
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			if !isIdempotent(msg) && !isDialError(err) {
+				return err
+			}
+			lastErr = err
+			continue
+		}
+
+		if isRetryableStatus(resp.StatusCode) {
+			resp.Body.Close()
+			if !isIdempotent(msg) {
+				return fmt.Errorf("MCP server returned HTTP %d", resp.StatusCode)
+			}
+			lastErr = fmt.Errorf("HTTP %d", resp.StatusCode)
+			continue
+		}
+
+		return t.handleResponse(ctx, msg, resp, session)
+	}
+
+	return fmt.Errorf("failed to reach MCP server after %d attempts: %w", maxSendAttempts, lastErr)
+}
+
+func (t *HTTPTransport) Close() error {
+	t.mu.Lock()
+	if t.closed {
+		t.mu.Unlock()
+		return nil
+	}
+	t.closed = true
+	session := t.sessionID
+	t.mu.Unlock()
+
+	close(t.done)
+	t.sends.Wait()
+	close(t.incoming)
+
+	// Tell the server the session is over; failures are irrelevant.
+	if session != "" {
+		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+		defer cancel()
+		if req, err := http.NewRequestWithContext(ctx, http.MethodDelete, t.url, nil); err == nil {
+			t.setHeaders(req, session)
+			if resp, err := t.client.Do(req); err == nil {
+				resp.Body.Close()
+			}
+		}
+	}
+
+	return nil
+}
+
+func (t *HTTPTransport) post(ctx context.Context, body []byte) (*http.Response, string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, t.url, bytes.NewReader(body))
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to create request: %w", err)
+	}
+
+	t.mu.Lock()
+	session := t.sessionID
+	t.mu.Unlock()
+
+	t.setHeaders(req, session)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json, text/event-stream")
+
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to send request: %w", err)
+	}
+	return resp, session, nil
+}
+
+func (t *HTTPTransport) handleResponse(ctx context.Context, msg *Message, resp *http.Response, session string) error {
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound && session != "" {
+		t.mu.Lock()
+		if t.sessionID == session {
+			t.sessionID = ""
+		}
+		t.mu.Unlock()
+		return ErrSessionExpired
+	}
+
+	if resp.StatusCode >= 400 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return fmt.Errorf("HTTP %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+
+	if id := resp.Header.Get(sessionHeader); id != "" {
+		t.mu.Lock()
+		t.sessionID = id
+		t.mu.Unlock()
+	}
+
+	if resp.StatusCode == http.StatusAccepted || resp.StatusCode == http.StatusNoContent {
+		return nil
+	}
+
+	if strings.HasPrefix(resp.Header.Get("Content-Type"), "text/event-stream") {
+		return t.readEventStream(ctx, msg, resp.Body)
+	}
+
+	return t.readJSON(resp.Body)
+}
+
+func (t *HTTPTransport) readJSON(body io.Reader) error {
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return fmt.Errorf("failed to read response: %w", err)
+	}
+
+	data = bytes.TrimSpace(data)
+	if len(data) == 0 {
+		return nil
+	}
+
+	if data[0] == '[' {
+		var batch []*Message
+		if err := json.Unmarshal(data, &batch); err != nil {
+			return fmt.Errorf("failed to parse response: %w", err)
+		}
+		for _, m := range batch {
+			t.deliver(m)
+		}
+		return nil
+	}
+
+	var m Message
+	if err := json.Unmarshal(data, &m); err != nil {
+		return fmt.Errorf("failed to parse response: %w", err)
+	}
+	t.deliver(&m)
+	return nil
+}
+
+// readEventStream relays messages from an SSE response until the reply to
+// msg arrives. If the stream drops first it is resumed with Last-Event-ID.
+func (t *HTTPTransport) readEventStream(ctx context.Context, msg *Message, body io.Reader) error {
+	if !msg.IsRequest() {
+		t.consumeStream(body, "")
+		return nil
+	}
+
+	waitingFor := string(msg.ID)
+	answered, lastEventID := t.consumeStream(body, waitingFor)
+
+	for attempt := 1; !answered && lastEventID != "" && attempt < maxSendAttempts; attempt++ {
+		if err := sleepContext(ctx, retryDelay(attempt)); err != nil {
+			return err
+		}
+
+		resp, err := t.resume(ctx, lastEventID)
+		if err != nil {
+			continue
+		}
+
+		var resumedID string
+		answered, resumedID = t.consumeStream(resp.Body, waitingFor)
+		resp.Body.Close()
+		if resumedID != "" {
+			lastEventID = resumedID
+		}
+	}
+
+	if !answered {
+		return fmt.Errorf("MCP server closed the stream before responding")
+	}
+	return nil
+}
+
+func (t *HTTPTransport) consumeStream(body io.Reader, waitingFor string) (answered bool, lastEventID string) {
+	readSSE(body, func(ev sseEvent) bool {
+		if ev.ID != "" {
+			lastEventID = ev.ID
+		}
+		if ev.Event != "" && ev.Event != "message" {
+			return true
+		}
+
+		var m Message
+		if err := json.Unmarshal([]byte(ev.Data), &m); err != nil {
+			return true
+		}
+		t.deliver(&m)
+
+		if waitingFor != "" && m.IsResponse() && string(m.ID) == waitingFor {
+			answered = true
+			return false
+		}
+		return true
+	})
+	return answered, lastEventID
+}
+
+func (t *HTTPTransport) resume(ctx context.Context, lastEventID string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, t.url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	t.mu.Lock()
+	session := t.sessionID
+	t.mu.Unlock()
+
+	t.setHeaders(req, session)
+	req.Header.Set("Accept", "text/event-stream")
+	req.Header.Set(lastEventIDHeader, lastEventID)
+
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("HTTP %d", resp.StatusCode)
+	}
+	return resp, nil
+}
+
+func (t *HTTPTransport) setHeaders(req *http.Request, session string) {
+	for k, v := range t.headers {
+		req.Header.Set(k, v)
+	}
+	if session != "" {
+		req.Header.Set(sessionHeader, session)
+	}
+}
+
+func (t *HTTPTransport) deliver(m *Message) {
+	select {
+	case t.incoming <- m:
+	case <-t.done:
+	}
+}
+
+// SSETransport implements the older HTTP+SSE transport: the client holds a
+// GET event stream open for server messages and POSTs its own messages to
+// the endpoint announced on that stream. A dropped stream is reopened, after
+// which the server expects a fresh initialize.
+type SSETransport struct {
+	url     string
+	headers map[string]string
+	client  *http.Client
+
+	mu        sync.Mutex
+	endpoint  string
+	needsInit bool
+	closed    bool
+
+	ctx       context.Context
+	cancel    context.CancelFunc
+	streams   sync.WaitGroup
+	closeOnce sync.Once
+	incoming  chan *Message
+}
+
+func NewSSETransport(endpoint string, headers map[string]string) *SSETransport {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &SSETransport{
+		url:      endpoint,
+		headers:  headers,
+		client:   &http.Client{},
+		ctx:      ctx,
+		cancel:   cancel,
+		incoming: make(chan *Message, 16),
+	}
+}
+
+func (t *SSETransport) Start(ctx context.Context) error {
+	if _, err := url.ParseRequestURI(t.url); err != nil {
+		return fmt.Errorf("invalid url: %w", err)
+	}
+	return t.connect(ctx)
+}
+
+func (t *SSETransport) Incoming() <-chan *Message {
+	return t.incoming
+}
+
+func (t *SSETransport) Send(ctx context.Context, msg *Message) error {
+	t.mu.Lock()
+	if t.closed {
+		t.mu.Unlock()
+		return errTransportClosed
+	}
+	endpoint := t.endpoint
+	if t.needsInit {
+		switch {
+		case msg.Method == methodInitialize:
+			t.needsInit = false
+		case msg.IsRequest():
+			t.mu.Unlock()
+			return ErrSessionExpired
+		}
+	}
+	t.mu.Unlock()
+
+	body, err := json.Marshal(msg)
+	if err != nil {
+		return fmt.Errorf("failed to marshal message: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	t.setHeaders(req)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return fmt.Errorf("HTTP %d: %s", resp.StatusCode, strings.TrimSpace(string(respBody)))
+	}
+
+	return nil
+}
+
+func (t *SSETransport) Close() error {
+	t.mu.Lock()
+	if t.closed {
+		t.mu.Unlock()
+		return nil
+	}
+	t.closed = true
+	t.mu.Unlock()
+
+	t.cancel()
+	t.streams.Wait()
+	t.closeIncoming()
+	return nil
+}
+
+// connect opens the event stream and waits for the endpoint event. The
+// stream itself lives until the transport is closed, not until ctx ends.
+func (t *SSETransport) connect(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(t.ctx, http.MethodGet, t.url, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	t.setHeaders(req)
+	req.Header.Set("Accept", "text/event-stream")
+
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to open event stream: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		resp.Body.Close()
+		return fmt.Errorf("HTTP %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+
+	endpointChan := make(chan string, 1)
+	t.streams.Add(1)
+	go t.readStream(resp.Body, endpointChan)
+
+	select {
+	case endpoint, ok := <-endpointChan:
+		if !ok {
+			return fmt.Errorf("event stream closed before the server announced a valid endpoint")
+		}
+		t.mu.Lock()
+		t.endpoint = endpoint
+		t.mu.Unlock()
+		return nil
+	case <-ctx.Done():
+		resp.Body.Close()
+		return ctx.Err()
+	}
+}
+
+func (t *SSETransport) readStream(body io.ReadCloser, endpointChan chan<- string) {
+	defer t.streams.Done()
+
+	announced := false
+	readSSE(body, func(ev sseEvent) bool {
+		switch ev.Event {
+		case "endpoint":
+			if announced {
+				return true
+			}
+			endpoint, err := resolveEndpoint(t.url, ev.Data)
+			if err != nil {
+				return false
+			}
+			announced = true
+			endpointChan <- endpoint
+		case "", "message":
+			var m Message
+			if err := json.Unmarshal([]byte(ev.Data), &m); err == nil {
+				select {
+				case t.incoming <- &m:
+				case <-t.ctx.Done():
+					return false
+				}
+			}
+		}
+		return true
+	})
+	body.Close()
+	close(endpointChan)
+
+	// A stream that never became usable is reported by connect; only an
+	// established stream that dropped is worth reopening.
+	if !announced || t.ctx.Err() != nil {
+		return
+	}
+	t.reconnect()
+}
+
+func (t *SSETransport) reconnect() {
+	for attempt := 1; attempt <= maxReconnectAttempts; attempt++ {
+		if err := sleepContext(t.ctx, retryDelay(attempt)); err != nil {
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(t.ctx, DefaultConnectTimeout)
+		err := t.connect(ctx)
+		cancel()
+
+		if err == nil {
+			t.mu.Lock()
+			t.needsInit = true
+			t.mu.Unlock()
+			return
+		}
+	}
+
+	// Out of attempts: closing Incoming lets the client fail pending calls.
+	t.closeIncoming()
+}
+
+func (t *SSETransport) closeIncoming() {
+	t.closeOnce.Do(func() { close(t.incoming) })
+}
+
+func (t *SSETransport) setHeaders(req *http.Request) {
+	for k, v := range t.headers {
+		req.Header.Set(k, v)
+	}
+}
+
+func resolveEndpoint(base, endpoint string) (string, error) {
+	baseURL, err := url.Parse(base)
+	if err != nil {
+		return "", fmt.Errorf("invalid url: %w", err)
+	}
+	ref, err := url.Parse(strings.TrimSpace(endpoint))
+	if err != nil {
+		return "", fmt.Errorf("invalid endpoint %q: %w", endpoint, err)
+	}
+	return baseURL.ResolveReference(ref).String(), nil
+}
+
+func isRetryableStatus(status int) bool {
+	switch status {
+	case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	default:
+		return false
+	}
+}
+
+// isIdempotent reports whether a message can be sent again after a failure
+// that may have reached the server. A tool call can't: a gateway timeout or
+// a dropped connection often means the tool already ran.
+func isIdempotent(msg *Message) bool {
+	switch msg.Method {
+	case "", methodInitialize, methodPing, methodResourcesRead, methodPromptsGet:
+		return true
+	}
+	return strings.HasPrefix(msg.Method, "notifications/") || strings.HasSuffix(msg.Method, "/list")
+}
+
+// isDialError reports whether the connection failed before the request
+// was written, so the server never saw it.
+func isDialError(err error) bool {
+	var opErr *net.OpError
+	return errors.As(err, &opErr) && opErr.Op == "dial"
+}
+
+func retryDelay(attempt int) time.Duration {
+	return retryBaseDelay * time.Duration(1<<(attempt-1))
+}
+
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,512 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/taaha3244/potus/internal/config"
+)
+
+// handleFake answers the subset of MCP used by the HTTP transport tests.
+func handleFake(msg *Message) *Message {
+	resp := &Message{JSONRPC: jsonrpcVersion, ID: msg.ID}
+
+	var result interface{}
+	switch msg.Method {
+	case methodInitialize:
+		result = map[string]interface{}{
+			"protocolVersion": ProtocolVersion,
+			"capabilities":    map[string]interface{}{"tools": map[string]interface{}{}, "resources": map[string]interface{}{}},
+			"serverInfo":      map[string]interface{}{"name": "httpfake", "version": "2.0.0"},
+		}
+	case methodToolsList:
+		result = map[string]interface{}{
+			"tools": []interface{}{
+				map[string]interface{}{"name": "lookup", "inputSchema": map[string]interface{}{"type": "object"}},
+			},
+		}
+	case methodToolsCall:
+		var p callToolParams
+		json.Unmarshal(msg.Params, &p)
+		result = map[string]interface{}{
+			"content": []interface{}{map[string]interface{}{"type": "text", "text": fmt.Sprintf("result for %v", p.Arguments["q"])}},
+		}
+	default:
+		resp.Error = &RPCError{Code: codeMethodNotFound, Message: "method not found"}
+		return resp
+	}
+
+	resp.Result, _ = json.Marshal(result)
+	return resp
+}
+
+type streamableServer struct {
+	mu            sync.Mutex
+	sessions      int
+	session       string
+	headers       http.Header
+	expireSession bool
+	dropStream    bool
+	failures      int
+	hangOnCall    bool
+	deleted       string
+	pending       *Message
+}
+
+func (s *streamableServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.headers = r.Header.Clone()
+
+	switch r.Method {
+	case http.MethodDelete:
+		s.deleted = r.Header.Get(sessionHeader)
+		w.WriteHeader(http.StatusOK)
+		return
+
+	case http.MethodGet:
+		// Resume a dropped stream by replaying the pending response.
+		if r.Header.Get(lastEventIDHeader) != "1" || s.pending == nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		writeEvent(w, "2", s.pending)
+		s.pending = nil
+		return
+	}
+
+	if s.failures > 0 {
+		s.failures--
+		w.WriteHeader(http.StatusServiceUnavailable)
+		return
+	}
+
+	var msg Message
+	json.NewDecoder(r.Body).Decode(&msg)
+
+	if msg.Method == methodInitialize {
+		s.sessions++
+		s.session = fmt.Sprintf("session-%d", s.sessions)
+		w.Header().Set(sessionHeader, s.session)
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(handleFake(&msg))
+		return
+	}
+
+	if r.Header.Get(sessionHeader) != s.session || s.expireSession {
+		s.expireSession = false
+		s.session = ""
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	if !msg.IsRequest() {
+		w.WriteHeader(http.StatusAccepted)
+		return
+	}
+
+	if msg.Method != methodToolsCall {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(handleFake(&msg))
+		return
+	}
+
+	if s.hangOnCall {
+		s.mu.Unlock()
+		<-r.Context().Done()
+		s.mu.Lock()
+		return
+	}
+
+	// Tool calls stream a progress notification before the result.
+	w.Header().Set("Content-Type", "text/event-stream")
+	writeEvent(w, "1", &Message{JSONRPC: jsonrpcVersion, Method: "notifications/progress"})
+
+	if s.dropStream {
+		s.dropStream = false
+		s.pending = handleFake(&msg)
+		return
+	}
+	writeEvent(w, "2", handleFake(&msg))
+}
+
+func writeEvent(w http.ResponseWriter, id string, msg *Message) {
+	data, _ := json.Marshal(msg)
+	fmt.Fprintf(w, "id: %s\nevent: message\ndata: %s\n\n", id, data)
+	if f, ok := w.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func connectHTTP(t *testing.T, cfg config.MCPServerConfig) *Client {
+	t.Helper()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	client, err := Connect(ctx, "remote", cfg)
+	if err != nil {
+		t.Fatalf("Connect() error = %v", err)
+	}
+	t.Cleanup(func() { client.Close() })
+	return client
+}
+
+func TestHTTPTransport(t *testing.T) {
+	t.Setenv("DOCS_TOKEN", "secret-123")
+
+	server := &streamableServer{}
+	ts := httptest.NewServer(server)
+	t.Cleanup(ts.Close)
+
+	client := connectHTTP(t, config.MCPServerConfig{
+		URL:     ts.URL,
+		Headers: map[string]string{"Authorization": "Bearer ${DOCS_TOKEN}"},
+	})
+
+	info := client.ServerInfo()
+	if info.ServerInfo.Name != "httpfake" {
+		t.Errorf("server name = %s, want httpfake", info.ServerInfo.Name)
+	}
+	if info.Capabilities.Resources == nil {
+		t.Error("expected resources capability")
+	}
+
+	tools, err := client.ListTools(context.Background())
+	if err != nil {
+		t.Fatalf("ListTools() error = %v", err)
+	}
+	if len(tools) != 1 || tools[0].Name != "lookup" {
+		t.Errorf("tools = %+v", tools)
+	}
+
+	server.mu.Lock()
+	auth := server.headers.Get("Authorization")
+	session := server.headers.Get(sessionHeader)
+	server.mu.Unlock()
+
+	if auth != "Bearer secret-123" {
+		t.Errorf("Authorization = %q, want expanded env value", auth)
+	}
+	if session != "session-1" {
+		t.Errorf("session header = %q, want session-1", session)
+	}
+
+	result, err := client.CallTool(context.Background(), "lookup", map[string]interface{}{"q": "go"})
+	if err != nil {
+		t.Fatalf("CallTool() error = %v", err)
+	}
+	if FormatContent(result.Content) != "result for go" {
+		t.Errorf("content = %q", FormatContent(result.Content))
+	}
+
+	client.Close()
+
+	server.mu.Lock()
+	deleted := server.deleted
+	server.mu.Unlock()
+	if deleted != "session-1" {
+		t.Errorf("DELETE session = %q, want session-1", deleted)
+	}
+}
+
+func TestHTTPTransport_SessionExpired(t *testing.T) {
+	server := &streamableServer{}
+	ts := httptest.NewServer(server)
+	t.Cleanup(ts.Close)
+
+	client := connectHTTP(t, config.MCPServerConfig{URL: ts.URL})
+
+	server.mu.Lock()
+	server.expireSession = true
+	server.mu.Unlock()
+
+	server.mu.Lock()
+	server.failures = 1
+	server.mu.Unlock()
+
+	if _, err := client.ListTools(context.Background()); err != nil {
+		t.Fatalf("ListTools() after expiry error = %v", err)
+	}
+
+	server.mu.Lock()
+	sessions := server.sessions
+	server.mu.Unlock()
+	if sessions != 2 {
+		t.Errorf("sessions = %d, want re-initialize to open a second session", sessions)
+	}
+}
+
+func TestHTTPTransport_ResumeDroppedStream(t *testing.T) {
+	server := &streamableServer{dropStream: true}
+	ts := httptest.NewServer(server)
+	t.Cleanup(ts.Close)
+
+	client := connectHTTP(t, config.MCPServerConfig{URL: ts.URL})
+
+	result, err := client.CallTool(context.Background(), "lookup", map[string]interface{}{"q": "resume"})
+	if err != nil {
+		t.Fatalf("CallTool() error = %v", err)
+	}
+	if FormatContent(result.Content) != "result for resume" {
+		t.Errorf("content = %q", FormatContent(result.Content))
+	}
+}
+
+func TestHTTPTransport_RetriesUnavailable(t *testing.T) {
+	server := &streamableServer{failures: 2}
+	ts := httptest.NewServer(server)
+	t.Cleanup(ts.Close)
+
+	connectHTTP(t, config.MCPServerConfig{URL: ts.URL})
+}
+
+func TestHTTPTransport_DoesNotRetryToolCalls(t *testing.T) {
+	server := &streamableServer{}
+	ts := httptest.NewServer(server)
+	t.Cleanup(ts.Close)
+
+	client := connectHTTP(t, config.MCPServerConfig{URL: ts.URL})
+
+	server.mu.Lock()
+	server.failures = 1
+	server.mu.Unlock()
+
+	if _, err := client.CallTool(context.Background(), "lookup", map[string]interface{}{"q": "x"}); err == nil {
+		t.Fatal("expected the 503 to be returned rather than the call sent again")
+	}
+
+	server.mu.Lock()
+	server.failures = 1
+	server.mu.Unlock()
+
+	if _, err := client.ListTools(context.Background()); err != nil {
+		t.Errorf("ListTools() error = %v", err)
+	}
+}
+
+func TestIsIdempotent(t *testing.T) {
+	for method, want := range map[string]bool{
+		methodInitialize:    true,
+		methodInitialized:   true,
+		methodPing:          true,
+		methodToolsList:     true,
+		methodResourcesList: true,
+		methodResourcesRead: true,
+		methodToolsCall:     false,
+	} {
+		if got := isIdempotent(&Message{Method: method}); got != want {
+			t.Errorf("isIdempotent(%s) = %v, want %v", method, got, want)
+		}
+	}
+}
+
+func TestHTTPTransport_RequestTimeout(t *testing.T) {
+	server := &streamableServer{hangOnCall: true}
+	ts := httptest.NewServer(server)
+	t.Cleanup(ts.Close)
+
+	client := connectHTTP(t, config.MCPServerConfig{URL: ts.URL, Timeout: "200ms"})
+
+	start := time.Now()
+	_, err := client.CallTool(context.Background(), "lookup", nil)
+	if err == nil {
+		t.Fatal("expected timeout error")
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("timeout took %v", elapsed)
+	}
+}
+
+type legacySSEServer struct {
+	mu       sync.Mutex
+	streams  int
+	dropNext bool
+	queues   map[string]chan *Message
+}
+
+func (s *legacySSEServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch {
+	case r.Method == http.MethodGet && r.URL.Path == "/sse":
+		s.mu.Lock()
+		s.streams++
+		id := fmt.Sprintf("%d", s.streams)
+		queue := make(chan *Message, 16)
+		s.queues[id] = queue
+		s.mu.Unlock()
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprintf(w, "event: endpoint\ndata: /messages?session=%s\n\n", id)
+		w.(http.Flusher).Flush()
+
+		for {
+			select {
+			case msg := <-queue:
+				data, _ := json.Marshal(msg)
+				fmt.Fprintf(w, "event: message\ndata: %s\n\n", data)
+				w.(http.Flusher).Flush()
+
+				s.mu.Lock()
+				drop := s.dropNext
+				s.dropNext = false
+				s.mu.Unlock()
+				if drop {
+					return
+				}
+			case <-r.Context().Done():
+				return
+			}
+		}
+
+	case r.Method == http.MethodPost && r.URL.Path == "/messages":
+		s.mu.Lock()
+		queue := s.queues[r.URL.Query().Get("session")]
+		s.mu.Unlock()
+
+		if queue == nil {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		var msg Message
+		json.NewDecoder(r.Body).Decode(&msg)
+		w.WriteHeader(http.StatusAccepted)
+
+		if msg.IsRequest() {
+			queue <- handleFake(&msg)
+		}
+
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func TestSSETransport(t *testing.T) {
+	server := &legacySSEServer{queues: make(map[string]chan *Message)}
+	ts := httptest.NewServer(server)
+	t.Cleanup(ts.Close)
+
+	client := connectHTTP(t, config.MCPServerConfig{URL: ts.URL + "/sse", Transport: "sse"})
+
+	if client.ServerInfo().ServerInfo.Name != "httpfake" {
+		t.Errorf("unexpected server info: %+v", client.ServerInfo())
+	}
+
+	// Drop the stream right after this response; the transport should
+	// reconnect and the next call should re-initialize transparently.
+	server.mu.Lock()
+	server.dropNext = true
+	server.mu.Unlock()
+
+	if _, err := client.ListTools(context.Background()); err != nil {
+		t.Fatalf("ListTools() error = %v", err)
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		server.mu.Lock()
+		streams := server.streams
+		server.mu.Unlock()
+		if streams >= 2 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("transport did not reconnect")
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+
+	result, err := client.CallTool(context.Background(), "lookup", map[string]interface{}{"q": "again"})
+	if err != nil {
+		t.Fatalf("CallTool() after reconnect error = %v", err)
+	}
+	if FormatContent(result.Content) != "result for again" {
+		t.Errorf("content = %q", FormatContent(result.Content))
+	}
+}
+
+func TestNewTransport(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     config.MCPServerConfig
+		want    string
+		wantErr bool
+	}{
+		{name: "command", cfg: config.MCPServerConfig{Command: "server"}, want: TransportStdio},
+		{name: "url defaults to http", cfg: config.MCPServerConfig{URL: "https://mcp.example.com/mcp"}, want: TransportHTTP},
+		{name: "streamable alias", cfg: config.MCPServerConfig{URL: "https://x", Transport: "streamable-http"}, want: TransportHTTP},
+		{name: "sse", cfg: config.MCPServerConfig{URL: "https://x/sse", Transport: "SSE"}, want: TransportSSE},
+		{name: "unknown transport", cfg: config.MCPServerConfig{URL: "https://x", Transport: "grpc"}, wantErr: true},
+		{name: "nothing configured", cfg: config.MCPServerConfig{}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewTransport(tt.cfg)
+			if tt.wantErr {
+				if err == nil {
+					t.Error("expected error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("NewTransport() error = %v", err)
+			}
+			if got := TransportName(tt.cfg); got != tt.want {
+				t.Errorf("TransportName() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRequestTimeout(t *testing.T) {
+	if d, _ := RequestTimeout(config.MCPServerConfig{}); d != DefaultRequestTimeout {
+		t.Errorf("default timeout = %v", d)
+	}
+	if d, _ := RequestTimeout(config.MCPServerConfig{Timeout: "5s"}); d != 5*time.Second {
+		t.Errorf("timeout = %v, want 5s", d)
+	}
+	if _, err := RequestTimeout(config.MCPServerConfig{Timeout: "soon"}); err == nil {
+		t.Error("expected error for invalid timeout")
+	}
+}
+
+func TestReadSSE(t *testing.T) {
+	stream := ": comment\n" +
+		"id: 7\n" +
+		"event: message\n" +
+		"data: line one\n" +
+		"data: line two\n" +
+		"\n" +
+		"data: no event name\n" +
+		"\n" +
+		"data: incomplete"
+
+	var events []sseEvent
+	err := readSSE(strings.NewReader(stream), func(ev sseEvent) bool {
+		events = append(events, ev)
+		return true
+	})
+	if err != nil {
+		t.Fatalf("readSSE() error = %v", err)
+	}
+
+	if len(events) != 2 {
+		t.Fatalf("got %d events, want 2: %+v", len(events), events)
+	}
+	if events[0].ID != "7" || events[0].Event != "message" || events[0].Data != "line one\nline two" {
+		t.Errorf("first event = %+v", events[0])
+	}
+	if events[1].ID != "7" || events[1].Event != "" || events[1].Data != "no event name" {
+		t.Errorf("second event = %+v", events[1])
+	}
+}
@@ -0,0 +1,123 @@
+package mcp
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/taaha3244/potus/internal/config"
+	"github.com/taaha3244/potus/internal/tools"
+)
+
+// DefaultConnectTimeout bounds how long a single server may take to start
+// and complete the handshake.
+const DefaultConnectTimeout = 30 * time.Second
+
+// Manager owns the clients for every configured server.
+type Manager struct {
+	mu      sync.Mutex
+	clients map[string]*Client
+}
+
+func NewManager() *Manager {
+	return &Manager{
+		clients: make(map[string]*Client),
+	}
+}
+
+// Start connects to every enabled server and registers its tools. A server
+// that fails to start does not prevent the others from loading; its error
+// is returned keyed by server name.
+func (m *Manager) Start(ctx context.Context, servers map[string]config.MCPServerConfig, registry *tools.Registry) map[string]error {
+	errs := make(map[string]error)
+
+	names := make([]string, 0, len(servers))
+	for name, server := range servers {
+		if server.Enabled {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		if err := m.startServer(ctx, name, servers[name], registry); err != nil {
+			errs[name] = err
+		}
+	}
+
+	return errs
+}
+
+func (m *Manager) startServer(ctx context.Context, name string, server config.MCPServerConfig, registry *tools.Registry) error {
+	connectCtx, cancel := context.WithTimeout(ctx, DefaultConnectTimeout)
+	defer cancel()
+
+	client, err := Connect(connectCtx, name, server)
+	if err != nil {
+		return err
+	}
+
+	infos, err := client.ListTools(connectCtx)
+	if err != nil {
+		client.Close()
+		return err
+	}
+
+	registerTools(registry, client, infos)
+
+	m.mu.Lock()
+	m.clients[name] = client
+	m.mu.Unlock()
+
+	return nil
+}
+
+// registerTools adds the tools of a server to the registry. A tool whose
+// name is already taken gets a unique one instead of replacing the other.
+func registerTools(registry *tools.Registry, client *Client, infos []ToolInfo) {
+	for _, info := range infos {
+		tool := NewTool(client, info)
+		tool.name = uniqueToolName(tool.name, tool.server, info.Name, func(name string) bool {
+			_, err := registry.Get(name)
+			return err == nil
+		})
+		registry.Register(tool)
+	}
+}
+
+func (m *Manager) Get(name string) (*Client, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	client, ok := m.clients[name]
+	if !ok {
+		return nil, fmt.Errorf("MCP server not connected: %s", name)
+	}
+	return client, nil
+}
+
+func (m *Manager) List() []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	names := make([]string, 0, len(m.clients))
+	for name := range m.clients {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (m *Manager) Close() error {
+	m.mu.Lock()
+	clients := m.clients
+	m.clients = make(map[string]*Client)
+	m.mu.Unlock()
+
+	for _, client := range clients {
+		client.Close()
+	}
+	return nil
+}
@@ -0,0 +1,172 @@
+package mcp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Resource is a resource entry together with the server that publishes it.
+type Resource struct {
+	Server string
+	ResourceInfo
+}
+
+// Prompt is a prompt template together with the server that publishes it.
+type Prompt struct {
+	Server string
+	PromptInfo
+}
+
+// Command is the slash command that runs the prompt, e.g.
+// "/mcp__docs__summarize".
+func (p Prompt) Command() string {
+	return "/" + ToolName(p.Server, p.Name)
+}
+
+// Resources lists the resources of every connected server. A failing server
+// does not hide the others; its error is joined into the returned error.
+func (m *Manager) Resources(ctx context.Context) ([]Resource, error) {
+	var resources []Resource
+	var errs []error
+
+	for _, client := range m.sortedClients() {
+		infos, err := client.ListResources(ctx)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", client.Name(), err))
+			continue
+		}
+		for _, info := range infos {
+			resources = append(resources, Resource{Server: client.Name(), ResourceInfo: info})
+		}
+	}
+
+	return resources, errors.Join(errs...)
+}
+
+// Prompts lists the prompt templates of every connected server.
+func (m *Manager) Prompts(ctx context.Context) ([]Prompt, error) {
+	var prompts []Prompt
+	var errs []error
+
+	for _, client := range m.sortedClients() {
+		infos, err := client.ListPrompts(ctx)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", client.Name(), err))
+			continue
+		}
+		for _, info := range infos {
+			prompts = append(prompts, Prompt{Server: client.Name(), PromptInfo: info})
+		}
+	}
+
+	return prompts, errors.Join(errs...)
+}
+
+// ReadResource fetches a resource and renders its contents as text.
+func (m *Manager) ReadResource(ctx context.Context, server, uri string) (string, error) {
+	client, err := m.Get(server)
+	if err != nil {
+		return "", err
+	}
+
+	result, err := client.ReadResource(ctx, uri)
+	if err != nil {
+		return "", err
+	}
+
+	return FormatResourceContents(result.Contents), nil
+}
+
+// GetPrompt expands a prompt template and renders its messages as text.
+func (m *Manager) GetPrompt(ctx context.Context, server, name string, arguments map[string]string) (string, error) {
+	client, err := m.Get(server)
+	if err != nil {
+		return "", err
+	}
+
+	result, err := client.GetPrompt(ctx, name, arguments)
+	if err != nil {
+		return "", err
+	}
+
+	return FormatPromptMessages(result.Messages), nil
+}
+
+func (m *Manager) sortedClients() []*Client {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	clients := make([]*Client, 0, len(m.clients))
+	for _, client := range m.clients {
+		clients = append(clients, client)
+	}
+	sort.Slice(clients, func(i, j int) bool {
+		return clients[i].Name() < clients[j].Name()
+	})
+	return clients
+}
+
+// PromptArguments maps positional command arguments onto the prompt's
+// declared arguments in order; the last declared argument receives any
+// remaining words.
+func PromptArguments(prompt PromptInfo, words []string) (map[string]string, error) {
+	args := make(map[string]string)
+
+	for i, arg := range prompt.Arguments {
+		if i >= len(words) {
+			if arg.Required {
+				return nil, fmt.Errorf("missing required argument: %s", arg.Name)
+			}
+			continue
+		}
+		if i == len(prompt.Arguments)-1 {
+			args[arg.Name] = strings.Join(words[i:], " ")
+		} else {
+			args[arg.Name] = words[i]
+		}
+	}
+
+	if len(prompt.Arguments) == 0 && len(words) > 0 {
+		return nil, fmt.Errorf("prompt %s takes no arguments", prompt.Name)
+	}
+
+	return args, nil
+}
+
+// FormatResourceContents renders resource contents as text. Binary blobs
+// are summarized rather than inlined.
+func FormatResourceContents(contents []ResourceContents) string {
+	parts := make([]string, 0, len(contents))
+
+	for _, c := range contents {
+		if c.Blob != "" {
+			parts = append(parts, fmt.Sprintf("[binary resource: %s, %s, %d bytes base64]", c.URI, c.MimeType, len(c.Blob)))
+			continue
+		}
+		parts = append(parts, c.Text)
+	}
+
+	return strings.Join(parts, "\n")
+}
+
+// FormatPromptMessages flattens the messages of an expanded prompt into a
+// single block of text suitable for sending as the user's message.
+func FormatPromptMessages(messages []PromptMessage) string {
+	parts := make([]string, 0, len(messages))
+
+	for _, msg := range messages {
+		text := FormatContent([]ContentItem{msg.Content})
+		if text == "" {
+			continue
+		}
+		if msg.Role == "assistant" {
+			text = "Assistant: " + text
+		}
+		parts = append(parts, text)
+	}
+
+	return strings.Join(parts, "\n\n")
+}
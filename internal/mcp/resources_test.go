@@ -0,0 +1,139 @@
+package mcp
+
+import (
+	"context"
+	"testing"
+
+	"github.com/taaha3244/potus/internal/config"
+	"github.com/taaha3244/potus/internal/tools"
+)
+
+func startFakeManager(t *testing.T) *Manager {
+	t.Helper()
+
+	manager := NewManager()
+	t.Cleanup(func() { manager.Close() })
+
+	errs := manager.Start(context.Background(), map[string]config.MCPServerConfig{"fake": fakeServerConfig()}, tools.NewRegistry())
+	if len(errs) > 0 {
+		t.Fatalf("Start() errors = %v", errs)
+	}
+	return manager
+}
+
+func TestManager_Resources(t *testing.T) {
+	manager := startFakeManager(t)
+	ctx := context.Background()
+
+	resources, err := manager.Resources(ctx)
+	if err != nil {
+		t.Fatalf("Resources() error = %v", err)
+	}
+	if len(resources) != 1 || resources[0].Server != "fake" || resources[0].URI != "docs://guide" {
+		t.Fatalf("resources = %+v", resources)
+	}
+
+	text, err := manager.ReadResource(ctx, "fake", "docs://guide")
+	if err != nil {
+		t.Fatalf("ReadResource() error = %v", err)
+	}
+	if text != "# Guide\nUse the echo tool." {
+		t.Errorf("text = %q", text)
+	}
+
+	if _, err := manager.ReadResource(ctx, "fake", "docs://missing"); err == nil {
+		t.Error("expected error for unknown resource")
+	}
+	if _, err := manager.ReadResource(ctx, "nope", "docs://guide"); err == nil {
+		t.Error("expected error for unknown server")
+	}
+}
+
+func TestManager_Prompts(t *testing.T) {
+	manager := startFakeManager(t)
+	ctx := context.Background()
+
+	prompts, err := manager.Prompts(ctx)
+	if err != nil {
+		t.Fatalf("Prompts() error = %v", err)
+	}
+	if len(prompts) != 1 || prompts[0].Name != "review" {
+		t.Fatalf("prompts = %+v", prompts)
+	}
+	if cmd := prompts[0].Command(); cmd != "/mcp__fake__review" {
+		t.Errorf("Command() = %s", cmd)
+	}
+
+	args, err := PromptArguments(prompts[0].PromptInfo, []string{"main.go", "error", "handling"})
+	if err != nil {
+		t.Fatalf("PromptArguments() error = %v", err)
+	}
+
+	text, err := manager.GetPrompt(ctx, "fake", "review", args)
+	if err != nil {
+		t.Fatalf("GetPrompt() error = %v", err)
+	}
+	if text != "Review main.go focusing on error handling" {
+		t.Errorf("text = %q", text)
+	}
+}
+
+func TestPromptArguments(t *testing.T) {
+	prompt := PromptInfo{
+		Name: "review",
+		Arguments: []PromptArgument{
+			{Name: "file", Required: true},
+			{Name: "focus"},
+		},
+	}
+
+	tests := []struct {
+		name    string
+		prompt  PromptInfo
+		words   []string
+		want    map[string]string
+		wantErr bool
+	}{
+		{name: "all arguments", prompt: prompt, words: []string{"a.go", "b"}, want: map[string]string{"file": "a.go", "focus": "b"}},
+		{name: "optional omitted", prompt: prompt, words: []string{"a.go"}, want: map[string]string{"file": "a.go"}},
+		{name: "last takes the rest", prompt: prompt, words: []string{"a.go", "x", "y"}, want: map[string]string{"file": "a.go", "focus": "x y"}},
+		{name: "missing required", prompt: prompt, words: nil, wantErr: true},
+		{name: "no arguments", prompt: PromptInfo{Name: "hi"}, words: nil, want: map[string]string{}},
+		{name: "unexpected arguments", prompt: PromptInfo{Name: "hi"}, words: []string{"x"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := PromptArguments(tt.prompt, tt.words)
+			if tt.wantErr {
+				if err == nil {
+					t.Error("expected error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("PromptArguments() error = %v", err)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %v, want %v", got, tt.want)
+			}
+			for k, v := range tt.want {
+				if got[k] != v {
+					t.Errorf("%s = %q, want %q", k, got[k], v)
+				}
+			}
+		})
+	}
+}
+
+func TestFormatResourceContents(t *testing.T) {
+	got := FormatResourceContents([]ResourceContents{
+		{URI: "docs://a", Text: "hello"},
+		{URI: "docs://logo", MimeType: "image/png", Blob: "aGVsbG8="},
+	})
+
+	want := "hello\n[binary resource: docs://logo, image/png, 8 bytes base64]"
+	if got != want {
+		t.Errorf("FormatResourceContents() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,60 @@
+package mcp
+
+import (
+	"bufio"
+	"io"
+	"strings"
+)
+
+type sseEvent struct {
+	ID    string
+	Event string
+	Data  string
+}
+
+// readSSE parses a text/event-stream body, calling fn for every complete
+// event. It returns when the stream ends or fn returns false; a nil error
+// does not mean the caller saw everything it was waiting for.
+func readSSE(r io.Reader, fn func(sseEvent) bool) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxMessageSize)
+
+	var event sseEvent
+	var data []string
+
+	for scanner.Scan() {
+		line := scanner.Text()
+
+		if line == "" {
+			if len(data) > 0 || event.Event != "" {
+				event.Data = strings.Join(data, "\n")
+				if !fn(event) {
+					return nil
+				}
+			}
+			event = sseEvent{ID: event.ID}
+			data = data[:0]
+			continue
+		}
+
+		if strings.HasPrefix(line, ":") {
+			continue
+		}
+
+		field, value, _ := strings.Cut(line, ":")
+		value = strings.TrimPrefix(value, " ")
+
+		switch field {
+		case "event":
+			event.Event = value
+		case "data":
+			data = append(data, value)
+		case "id":
+			event.ID = value
+		}
+	}
+
+	// An event without its terminating blank line is incomplete and is
+	// discarded, as the SSE spec requires.
+	return scanner.Err()
+}
@@ -0,0 +1,206 @@
+// Command fakeserver is a minimal MCP server used by the mcp package tests.
+// It speaks newline-delimited JSON-RPC over stdin and stdout.
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+type message struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method,omitempty"`
+	Params  json.RawMessage `json:"params,omitempty"`
+	Result  interface{}     `json:"result,omitempty"`
+	Error   *rpcError       `json:"error,omitempty"`
+}
+
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func main() {
+	if msg := os.Getenv("FAKE_MCP_CRASH"); msg != "" {
+		fmt.Fprintln(os.Stderr, msg)
+		os.Exit(1)
+	}
+
+	out := json.NewEncoder(os.Stdout)
+	scanner := bufio.NewScanner(os.Stdin)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+
+	// Noise on stdout must be ignored by the client.
+	fmt.Println("fakeserver starting")
+
+	for scanner.Scan() {
+		var req message
+		if err := json.Unmarshal(scanner.Bytes(), &req); err != nil {
+			continue
+		}
+		if len(req.ID) == 0 {
+			continue
+		}
+
+		resp := message{JSONRPC: "2.0", ID: req.ID}
+		result, err := handle(req.Method, req.Params)
+		if err != nil {
+			resp.Error = err
+		} else {
+			resp.Result = result
+		}
+		out.Encode(resp)
+	}
+}
+
+func handle(method string, params json.RawMessage) (interface{}, *rpcError) {
+	switch method {
+	case "initialize":
+		var p struct {
+			ProtocolVersion string `json:"protocolVersion"`
+		}
+		json.Unmarshal(params, &p)
+
+		return map[string]interface{}{
+			"protocolVersion": p.ProtocolVersion,
+			"capabilities": map[string]interface{}{
+				"tools":     map[string]interface{}{},
+				"resources": map[string]interface{}{},
+				"prompts":   map[string]interface{}{},
+			},
+			"serverInfo": map[string]interface{}{
+				"name":    "fakeserver",
+				"version": "0.1.0",
+			},
+		}, nil
+
+	case "tools/list":
+		var p struct {
+			Cursor string `json:"cursor"`
+		}
+		json.Unmarshal(params, &p)
+
+		if p.Cursor == "" {
+			return map[string]interface{}{
+				"tools": []interface{}{
+					tool("echo", "Echo the given text", map[string]interface{}{
+						"text": map[string]interface{}{"type": "string"},
+					}),
+					tool("fail", "Always fails", map[string]interface{}{}),
+				},
+				"nextCursor": "page2",
+			}, nil
+		}
+		return map[string]interface{}{
+			"tools": []interface{}{
+				tool("env", "Return the FAKE_MCP_GREETING variable", map[string]interface{}{}),
+			},
+		}, nil
+
+	case "tools/call":
+		var p struct {
+			Name      string                 `json:"name"`
+			Arguments map[string]interface{} `json:"arguments"`
+		}
+		json.Unmarshal(params, &p)
+
+		switch p.Name {
+		case "echo":
+			text, _ := p.Arguments["text"].(string)
+			return textResult(text, false), nil
+		case "fail":
+			return textResult("something went wrong", true), nil
+		case "env":
+			return textResult(os.Getenv("FAKE_MCP_GREETING"), false), nil
+		}
+		return nil, &rpcError{Code: -32602, Message: "unknown tool: " + p.Name}
+
+	case "resources/list":
+		return map[string]interface{}{
+			"resources": []interface{}{
+				map[string]interface{}{
+					"uri":      "docs://guide",
+					"name":     "Guide",
+					"mimeType": "text/markdown",
+				},
+			},
+		}, nil
+
+	case "resources/read":
+		var p struct {
+			URI string `json:"uri"`
+		}
+		json.Unmarshal(params, &p)
+
+		if p.URI != "docs://guide" {
+			return nil, &rpcError{Code: -32002, Message: "resource not found: " + p.URI}
+		}
+		return map[string]interface{}{
+			"contents": []interface{}{
+				map[string]interface{}{"uri": p.URI, "mimeType": "text/markdown", "text": "# Guide\nUse the echo tool."},
+			},
+		}, nil
+
+	case "prompts/list":
+		return map[string]interface{}{
+			"prompts": []interface{}{
+				map[string]interface{}{
+					"name":        "review",
+					"description": "Review a file",
+					"arguments": []interface{}{
+						map[string]interface{}{"name": "file", "required": true},
+						map[string]interface{}{"name": "focus"},
+					},
+				},
+			},
+		}, nil
+
+	case "prompts/get":
+		var p struct {
+			Name      string            `json:"name"`
+			Arguments map[string]string `json:"arguments"`
+		}
+		json.Unmarshal(params, &p)
+
+		if p.Name != "review" {
+			return nil, &rpcError{Code: -32602, Message: "unknown prompt: " + p.Name}
+		}
+		text := "Review " + p.Arguments["file"]
+		if focus := p.Arguments["focus"]; focus != "" {
+			text += " focusing on " + focus
+		}
+		return map[string]interface{}{
+			"messages": []interface{}{
+				map[string]interface{}{
+					"role":    "user",
+					"content": map[string]interface{}{"type": "text", "text": text},
+				},
+			},
+		}, nil
+	}
+
+	return nil, &rpcError{Code: -32601, Message: "method not found: " + method}
+}
+
+func tool(name, description string, properties map[string]interface{}) map[string]interface{} {
+	return map[string]interface{}{
+		"name":        name,
+		"description": description,
+		"inputSchema": map[string]interface{}{
+			"type":       "object",
+			"properties": properties,
+		},
+	}
+}
+
+func textResult(text string, isError bool) map[string]interface{} {
+	return map[string]interface{}{
+		"content": []interface{}{
+			map[string]interface{}{"type": "text", "text": text},
+		},
+		"isError": isError,
+	}
+}
@@ -0,0 +1,165 @@
+package mcp
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/taaha3244/potus/internal/tools"
+)
+
+// ToolNamePrefix marks tools that are served by an MCP server. Registered
+// names take the form mcp__<server>__<tool>.
+const ToolNamePrefix = "mcp__"
+
+// maxToolNameLength matches the limit providers place on tool names.
+const maxToolNameLength = 64
+
+var invalidToolNameChars = regexp.MustCompile(`[^a-zA-Z0-9_-]`)
+
+func IsToolName(name string) bool {
+	return strings.HasPrefix(name, ToolNamePrefix)
+}
+
+func ToolName(server, tool string) string {
+	name := ToolNamePrefix + sanitizeName(server) + "__" + sanitizeName(tool)
+	if len(name) > maxToolNameLength {
+		name = name[:maxToolNameLength]
+	}
+	return name
+}
+
+// ParseToolName splits a registered tool name back into its server and
+// tool parts. The tool part is the sanitized name.
+func ParseToolName(name string) (server, tool string, ok bool) {
+	if !IsToolName(name) {
+		return "", "", false
+	}
+	parts := strings.SplitN(strings.TrimPrefix(name, ToolNamePrefix), "__", 2)
+	if len(parts) != 2 {
+		return "", "", false
+	}
+	return parts[0], parts[1], true
+}
+
+func sanitizeName(name string) string {
+	return invalidToolNameChars.ReplaceAllString(name, "_")
+}
+
+// uniqueToolName returns name, or if it is taken, name with a short hash
+// of the server and remote tool name appended. Sanitizing and truncating
+// can map different tools, e.g. a.b and a_b, to the same name.
+func uniqueToolName(name, server, remote string, taken func(string) bool) string {
+	if !taken(name) {
+		return name
+	}
+
+	sum := sha256.Sum256([]byte(server + "\x00" + remote))
+	suffix := "_" + hex.EncodeToString(sum[:4])
+	base := name
+	if len(base)+len(suffix) > maxToolNameLength {
+		base = base[:maxToolNameLength-len(suffix)]
+	}
+
+	unique := base + suffix
+	for i := 2; taken(unique); i++ {
+		n := fmt.Sprintf("_%d", i)
+		unique = base[:len(base)-len(n)] + suffix + n
+	}
+	return unique
+}
+
+// Tool exposes a single remote MCP tool through the tools.Tool interface.
+type Tool struct {
+	client *Client
+	server string
+	name   string
+	info   ToolInfo
+}
+
+func NewTool(client *Client, info ToolInfo) *Tool {
+	return &Tool{
+		client: client,
+		server: client.Name(),
+		name:   ToolName(client.Name(), info.Name),
+		info:   info,
+	}
+}
+
+func (t *Tool) Name() string {
+	return t.name
+}
+
+func (t *Tool) Description() string {
+	desc := t.info.Description
+	if desc == "" {
+		desc = t.info.Name
+	}
+	return fmt.Sprintf("[MCP %s] %s", t.server, desc)
+}
+
+func (t *Tool) Schema() map[string]interface{} {
+	if t.info.InputSchema == nil {
+		return map[string]interface{}{
+			"type":       "object",
+			"properties": map[string]interface{}{},
+		}
+	}
+	return t.info.InputSchema
+}
+
+func (t *Tool) Server() string {
+	return t.server
+}
+
+func (t *Tool) RemoteName() string {
+	return t.info.Name
+}
+
+func (t *Tool) Execute(ctx context.Context, params map[string]interface{}) (*tools.Result, error) {
+	result, err := t.client.CallTool(ctx, t.info.Name, params)
+	if err != nil {
+		return tools.NewErrorResult(err), nil
+	}
+
+	output := FormatContent(result.Content)
+	if result.IsError {
+		if output == "" {
+			output = "tool reported an error"
+		}
+		return tools.NewErrorResult(fmt.Errorf("%s", output)), nil
+	}
+
+	return tools.NewResult(output), nil
+}
+
+// FormatContent flattens tool result content into text for the model.
+// Binary content is summarized rather than inlined.
+func FormatContent(items []ContentItem) string {
+	parts := make([]string, 0, len(items))
+
+	for _, item := range items {
+		switch item.Type {
+		case "text":
+			parts = append(parts, item.Text)
+		case "image", "audio":
+			parts = append(parts, fmt.Sprintf("[%s: %s, %d bytes base64]", item.Type, item.MimeType, len(item.Data)))
+		case "resource":
+			if item.Resource == nil {
+				continue
+			}
+			if item.Resource.Text != "" {
+				parts = append(parts, fmt.Sprintf("[resource: %s]\n%s", item.Resource.URI, item.Resource.Text))
+			} else {
+				parts = append(parts, fmt.Sprintf("[resource: %s, %s]", item.Resource.URI, item.Resource.MimeType))
+			}
+		default:
+			parts = append(parts, fmt.Sprintf("[unsupported content type: %s]", item.Type))
+		}
+	}
+
+	return strings.Join(parts, "\n")
+}
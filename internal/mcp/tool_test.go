@@ -0,0 +1,169 @@
+package mcp
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/taaha3244/potus/internal/config"
+	"github.com/taaha3244/potus/internal/tools"
+)
+
+func TestToolName(t *testing.T) {
+	tests := []struct {
+		server string
+		tool   string
+		want   string
+	}{
+		{"docs", "search", "mcp__docs__search"},
+		{"my.server", "get page", "mcp__my_server__get_page"},
+		{"fs", "read-file", "mcp__fs__read-file"},
+	}
+
+	for _, tt := range tests {
+		if got := ToolName(tt.server, tt.tool); got != tt.want {
+			t.Errorf("ToolName(%q, %q) = %q, want %q", tt.server, tt.tool, got, tt.want)
+		}
+	}
+
+	long := ToolName("server", strings.Repeat("x", 100))
+	if len(long) != maxToolNameLength {
+		t.Errorf("long name length = %d, want %d", len(long), maxToolNameLength)
+	}
+}
+
+func TestRegisterTools_NameCollisions(t *testing.T) {
+	registry := tools.NewRegistry()
+	client := &Client{name: "docs"}
+	long := strings.Repeat("x", 70)
+
+	registerTools(registry, client, []ToolInfo{
+		{Name: "get.page"},
+		{Name: "get_page"},
+		{Name: long + "a"},
+		{Name: long + "b"},
+	})
+
+	all := registry.List()
+	if len(all) != 4 {
+		t.Fatalf("registered %d tools, want 4", len(all))
+	}
+
+	remotes := make(map[string]string)
+	for _, tool := range all {
+		name := tool.Name()
+		if len(name) > maxToolNameLength {
+			t.Errorf("name %q is longer than %d", name, maxToolNameLength)
+		}
+		if _, _, ok := ParseToolName(name); !ok {
+			t.Errorf("name %q does not parse", name)
+		}
+		remotes[name] = tool.(*Tool).RemoteName()
+	}
+
+	if remotes["mcp__docs__get_page"] != "get.page" {
+		t.Errorf("the first tool should keep its name, got %v", remotes)
+	}
+	if len(remotes) != 4 {
+		t.Errorf("names are not unique: %v", remotes)
+	}
+}
+
+func TestParseToolName(t *testing.T) {
+	server, tool, ok := ParseToolName("mcp__docs__search_pages")
+	if !ok || server != "docs" || tool != "search_pages" {
+		t.Errorf("ParseToolName() = %q, %q, %v", server, tool, ok)
+	}
+
+	if _, _, ok := ParseToolName("file_read"); ok {
+		t.Error("file_read should not parse as an MCP tool")
+	}
+
+	if !IsToolName("mcp__a__b") || IsToolName("bash") {
+		t.Error("IsToolName returned wrong result")
+	}
+}
+
+func TestFormatContent(t *testing.T) {
+	items := []ContentItem{
+		{Type: "text", Text: "first"},
+		{Type: "image", MimeType: "image/png", Data: "aGVsbG8="},
+		{Type: "resource", Resource: &ResourceContents{URI: "file:///a.txt", Text: "body"}},
+		{Type: "mystery"},
+	}
+
+	got := FormatContent(items)
+
+	for _, want := range []string{"first", "[image: image/png, 8 bytes base64]", "[resource: file:///a.txt]\nbody", "unsupported content type: mystery"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("FormatContent() missing %q in:\n%s", want, got)
+		}
+	}
+}
+
+func TestTool_Execute(t *testing.T) {
+	client := connectFake(t)
+
+	echo := NewTool(client, ToolInfo{Name: "echo", Description: "Echo the given text"})
+	if echo.Name() != "mcp__fake__echo" {
+		t.Errorf("Name() = %s", echo.Name())
+	}
+	if !strings.Contains(echo.Description(), "Echo the given text") {
+		t.Errorf("Description() = %s", echo.Description())
+	}
+	if echo.Schema()["type"] != "object" {
+		t.Error("missing schema should default to an empty object schema")
+	}
+
+	result, err := echo.Execute(context.Background(), map[string]interface{}{"text": "hi"})
+	if err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	if !result.Success || result.Output != "hi" {
+		t.Errorf("result = %+v", result)
+	}
+
+	fail := NewTool(client, ToolInfo{Name: "fail"})
+	result, err = fail.Execute(context.Background(), nil)
+	if err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	if result.Success {
+		t.Error("expected failure result")
+	}
+	if !strings.Contains(result.Output, "something went wrong") {
+		t.Errorf("Output = %q", result.Output)
+	}
+}
+
+func TestManager_Start(t *testing.T) {
+	registry := tools.NewRegistry()
+	manager := NewManager()
+	defer manager.Close()
+
+	servers := map[string]config.MCPServerConfig{
+		"fake":     fakeServerConfig(),
+		"disabled": {Command: fakeServerPath, Enabled: false},
+		"broken":   {Command: "", Enabled: true},
+	}
+
+	errs := manager.Start(context.Background(), servers, registry)
+
+	if len(errs) != 1 || errs["broken"] == nil {
+		t.Errorf("errors = %v, want only broken", errs)
+	}
+
+	if got := manager.List(); len(got) != 1 || got[0] != "fake" {
+		t.Errorf("List() = %v, want [fake]", got)
+	}
+
+	for _, name := range []string{"mcp__fake__echo", "mcp__fake__fail", "mcp__fake__env"} {
+		if _, err := registry.Get(name); err != nil {
+			t.Errorf("tool %s not registered", name)
+		}
+	}
+
+	if _, err := manager.Get("disabled"); err == nil {
+		t.Error("disabled server should not be connected")
+	}
+}
@@ -0,0 +1,216 @@
+package mcp
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+)
+
+// maxMessageSize bounds a single line read from a stdio server.
+const maxMessageSize = 16 * 1024 * 1024
+
+// Transport moves JSON-RPC messages between the client and a server.
+// Messages received from the server are delivered on Incoming, which is
+// closed once the transport shuts down.
+type Transport interface {
+	Start(ctx context.Context) error
+	Send(ctx context.Context, msg *Message) error
+	Incoming() <-chan *Message
+	Close() error
+}
+
+// StdioTransport runs a server as a child process and exchanges
+// newline-delimited JSON-RPC messages over its stdin and stdout.
+type StdioTransport struct {
+	command string
+	args    []string
+	env     map[string]string
+
+	mu         sync.Mutex
+	cmd        *exec.Cmd
+	stdin      io.WriteCloser
+	stderr     *tailBuffer
+	incoming   chan *Message
+	stderrDone chan struct{}
+	readDone   chan struct{}
+	closed     bool
+}
+
+func NewStdioTransport(command string, args []string, env map[string]string) *StdioTransport {
+	return &StdioTransport{
+		command:    command,
+		args:       args,
+		env:        env,
+		stderr:     &tailBuffer{limit: 4096},
+		incoming:   make(chan *Message, 16),
+		stderrDone: make(chan struct{}),
+		readDone:   make(chan struct{}),
+	}
+}
+
+func (t *StdioTransport) Start(ctx context.Context) error {
+	if t.command == "" {
+		return fmt.Errorf("command is required")
+	}
+
+	cmd := exec.Command(t.command, t.args...)
+	cmd.Env = os.Environ()
+	for k, v := range t.env {
+		cmd.Env = append(cmd.Env, k+"="+v)
+	}
+
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return fmt.Errorf("failed to open stdin: %w", err)
+	}
+
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return fmt.Errorf("failed to open stdout: %w", err)
+	}
+
+	stderr, err := cmd.StderrPipe()
+	if err != nil {
+		return fmt.Errorf("failed to open stderr: %w", err)
+	}
+
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("failed to start %s: %w", t.command, err)
+	}
+
+	t.mu.Lock()
+	t.cmd = cmd
+	t.stdin = stdin
+	t.mu.Unlock()
+
+	go func() {
+		defer close(t.stderrDone)
+		io.Copy(t.stderr, stderr)
+	}()
+	go t.readLoop(stdout)
+
+	return nil
+}
+
+func (t *StdioTransport) Send(ctx context.Context, msg *Message) error {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return fmt.Errorf("failed to marshal message: %w", err)
+	}
+	data = append(data, '\n')
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.closed || t.stdin == nil {
+		return fmt.Errorf("transport is closed")
+	}
+
+	if _, err := t.stdin.Write(data); err != nil {
+		return fmt.Errorf("failed to write message: %w", err)
+	}
+	return nil
+}
+
+func (t *StdioTransport) Incoming() <-chan *Message {
+	return t.incoming
+}
+
+func (t *StdioTransport) Close() error {
+	t.mu.Lock()
+	if t.closed {
+		t.mu.Unlock()
+		return nil
+	}
+	t.closed = true
+	cmd := t.cmd
+	stdin := t.stdin
+	t.mu.Unlock()
+
+	if cmd == nil {
+		return nil
+	}
+
+	if stdin != nil {
+		stdin.Close()
+	}
+
+	// Closing stdin asks the server to exit; give it a moment before
+	// killing it. Wait must not run until the pipes have been drained.
+	select {
+	case <-t.readDone:
+	case <-time.After(2 * time.Second):
+		cmd.Process.Kill()
+		<-t.readDone
+	}
+
+	cmd.Wait()
+	return nil
+}
+
+// Stderr returns the most recent output the server wrote to stderr, which
+// is usually the only clue when a server exits during startup.
+func (t *StdioTransport) Stderr() string {
+	return strings.TrimSpace(t.stderr.String())
+}
+
+func (t *StdioTransport) readLoop(stdout io.Reader) {
+	defer close(t.readDone)
+	defer close(t.incoming)
+
+	scanner := bufio.NewScanner(stdout)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxMessageSize)
+
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+
+		var msg Message
+		if err := json.Unmarshal(line, &msg); err != nil {
+			// Servers occasionally log to stdout; skip anything that is
+			// not a JSON-RPC message.
+			continue
+		}
+
+		t.incoming <- &msg
+	}
+
+	// Let stderr drain so a server that exits early can explain why.
+	select {
+	case <-t.stderrDone:
+	case <-time.After(time.Second):
+	}
+}
+
+type tailBuffer struct {
+	mu    sync.Mutex
+	buf   []byte
+	limit int
+}
+
+func (b *tailBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.buf = append(b.buf, p...)
+	if len(b.buf) > b.limit {
+		b.buf = b.buf[len(b.buf)-b.limit:]
+	}
+	return len(p), nil
+}
+
+func (b *tailBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return string(b.buf)
+}
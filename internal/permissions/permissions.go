@@ -5,6 +5,7 @@ import (
 	"sync"
 
 	"github.com/taaha3244/potus/internal/config"
+	"github.com/taaha3244/potus/internal/mcp"
 )
 
 type Permission string
@@ -128,6 +129,9 @@ func (m *Manager) getToolPermission(toolName string) Permission {
 	case "search_files", "search_content":
 		return PermissionAllow
 	default:
+		if mcp.IsToolName(toolName) {
+			return Permission(m.config.MCP)
+		}
 		return PermissionAsk
 	}
 }
@@ -384,3 +384,22 @@ func TestManager_SetPromptFunc(t *testing.T) {
 		t.Error("Should be allowed after setting prompt function")
 	}
 }
+
+func TestManager_Check_MCPTools(t *testing.T) {
+	cfg := &config.PermissionConfig{
+		MCP: config.PermissionDeny,
+	}
+
+	mgr := NewManager(cfg, nil)
+
+	result, _ := mgr.Check("mcp__docs__search", "call", "")
+	if result.Allowed {
+		t.Error("MCP tools should be denied when mcp=deny")
+	}
+
+	cfg.MCP = config.PermissionAllow
+	result, _ = mgr.Check("mcp__docs__search", "call", "")
+	if !result.Allowed {
+		t.Error("MCP tools should be allowed when mcp=allow")
+	}
+}
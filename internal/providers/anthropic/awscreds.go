@@ -0,0 +1,184 @@
+package anthropic
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"time"
+)
+
+const (
+	containerCredentialsHost = "http://169.254.170.2"
+	defaultIMDSEndpoint      = "http://169.254.169.254"
+
+	// imdsTimeout keeps the instance metadata probe short on machines
+	// that aren't EC2 instances
+	imdsTimeout = time.Second
+
+	credentialProcessTimeout = time.Minute
+)
+
+// credentialsResponse is the JSON returned by credential_process commands
+// and by the container and instance metadata endpoints. Processes name the
+// session token SessionToken, the endpoints Token.
+type credentialsResponse struct {
+	AccessKeyID     string `json:"AccessKeyId"`
+	SecretAccessKey string `json:"SecretAccessKey"`
+	SessionToken    string `json:"SessionToken"`
+	Token           string `json:"Token"`
+	Expiration      string `json:"Expiration"`
+}
+
+func parseCredentialsResponse(data []byte, source string) (AWSCredentials, error) {
+	var resp credentialsResponse
+	if err := json.Unmarshal(data, &resp); err != nil {
+		return AWSCredentials{}, fmt.Errorf("invalid credentials from %s: %w", source, err)
+	}
+	if resp.AccessKeyID == "" || resp.SecretAccessKey == "" {
+		return AWSCredentials{}, fmt.Errorf("%s returned no access key", source)
+	}
+
+	creds := AWSCredentials{
+		AccessKeyID:     resp.AccessKeyID,
+		SecretAccessKey: resp.SecretAccessKey,
+		SessionToken:    resp.SessionToken,
+	}
+	if creds.SessionToken == "" {
+		creds.SessionToken = resp.Token
+	}
+	if resp.Expiration != "" {
+		expires, err := time.Parse(time.RFC3339, resp.Expiration)
+		if err != nil {
+			return AWSCredentials{}, fmt.Errorf("invalid expiration from %s: %w", source, err)
+		}
+		creds.Expires = expires
+	}
+	return creds, nil
+}
+
+// processCredentials runs a profile's credential_process command, e.g.
+// "aws configure export-credentials --profile sso --format process".
+func processCredentials(command string) (AWSCredentials, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), credentialProcessTimeout)
+	defer cancel()
+
+	shell, flag := "sh", "-c"
+	if runtime.GOOS == "windows" {
+		shell, flag = "cmd", "/C"
+	}
+
+	out, err := exec.CommandContext(ctx, shell, flag, command).Output()
+	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && len(exitErr.Stderr) > 0 {
+			return AWSCredentials{}, fmt.Errorf("credential_process failed: %s", strings.TrimSpace(string(exitErr.Stderr)))
+		}
+		return AWSCredentials{}, fmt.Errorf("credential_process failed: %w", err)
+	}
+	return parseCredentialsResponse(out, "credential_process")
+}
+
+// containerCredentials fetches the role credentials of an ECS task or an
+// EKS pod (Pod Identity). ok is false outside such a container.
+func containerCredentials() (creds AWSCredentials, ok bool, err error) {
+	uri := os.Getenv("AWS_CONTAINER_CREDENTIALS_FULL_URI")
+	if relative := os.Getenv("AWS_CONTAINER_CREDENTIALS_RELATIVE_URI"); relative != "" {
+		uri = containerCredentialsHost + relative
+	}
+	if uri == "" {
+		return AWSCredentials{}, false, nil
+	}
+
+	req, err := http.NewRequest(http.MethodGet, uri, nil)
+	if err != nil {
+		return AWSCredentials{}, true, fmt.Errorf("invalid container credentials URI: %w", err)
+	}
+
+	token := os.Getenv("AWS_CONTAINER_AUTHORIZATION_TOKEN")
+	if file := os.Getenv("AWS_CONTAINER_AUTHORIZATION_TOKEN_FILE"); file != "" {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return AWSCredentials{}, true, fmt.Errorf("failed to read container authorization token: %w", err)
+		}
+		token = strings.TrimSpace(string(data))
+	}
+	if token != "" {
+		req.Header.Set("Authorization", token)
+	}
+
+	data, err := fetchMetadata(&http.Client{Timeout: 5 * time.Second}, req)
+	if err != nil {
+		return AWSCredentials{}, true, fmt.Errorf("container credentials: %w", err)
+	}
+	creds, err = parseCredentialsResponse(data, "the container credentials endpoint")
+	return creds, true, err
+}
+
+// imdsCredentials fetches the instance role credentials of an EC2
+// instance through IMDSv2.
+func imdsCredentials() (AWSCredentials, error) {
+	endpoint := strings.TrimSuffix(os.Getenv("AWS_EC2_METADATA_SERVICE_ENDPOINT"), "/")
+	if endpoint == "" {
+		endpoint = defaultIMDSEndpoint
+	}
+	client := &http.Client{Timeout: imdsTimeout}
+
+	req, err := http.NewRequest(http.MethodPut, endpoint+"/latest/api/token", nil)
+	if err != nil {
+		return AWSCredentials{}, err
+	}
+	req.Header.Set("X-aws-ec2-metadata-token-ttl-seconds", "21600")
+	token, err := fetchMetadata(client, req)
+	if err != nil {
+		return AWSCredentials{}, err
+	}
+
+	get := func(path string) ([]byte, error) {
+		req, err := http.NewRequest(http.MethodGet, endpoint+path, nil)
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("X-aws-ec2-metadata-token", string(token))
+		return fetchMetadata(client, req)
+	}
+
+	const rolesPath = "/latest/meta-data/iam/security-credentials/"
+	roles, err := get(rolesPath)
+	if err != nil {
+		return AWSCredentials{}, err
+	}
+	role, _, _ := strings.Cut(strings.TrimSpace(string(roles)), "\n")
+	if role == "" {
+		return AWSCredentials{}, fmt.Errorf("the instance has no IAM role")
+	}
+
+	data, err := get(rolesPath + role)
+	if err != nil {
+		return AWSCredentials{}, err
+	}
+	return parseCredentialsResponse(data, "instance metadata")
+}
+
+func fetchMetadata(client *http.Client, req *http.Request) ([]byte, error) {
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s %s: HTTP %d", req.Method, req.URL.Path, resp.StatusCode)
+	}
+	return data, nil
+}
@@ -0,0 +1,237 @@
+package anthropic
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/taaha3244/potus/internal/providers"
+)
+
+const bedrockAnthropicVersion = "bedrock-2023-05-31"
+
+// BedrockConfig describes access to Claude on AWS Bedrock. The model part
+// of --model is the Bedrock model or inference profile ID, e.g.
+// us.anthropic.claude-sonnet-4-5-20250929-v1:0.
+type BedrockConfig struct {
+	Region      string
+	Credentials AWSCredentials
+	Endpoint    string            // defaults to https://bedrock-runtime.<region>.amazonaws.com
+	Models      []providers.Model // optional context sizes and pricing per model ID
+}
+
+type bedrockConfig struct {
+	region   string
+	endpoint string
+	models   []providers.Model
+	now      func() time.Time
+
+	mu              sync.Mutex
+	credentials     AWSCredentials
+	loadCredentials func() (AWSCredentials, error)
+}
+
+// credentialRefreshWindow is how long before they expire temporary
+// credentials are replaced.
+const credentialRefreshWindow = 5 * time.Minute
+
+// currentCredentials returns the credentials to sign with, loading new
+// ones when temporary credentials are about to expire.
+func (b *bedrockConfig) currentCredentials() (AWSCredentials, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.credentials.Expires.IsZero() || b.now().Add(credentialRefreshWindow).Before(b.credentials.Expires) {
+		return b.credentials, nil
+	}
+
+	creds, err := b.loadCredentials()
+	if err != nil {
+		return AWSCredentials{}, fmt.Errorf("failed to refresh AWS credentials: %w", err)
+	}
+	b.credentials = creds
+	return creds, nil
+}
+
+func NewBedrock(cfg BedrockConfig) (*Client, error) {
+	if cfg.Region == "" {
+		return nil, fmt.Errorf("region is required")
+	}
+	if cfg.Credentials.AccessKeyID == "" || cfg.Credentials.SecretAccessKey == "" {
+		return nil, fmt.Errorf("AWS credentials are required")
+	}
+
+	endpoint := cfg.Endpoint
+	if endpoint == "" {
+		endpoint = "https://bedrock-runtime." + cfg.Region + ".amazonaws.com"
+	}
+
+	models := make([]providers.Model, len(cfg.Models))
+	for i, m := range cfg.Models {
+		if m.Name == "" {
+			m.Name = m.ID
+		}
+		m.Provider = "bedrock"
+		models[i] = m
+	}
+	if len(models) == 0 {
+		models = bedrockModels(cfg.Region)
+	}
+
+	return &Client{
+		client: &http.Client{},
+		bedrock: &bedrockConfig{
+			region:          cfg.Region,
+			endpoint:        strings.TrimSuffix(endpoint, "/"),
+			models:          models,
+			now:             time.Now,
+			credentials:     cfg.Credentials,
+			loadCredentials: LoadAWSCredentials,
+		},
+	}, nil
+}
+
+// bedrockModels lists the known models under their Bedrock IDs. Current
+// Claude models are only served on demand through cross-region inference
+// profiles, whose IDs start with the region's geography.
+func bedrockModels(region string) []providers.Model {
+	prefix := ""
+	switch {
+	case strings.HasPrefix(region, "us-"):
+		prefix = "us."
+	case strings.HasPrefix(region, "eu-"):
+		prefix = "eu."
+	case strings.HasPrefix(region, "ap-"):
+		prefix = "apac."
+	}
+
+	models := make([]providers.Model, len(knownModels))
+	for i, m := range knownModels {
+		m.ID = prefix + "anthropic." + m.ID + "-v1:0"
+		m.Provider = "bedrock"
+		models[i] = m
+	}
+	return models
+}
+
+func (c *Client) bedrockChatURL(model string) string {
+	// Model IDs contain a colon, which Bedrock expects escaped
+	escaped := strings.ReplaceAll(url.PathEscape(model), ":", "%3A")
+	return c.bedrock.endpoint + "/model/" + escaped + "/invoke-with-response-stream"
+}
+
+func (c *Client) chatBedrock(ctx context.Context, req *providers.ChatRequest) (<-chan providers.ChatEvent, error) {
+	if req.Model == "" {
+		return nil, fmt.Errorf("no Bedrock model given (use --model bedrock/<model-id>)")
+	}
+
+	// The model is named in the URL and streaming is chosen by the
+	// endpoint, so the body is the Messages API request without them
+	apiReq := c.buildRequest(req)
+	delete(apiReq, "model")
+	delete(apiReq, "stream")
+	apiReq["anthropic_version"] = bedrockAnthropicVersion
+
+	body, err := json.Marshal(apiReq)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", c.bedrockChatURL(req.Model), bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("Accept", "application/vnd.amazon.eventstream")
+	creds, err := c.bedrock.currentCredentials()
+	if err != nil {
+		return nil, err
+	}
+	signV4(httpReq, body, creds, c.bedrock.region, "bedrock", c.bedrock.now())
+
+	resp, err := c.client.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send request: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, providers.NewAPIError(resp)
+	}
+
+	eventChan := make(chan providers.ChatEvent, 10)
+
+	go c.streamBedrockResponse(resp.Body, newStreamState(req), eventChan)
+
+	return eventChan, nil
+}
+
+// streamBedrockResponse decodes an event stream whose chunk events each
+// carry one Messages API streaming event, base64 encoded.
+func (c *Client) streamBedrockResponse(body io.ReadCloser, state *streamState, eventChan chan<- providers.ChatEvent) {
+	defer close(eventChan)
+	defer body.Close()
+
+	reader := newEventStreamReader(body)
+
+	for {
+		message, err := reader.ReadMessage()
+		if err == io.EOF {
+			if !state.stopped {
+				eventChan <- providers.ChatEvent{
+					Type:  providers.EventTypeError,
+					Error: fmt.Errorf("truncated Bedrock stream: no message_stop"),
+				}
+			}
+			return
+		}
+		if err == nil {
+			err = c.handleBedrockMessage(state, message, eventChan)
+		}
+		if err != nil {
+			eventChan <- providers.ChatEvent{
+				Type:  providers.EventTypeError,
+				Error: err,
+			}
+			return
+		}
+	}
+}
+
+func (c *Client) handleBedrockMessage(state *streamState, message *eventStreamMessage, eventChan chan<- providers.ChatEvent) error {
+	switch message.Headers[":message-type"] {
+	case "exception":
+		var exception struct {
+			Message string `json:"message"`
+		}
+		json.Unmarshal(message.Payload, &exception)
+		return fmt.Errorf("stream error: %s: %s", message.Headers[":exception-type"], exception.Message)
+	case "error":
+		return fmt.Errorf("stream error: %s: %s", message.Headers[":error-code"], message.Headers[":error-message"])
+	}
+
+	if message.Headers[":event-type"] != "chunk" {
+		return nil
+	}
+
+	// encoding/json decodes the base64 of a []byte field
+	var chunk struct {
+		Bytes []byte `json:"bytes"`
+	}
+	if err := json.Unmarshal(message.Payload, &chunk); err != nil {
+		return fmt.Errorf("failed to parse chunk: %w", err)
+	}
+
+	var event map[string]interface{}
+	if err := json.Unmarshal(chunk.Bytes, &event); err != nil {
+		return fmt.Errorf("failed to parse event: %w", err)
+	}
+	return c.handleEvent(state, event, eventChan)
+}
@@ -0,0 +1,257 @@
+package anthropic
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/taaha3244/potus/internal/providers"
+)
+
+var testAWSCredentials = AWSCredentials{AccessKeyID: "AKIDEXAMPLE", SecretAccessKey: "secret"}
+
+func TestNewBedrock(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     BedrockConfig
+		wantErr bool
+	}{
+		{"valid", BedrockConfig{Region: "us-east-1", Credentials: testAWSCredentials}, false},
+		{"missing region", BedrockConfig{Credentials: testAWSCredentials}, true},
+		{"missing credentials", BedrockConfig{Region: "us-east-1"}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, err := NewBedrock(tt.cfg)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("NewBedrock() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && client.Name() != "bedrock" {
+				t.Errorf("Name() = %s, want bedrock", client.Name())
+			}
+		})
+	}
+}
+
+func TestBedrock_ListModels(t *testing.T) {
+	client, _ := NewBedrock(BedrockConfig{Region: "eu-central-1", Credentials: testAWSCredentials})
+	models, _ := client.ListModels(context.Background())
+
+	m, ok := providers.FindModel(models, "eu.anthropic.claude-sonnet-4-5-20250929-v1:0")
+	if !ok {
+		t.Fatalf("inference profile not listed: %v", models)
+	}
+	if m.Provider != "bedrock" || m.Pricing.InputPer1M != 3.00 {
+		t.Errorf("model = %+v", m)
+	}
+
+	client, _ = NewBedrock(BedrockConfig{
+		Region:      "us-west-2",
+		Credentials: testAWSCredentials,
+		Models:      []providers.Model{{ID: "arn:aws:bedrock:us-west-2:123456789012:application-inference-profile/abc", ContextSize: 200000}},
+	})
+	models, _ = client.ListModels(context.Background())
+	if len(models) != 1 || models[0].Name != models[0].ID {
+		t.Errorf("configured models = %+v", models)
+	}
+}
+
+// bedrockChunk frames a Messages API streaming event as Bedrock sends it.
+func bedrockChunk(event string) []byte {
+	payload, _ := json.Marshal(map[string]string{"bytes": base64.StdEncoding.EncodeToString([]byte(event))})
+	return encodeEventStreamMessage(map[string]string{
+		":event-type":   "chunk",
+		":content-type": "application/json",
+		":message-type": "event",
+	}, payload)
+}
+
+func newTestBedrock(t *testing.T, handler http.HandlerFunc) *Client {
+	t.Helper()
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	client, err := NewBedrock(BedrockConfig{Region: "us-east-1", Credentials: testAWSCredentials, Endpoint: server.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+	client.bedrock.now = func() time.Time { return time.Date(2026, 1, 2, 15, 4, 5, 0, time.UTC) }
+	return client
+}
+
+func TestBedrock_Chat(t *testing.T) {
+	client := newTestBedrock(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.EscapedPath() != "/model/us.anthropic.claude-sonnet-4-5-20250929-v1%3A0/invoke-with-response-stream" {
+			t.Errorf("path = %s", r.URL.EscapedPath())
+		}
+		auth := r.Header.Get("Authorization")
+		if !strings.HasPrefix(auth, "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20260102/us-east-1/bedrock/aws4_request, SignedHeaders=content-type;host;x-amz-date, Signature=") {
+			t.Errorf("Authorization = %s", auth)
+		}
+		if r.Header.Get("X-Amz-Date") != "20260102T150405Z" {
+			t.Errorf("X-Amz-Date = %s", r.Header.Get("X-Amz-Date"))
+		}
+
+		var req map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&req)
+		if req["anthropic_version"] != bedrockAnthropicVersion {
+			t.Errorf("anthropic_version = %v", req["anthropic_version"])
+		}
+		if _, ok := req["model"]; ok {
+			t.Error("model sent in the body")
+		}
+		if _, ok := req["stream"]; ok {
+			t.Error("stream sent in the body")
+		}
+
+		w.Header().Set("Content-Type", "application/vnd.amazon.eventstream")
+		for _, event := range []string{
+			`{"type":"message_start","message":{"id":"msg_bdrk_01","usage":{"input_tokens":25,"output_tokens":1}}}`,
+			`{"type":"content_block_start","index":0,"content_block":{"type":"text","text":""}}`,
+			`{"type":"content_block_delta","index":0,"delta":{"type":"text_delta","text":"Checking"}}`,
+			`{"type":"content_block_stop","index":0}`,
+			`{"type":"content_block_start","index":1,"content_block":{"type":"tool_use","id":"toolu_bdrk_01","name":"git_status","input":{}}}`,
+			`{"type":"content_block_delta","index":1,"delta":{"type":"input_json_delta","partial_json":"{}"}}`,
+			`{"type":"content_block_stop","index":1}`,
+			`{"type":"message_delta","delta":{"stop_reason":"tool_use"},"usage":{"output_tokens":30}}`,
+			`{"type":"message_stop","amazon-bedrock-invocationMetrics":{"inputTokenCount":25,"outputTokenCount":30}}`,
+		} {
+			w.Write(bedrockChunk(event))
+		}
+	})
+
+	events, err := client.Chat(context.Background(), &providers.ChatRequest{
+		Model:     "us.anthropic.claude-sonnet-4-5-20250929-v1:0",
+		MaxTokens: 1024,
+		Messages: []providers.Message{
+			{Role: providers.RoleUser, Content: []providers.ContentBlock{&providers.TextContent{Text: "status?"}}},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Chat() error = %v", err)
+	}
+
+	var text string
+	var toolUse *providers.ToolUseContent
+	var usage *providers.Usage
+	for event := range events {
+		switch event.Type {
+		case providers.EventTypeTextDelta:
+			text += event.Content
+		case providers.EventTypeToolUse:
+			toolUse = event.ToolUse
+		case providers.EventTypeMessageDone:
+			usage = event.Usage
+		case providers.EventTypeError:
+			t.Fatalf("unexpected error: %v", event.Error)
+		}
+	}
+
+	if text != "Checking" {
+		t.Errorf("text = %q", text)
+	}
+	if toolUse == nil || toolUse.ID != "toolu_bdrk_01" || toolUse.Name != "git_status" {
+		t.Errorf("tool use = %+v", toolUse)
+	}
+	if usage == nil || usage.InputTokens != 25 || usage.OutputTokens != 30 {
+		t.Errorf("usage = %+v", usage)
+	}
+}
+
+func TestBedrock_StreamException(t *testing.T) {
+	client := newTestBedrock(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Write(bedrockChunk(`{"type":"message_start","message":{"id":"msg_bdrk_02"}}`))
+		w.Write(encodeEventStreamMessage(map[string]string{
+			":exception-type": "throttlingException",
+			":content-type":   "application/json",
+			":message-type":   "exception",
+		}, []byte(`{"message":"Too many requests, please wait before trying again."}`)))
+	})
+
+	events, err := client.Chat(context.Background(), &providers.ChatRequest{Model: "anthropic.claude-sonnet-4-20250514-v1:0"})
+	if err != nil {
+		t.Fatalf("Chat() error = %v", err)
+	}
+
+	var last providers.ChatEvent
+	for event := range events {
+		last = event
+	}
+	if last.Type != providers.EventTypeError || last.Error.Error() != "stream error: throttlingException: Too many requests, please wait before trying again." {
+		t.Errorf("last event = %+v", last)
+	}
+}
+
+func TestBedrock_TruncatedStream(t *testing.T) {
+	client := newTestBedrock(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Write(bedrockChunk(`{"type":"message_start","message":{"id":"msg_bdrk_03"}}`))
+		w.Write(bedrockChunk(`{"type":"content_block_start","index":0,"content_block":{"type":"text","text":""}}`))
+		w.Write(bedrockChunk(`{"type":"content_block_delta","index":0,"delta":{"type":"text_delta","text":"Half"}}`))
+	})
+
+	events, err := client.Chat(context.Background(), &providers.ChatRequest{Model: "anthropic.claude-sonnet-4-20250514-v1:0"})
+	if err != nil {
+		t.Fatalf("Chat() error = %v", err)
+	}
+
+	var last providers.ChatEvent
+	for event := range events {
+		if event.Type == providers.EventTypeMessageDone {
+			t.Error("a cut-off stream should not complete")
+		}
+		last = event
+	}
+	if last.Type != providers.EventTypeError || !strings.Contains(last.Error.Error(), "truncated Bedrock stream") {
+		t.Errorf("last event = %+v, want a truncation error", last)
+	}
+}
+
+func TestBedrock_RefreshesExpiringCredentials(t *testing.T) {
+	var keys []string
+	client := newTestBedrock(t, func(w http.ResponseWriter, r *http.Request) {
+		keys = append(keys, strings.SplitN(strings.TrimPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential="), "/", 2)[0])
+		w.Write(bedrockChunk(`{"type":"message_stop"}`))
+	})
+
+	now := client.bedrock.now()
+	client.bedrock.credentials = AWSCredentials{AccessKeyID: "ASIAOLD", SecretAccessKey: "s", Expires: now.Add(2 * time.Minute)}
+	loads := 0
+	client.bedrock.loadCredentials = func() (AWSCredentials, error) {
+		loads++
+		return AWSCredentials{AccessKeyID: "ASIANEW", SecretAccessKey: "s", Expires: now.Add(time.Hour)}, nil
+	}
+
+	for i := 0; i < 2; i++ {
+		events, err := client.Chat(context.Background(), &providers.ChatRequest{Model: "anthropic.claude-sonnet-4-20250514-v1:0"})
+		if err != nil {
+			t.Fatalf("Chat() error = %v", err)
+		}
+		for range events {
+		}
+	}
+
+	if loads != 1 {
+		t.Errorf("credentials loaded %d times, want 1", loads)
+	}
+	if len(keys) != 2 || keys[0] != "ASIANEW" || keys[1] != "ASIANEW" {
+		t.Errorf("signed with %v, want the refreshed key", keys)
+	}
+}
+
+func TestBedrock_APIError(t *testing.T) {
+	client := newTestBedrock(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+		w.Write([]byte(`{"message":"The security token included in the request is invalid."}`))
+	})
+
+	_, err := client.Chat(context.Background(), &providers.ChatRequest{Model: "anthropic.claude-sonnet-4-20250514-v1:0"})
+	if err == nil || !strings.Contains(err.Error(), "status 403") {
+		t.Errorf("Chat() error = %v, want the API error", err)
+	}
+}
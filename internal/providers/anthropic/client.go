@@ -8,20 +8,33 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"slices"
 	"strings"
 
 	"github.com/taaha3244/potus/internal/providers"
 )
 
 const (
-	defaultEndpoint = "https://api.anthropic.com/v1/messages"
-	apiVersion      = "2023-06-01"
+	defaultEndpoint       = "https://api.anthropic.com/v1/messages"
+	defaultModelsEndpoint = "https://api.anthropic.com/v1/models"
+	apiVersion            = "2023-06-01"
+
+	// defaultContextSize applies to models missing from knownModels
+	defaultContextSize = 200000
+
+	// minThinkingBudget is the smallest budget_tokens the API accepts.
+	minThinkingBudget = 1024
 )
 
 type Client struct {
-	apiKey   string
-	endpoint string
-	client   *http.Client
+	apiKey         string
+	endpoint       string
+	modelsEndpoint string
+	modelCache     *providers.ModelCache
+	client         *http.Client
+
+	// Set for Claude on AWS Bedrock, see NewBedrock
+	bedrock *bedrockConfig
 }
 
 func New(apiKey string) (*Client, error) {
@@ -30,13 +43,17 @@ func New(apiKey string) (*Client, error) {
 	}
 
 	return &Client{
-		apiKey:   apiKey,
-		endpoint: defaultEndpoint,
-		client:   &http.Client{},
+		apiKey:         apiKey,
+		endpoint:       defaultEndpoint,
+		modelsEndpoint: defaultModelsEndpoint,
+		client:         &http.Client{},
 	}, nil
 }
 
 func (c *Client) Name() string {
+	if c.bedrock != nil {
+		return "bedrock"
+	}
 	return "anthropic"
 }
 
@@ -48,52 +65,129 @@ func (c *Client) SupportsVision() bool {
 	return true
 }
 
-func (c *Client) ListModels(ctx context.Context) ([]providers.Model, error) {
-	return []providers.Model{
-		{
-			ID:          "claude-opus-4-5-20251101",
-			Name:        "Claude Opus 4.5",
-			Provider:    "anthropic",
-			ContextSize: 200000,
-			Pricing: providers.ModelPricing{
-				InputPer1M:  15.00,
-				OutputPer1M: 75.00,
-			},
+// knownModels holds the context size and pricing of models, which the
+// models endpoint doesn't report. It is also the list used offline.
+var knownModels = []providers.Model{
+	{
+		ID:          "claude-opus-4-5-20251101",
+		Name:        "Claude Opus 4.5",
+		Provider:    "anthropic",
+		ContextSize: 200000,
+		Pricing: providers.ModelPricing{
+			InputPer1M:      15.00,
+			OutputPer1M:     75.00,
+			CachedPer1M:     1.50,
+			CacheWritePer1M: 18.75,
 		},
-		{
-			ID:          "claude-sonnet-4-5-20250929",
-			Name:        "Claude Sonnet 4.5",
-			Provider:    "anthropic",
-			ContextSize: 200000,
-			Pricing: providers.ModelPricing{
-				InputPer1M:  3.00,
-				OutputPer1M: 15.00,
-			},
+		SupportsTools:  true,
+		SupportsVision: true,
+	},
+	{
+		ID:          "claude-sonnet-4-5-20250929",
+		Name:        "Claude Sonnet 4.5",
+		Provider:    "anthropic",
+		ContextSize: 200000,
+		Pricing: providers.ModelPricing{
+			InputPer1M:      3.00,
+			OutputPer1M:     15.00,
+			CachedPer1M:     0.30,
+			CacheWritePer1M: 3.75,
 		},
-		{
-			ID:          "claude-sonnet-4-20250514",
-			Name:        "Claude Sonnet 4",
-			Provider:    "anthropic",
-			ContextSize: 200000,
-			Pricing: providers.ModelPricing{
-				InputPer1M:  3.00,
-				OutputPer1M: 15.00,
-			},
+		SupportsTools:  true,
+		SupportsVision: true,
+	},
+	{
+		ID:          "claude-sonnet-4-20250514",
+		Name:        "Claude Sonnet 4",
+		Provider:    "anthropic",
+		ContextSize: 200000,
+		Pricing: providers.ModelPricing{
+			InputPer1M:      3.00,
+			OutputPer1M:     15.00,
+			CachedPer1M:     0.30,
+			CacheWritePer1M: 3.75,
 		},
-		{
-			ID:          "claude-haiku-4-5-20251015",
-			Name:        "Claude Haiku 4.5",
-			Provider:    "anthropic",
-			ContextSize: 200000,
-			Pricing: providers.ModelPricing{
-				InputPer1M:  1.00,
-				OutputPer1M: 5.00,
-			},
+		SupportsTools:  true,
+		SupportsVision: true,
+	},
+	{
+		ID:          "claude-haiku-4-5-20251015",
+		Name:        "Claude Haiku 4.5",
+		Provider:    "anthropic",
+		ContextSize: 200000,
+		Pricing: providers.ModelPricing{
+			InputPer1M:      1.00,
+			OutputPer1M:     5.00,
+			CachedPer1M:     0.10,
+			CacheWritePer1M: 1.25,
 		},
-	}, nil
+		SupportsTools:  true,
+		SupportsVision: true,
+	},
+}
+
+func (c *Client) ListModels(ctx context.Context) ([]providers.Model, error) {
+	if c.bedrock != nil {
+		return slices.Clone(c.bedrock.models), nil
+	}
+	if c.modelCache == nil {
+		return slices.Clone(knownModels), nil
+	}
+	return providers.DiscoverModels(ctx, c.modelCache, "anthropic", c.fetchModels, slices.Clone(knownModels)), nil
+}
+
+// SetModelCache makes ListModels query the models endpoint, keeping the
+// result in cache. Without a cache only the known models are listed.
+func (c *Client) SetModelCache(cache *providers.ModelCache) {
+	c.modelCache = cache
+}
+
+func (c *Client) fetchModels(ctx context.Context) ([]providers.Model, error) {
+	httpReq, err := http.NewRequestWithContext(ctx, "GET", c.modelsEndpoint+"?limit=1000", nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	httpReq.Header.Set("X-API-Key", c.apiKey)
+	httpReq.Header.Set("Anthropic-Version", apiVersion)
+
+	resp, err := c.client.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch models: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, providers.NewAPIError(resp)
+	}
+	defer resp.Body.Close()
+
+	var result struct {
+		Data []struct {
+			ID          string `json:"id"`
+			DisplayName string `json:"display_name"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("failed to decode models: %w", err)
+	}
+
+	models := make([]providers.Model, 0, len(result.Data))
+	for _, m := range result.Data {
+		models = append(models, providers.Model{
+			ID:             m.ID,
+			Name:           m.DisplayName,
+			Provider:       "anthropic",
+			ContextSize:    defaultContextSize,
+			SupportsTools:  true,
+			SupportsVision: true,
+		})
+	}
+	return providers.MergeModelMetadata(models, knownModels), nil
 }
 
 func (c *Client) Chat(ctx context.Context, req *providers.ChatRequest) (<-chan providers.ChatEvent, error) {
+	if c.bedrock != nil {
+		return c.chatBedrock(ctx, req)
+	}
+
 	apiReq := c.buildRequest(req)
 
 	body, err := json.Marshal(apiReq)
@@ -116,14 +210,12 @@ func (c *Client) Chat(ctx context.Context, req *providers.ChatRequest) (<-chan p
 	}
 
 	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		resp.Body.Close()
-		return nil, fmt.Errorf("API error (status %d): %s", resp.StatusCode, string(body))
+		return nil, providers.NewAPIError(resp)
 	}
 
 	eventChan := make(chan providers.ChatEvent, 10)
 
-	go c.streamResponse(resp.Body, eventChan)
+	go c.streamResponse(resp.Body, newStreamState(req), eventChan)
 
 	return eventChan, nil
 }
@@ -135,12 +227,32 @@ func (c *Client) buildRequest(req *providers.ChatRequest) map[string]interface{}
 		"stream":     true,
 	}
 
-	if req.Temperature > 0 {
+	// Thinking counts towards max_tokens and can't be combined with a
+	// custom temperature, nor with forced tool use.
+	if req.ThinkingBudget > 0 && !forcesToolUse(req) {
+		budget := req.ThinkingBudget
+		if budget < minThinkingBudget {
+			budget = minThinkingBudget
+		}
+		if req.MaxTokens <= budget {
+			apiReq["max_tokens"] = budget + req.MaxTokens
+		}
+		apiReq["thinking"] = map[string]interface{}{
+			"type":          "enabled",
+			"budget_tokens": budget,
+		}
+	} else if req.Temperature > 0 {
 		apiReq["temperature"] = req.Temperature
 	}
 
 	if req.System != "" {
-		apiReq["system"] = req.System
+		apiReq["system"] = []map[string]interface{}{
+			{
+				"type":          "text",
+				"text":          req.System,
+				"cache_control": ephemeralCache(),
+			},
+		}
 	}
 
 	messages := make([]map[string]interface{}, 0, len(req.Messages))
@@ -149,8 +261,14 @@ func (c *Client) buildRequest(req *providers.ChatRequest) map[string]interface{}
 			continue
 		}
 
+		// Tool results are sent back as user turns
+		role := msg.Role
+		if role == providers.RoleTool {
+			role = providers.RoleUser
+		}
+
 		apiMsg := map[string]interface{}{
-			"role": string(msg.Role),
+			"role": string(role),
 		}
 
 		if len(msg.Content) == 1 {
@@ -165,23 +283,103 @@ func (c *Client) buildRequest(req *providers.ChatRequest) map[string]interface{}
 
 		messages = append(messages, apiMsg)
 	}
+	markCacheBreakpoint(messages)
 	apiReq["messages"] = messages
 
+	tools := make([]map[string]interface{}, 0, len(req.Tools)+1)
+	for _, tool := range req.Tools {
+		tools = append(tools, map[string]interface{}{
+			"name":         tool.Name,
+			"description":  tool.Description,
+			"input_schema": tool.InputSchema,
+		})
+	}
+
 	if len(req.Tools) > 0 {
-		tools := make([]map[string]interface{}, 0, len(req.Tools))
-		for _, tool := range req.Tools {
-			tools = append(tools, map[string]interface{}{
-				"name":         tool.Name,
-				"description":  tool.Description,
-				"input_schema": tool.InputSchema,
-			})
+		switch req.ToolChoice {
+		case "", providers.ToolChoiceAuto:
+		case providers.ToolChoiceNone:
+			apiReq["tool_choice"] = map[string]interface{}{"type": "none"}
+		case providers.ToolChoiceRequired:
+			apiReq["tool_choice"] = map[string]interface{}{"type": "any"}
+		default:
+			apiReq["tool_choice"] = map[string]interface{}{"type": "tool", "name": string(req.ToolChoice)}
 		}
+	}
+
+	// There is no JSON mode, so the answer is requested as the input of a
+	// tool the model is made to call. With other tools it may call those
+	// first, unless they are forbidden; streamResponse turns the answer
+	// back into text. A choice naming a tool still takes precedence.
+	if format := req.ResponseFormat; format != nil {
+		tools = append(tools, map[string]interface{}{
+			"name":         format.Name,
+			"description":  "Give the final answer. Call this instead of replying with text.",
+			"input_schema": format.Schema,
+		})
+		switch {
+		case len(req.Tools) == 0 || req.ToolChoice == providers.ToolChoiceNone:
+			apiReq["tool_choice"] = map[string]interface{}{"type": "tool", "name": format.Name}
+		case req.ToolChoice.ForcedTool() == "":
+			apiReq["tool_choice"] = map[string]interface{}{"type": "any"}
+		}
+	}
+
+	if len(tools) > 0 {
+		tools[len(tools)-1]["cache_control"] = ephemeralCache()
 		apiReq["tools"] = tools
 	}
 
 	return apiReq
 }
 
+// forcesToolUse reports whether the request makes the model call a tool.
+func forcesToolUse(req *providers.ChatRequest) bool {
+	if req.ResponseFormat != nil {
+		return true
+	}
+	return len(req.Tools) > 0 && req.ToolChoice != "" &&
+		req.ToolChoice != providers.ToolChoiceAuto && req.ToolChoice != providers.ToolChoiceNone
+}
+
+// Prompt caching covers everything up to a breakpoint: tools, then the
+// system prompt, then messages. Marking the end of each lets every loop
+// iteration reuse the previous request's prefix.
+func ephemeralCache() map[string]interface{} {
+	return map[string]interface{}{"type": "ephemeral"}
+}
+
+// markCacheBreakpoint marks the last cacheable block of the final message,
+// which the next request will send again unchanged.
+func markCacheBreakpoint(messages []map[string]interface{}) {
+	if len(messages) == 0 {
+		return
+	}
+	last := messages[len(messages)-1]
+
+	if text, ok := last["content"].(string); ok {
+		last["content"] = []map[string]interface{}{
+			{
+				"type":          "text",
+				"text":          text,
+				"cache_control": ephemeralCache(),
+			},
+		}
+		return
+	}
+
+	blocks, _ := last["content"].([]map[string]interface{})
+	for i := len(blocks) - 1; i >= 0; i-- {
+		// Thinking blocks can't carry cache_control
+		switch blocks[i]["type"] {
+		case "thinking", "redacted_thinking":
+			continue
+		}
+		blocks[i]["cache_control"] = ephemeralCache()
+		return
+	}
+}
+
 func (c *Client) convertContent(blocks []providers.ContentBlock) []map[string]interface{} {
 	result := make([]map[string]interface{}, 0, len(blocks))
 
@@ -201,11 +399,43 @@ func (c *Client) convertContent(blocks []providers.ContentBlock) []map[string]in
 					"data":       b.Source.Data,
 				},
 			})
+		case *providers.ThinkingContent:
+			if b.Redacted() {
+				result = append(result, map[string]interface{}{
+					"type": "redacted_thinking",
+					"data": b.Data,
+				})
+			} else {
+				result = append(result, map[string]interface{}{
+					"type":      "thinking",
+					"thinking":  b.Thinking,
+					"signature": b.Signature,
+				})
+			}
+		case *providers.ToolUseContent:
+			input := b.Input
+			if input == nil {
+				input = map[string]interface{}{}
+			}
+			result = append(result, map[string]interface{}{
+				"type":  "tool_use",
+				"id":    b.ID,
+				"name":  b.Name,
+				"input": input,
+			})
 		case *providers.ToolResultContent:
+			var content interface{} = b.Content
+			if len(b.Images) > 0 {
+				blocks := []providers.ContentBlock{&providers.TextContent{Text: b.Content}}
+				for _, img := range b.Images {
+					blocks = append(blocks, img)
+				}
+				content = c.convertContent(blocks)
+			}
 			result = append(result, map[string]interface{}{
 				"type":        "tool_result",
 				"tool_use_id": b.ToolUseID,
-				"content":     b.Content,
+				"content":     content,
 				"is_error":    b.IsError,
 			})
 		}
@@ -214,11 +444,46 @@ func (c *Client) convertContent(blocks []providers.ContentBlock) []map[string]in
 	return result
 }
 
-func (c *Client) streamResponse(body io.ReadCloser, eventChan chan<- providers.ChatEvent) {
+// maxLineSize bounds a single SSE line; tool inputs arrive in many small
+// deltas, but text deltas can be long.
+const maxLineSize = 1024 * 1024
+
+// streamState tracks the content blocks of one streamed message by index.
+// Calls to responseTool carry the answer of a response format and are
+// emitted as text.
+type streamState struct {
+	blocks       map[int]*blockState
+	usage        providers.Usage
+	responseTool string
+	stopped      bool // message_stop arrived
+}
+
+type blockState struct {
+	blockType string
+	id        string
+	name      string
+	inputJSON strings.Builder
+	thinking  strings.Builder
+	signature string
+	data      string
+}
+
+func newStreamState(req *providers.ChatRequest) *streamState {
+	state := &streamState{
+		blocks: make(map[int]*blockState),
+	}
+	if req.ResponseFormat != nil {
+		state.responseTool = req.ResponseFormat.Name
+	}
+	return state
+}
+
+func (c *Client) streamResponse(body io.ReadCloser, state *streamState, eventChan chan<- providers.ChatEvent) {
 	defer close(eventChan)
 	defer body.Close()
 
 	scanner := bufio.NewScanner(body)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxLineSize)
 	for scanner.Scan() {
 		line := scanner.Text()
 		if !strings.HasPrefix(line, "data: ") {
@@ -239,7 +504,13 @@ func (c *Client) streamResponse(body io.ReadCloser, eventChan chan<- providers.C
 			return
 		}
 
-		c.handleEvent(event, eventChan)
+		if err := c.handleEvent(state, event, eventChan); err != nil {
+			eventChan <- providers.ChatEvent{
+				Type:  providers.EventTypeError,
+				Error: err,
+			}
+			return
+		}
 	}
 
 	if err := scanner.Err(); err != nil {
@@ -250,21 +521,40 @@ func (c *Client) streamResponse(body io.ReadCloser, eventChan chan<- providers.C
 	}
 }
 
-func (c *Client) handleEvent(event map[string]interface{}, eventChan chan<- providers.ChatEvent) {
+func (c *Client) handleEvent(state *streamState, event map[string]interface{}, eventChan chan<- providers.ChatEvent) error {
 	eventType, _ := event["type"].(string)
+	index := eventIndex(event)
 
 	switch eventType {
 	case "message_start":
+		if message, ok := event["message"].(map[string]interface{}); ok {
+			state.recordUsage(message["usage"])
+		}
 		eventChan <- providers.ChatEvent{
 			Type: providers.EventTypeMessageStart,
 		}
 
 	case "content_block_start":
+		block, ok := event["content_block"].(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("content_block_start %d has no content_block", index)
+		}
+
+		blockType, _ := block["type"].(string)
+		id, _ := block["id"].(string)
+		name, _ := block["name"].(string)
+		data, _ := block["data"].(string)
+		state.blocks[index] = &blockState{
+			blockType: blockType,
+			id:        id,
+			name:      name,
+			data:      data,
+		}
 
 	case "content_block_delta":
 		delta, ok := event["delta"].(map[string]interface{})
 		if !ok {
-			return
+			return nil
 		}
 
 		deltaType, _ := delta["type"].(string)
@@ -277,34 +567,135 @@ func (c *Client) handleEvent(event map[string]interface{}, eventChan chan<- prov
 				}
 			}
 		case "input_json_delta":
-
+			block, ok := state.blocks[index]
+			if !ok {
+				return fmt.Errorf("input_json_delta for unknown content block %d", index)
+			}
+			partial, _ := delta["partial_json"].(string)
+			block.inputJSON.WriteString(partial)
+		case "thinking_delta":
+			block, ok := state.blocks[index]
+			if !ok {
+				return fmt.Errorf("thinking_delta for unknown content block %d", index)
+			}
+			thinking, _ := delta["thinking"].(string)
+			block.thinking.WriteString(thinking)
+			eventChan <- providers.ChatEvent{
+				Type:    providers.EventTypeThinkingDelta,
+				Content: thinking,
+			}
+		case "signature_delta":
+			block, ok := state.blocks[index]
+			if !ok {
+				return fmt.Errorf("signature_delta for unknown content block %d", index)
+			}
+			signature, _ := delta["signature"].(string)
+			block.signature += signature
 		}
 
 	case "content_block_stop":
-		index, _ := event["index"].(float64)
-		contentBlock := event["content_block"]
-		if contentBlock != nil {
-			if blockMap, ok := contentBlock.(map[string]interface{}); ok {
-				if blockMap["type"] == "tool_use" {
-					toolUse := &providers.ToolUseContent{
-						ID:    blockMap["id"].(string),
-						Name:  blockMap["name"].(string),
-						Input: blockMap["input"].(map[string]interface{}),
-					}
-					eventChan <- providers.ChatEvent{
-						Type:    providers.EventTypeToolUse,
-						ToolUse: toolUse,
-					}
-				}
+		block, ok := state.blocks[index]
+		if !ok {
+			return nil
+		}
+		delete(state.blocks, index)
+
+		if block.blockType == "thinking" || block.blockType == "redacted_thinking" {
+			eventChan <- providers.ChatEvent{
+				Type: providers.EventTypeThinking,
+				Thinking: &providers.ThinkingContent{
+					Thinking:  block.thinking.String(),
+					Signature: block.signature,
+					Data:      block.data,
+				},
+			}
+			return nil
+		}
+
+		if block.blockType != "tool_use" {
+			return nil
+		}
+
+		if state.responseTool != "" && block.name == state.responseTool {
+			raw := strings.TrimSpace(block.inputJSON.String())
+			if raw == "" {
+				raw = "{}"
+			}
+			var answer bytes.Buffer
+			if err := json.Compact(&answer, []byte(raw)); err != nil {
+				return fmt.Errorf("invalid response from %s: %w", block.name, err)
+			}
+			eventChan <- providers.ChatEvent{
+				Type:    providers.EventTypeTextDelta,
+				Content: answer.String(),
+			}
+			return nil
+		}
+
+		input := map[string]interface{}{}
+		if raw := strings.TrimSpace(block.inputJSON.String()); raw != "" {
+			if err := json.Unmarshal([]byte(raw), &input); err != nil {
+				return fmt.Errorf("invalid input for tool %s (%s): %w", block.name, block.id, err)
 			}
 		}
-		_ = index
+
+		eventChan <- providers.ChatEvent{
+			Type: providers.EventTypeToolUse,
+			ToolUse: &providers.ToolUseContent{
+				ID:    block.id,
+				Name:  block.name,
+				Input: input,
+			},
+		}
 
 	case "message_delta":
+		state.recordUsage(event["usage"])
 
 	case "message_stop":
+		state.stopped = true
+		usage := state.usage
+		usage.TotalTokens = usage.InputTokens + usage.OutputTokens +
+			usage.CacheCreationInputTokens + usage.CacheReadInputTokens
 		eventChan <- providers.ChatEvent{
-			Type: providers.EventTypeMessageDone,
+			Type:  providers.EventTypeMessageDone,
+			Usage: &usage,
 		}
+
+	case "error":
+		message := "unknown error"
+		if apiErr, ok := event["error"].(map[string]interface{}); ok {
+			errType, _ := apiErr["type"].(string)
+			errMessage, _ := apiErr["message"].(string)
+			message = strings.TrimPrefix(errType+": "+errMessage, ": ")
+		}
+		return fmt.Errorf("stream error: %s", message)
+	}
+
+	return nil
+}
+
+// recordUsage merges a usage object from message_start or message_delta.
+// Counts in message_delta are cumulative, so later values replace earlier ones.
+func (s *streamState) recordUsage(raw interface{}) {
+	usage, ok := raw.(map[string]interface{})
+	if !ok {
+		return
+	}
+	if input, ok := usage["input_tokens"].(float64); ok {
+		s.usage.InputTokens = int(input)
+	}
+	if output, ok := usage["output_tokens"].(float64); ok {
+		s.usage.OutputTokens = int(output)
 	}
+	if created, ok := usage["cache_creation_input_tokens"].(float64); ok {
+		s.usage.CacheCreationInputTokens = int(created)
+	}
+	if read, ok := usage["cache_read_input_tokens"].(float64); ok {
+		s.usage.CacheReadInputTokens = int(read)
+	}
+}
+
+func eventIndex(event map[string]interface{}) int {
+	index, _ := event["index"].(float64)
+	return int(index)
 }
@@ -5,7 +5,12 @@ import (
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/taaha3244/potus/internal/providers"
 )
@@ -84,6 +89,62 @@ func TestClient_ListModels(t *testing.T) {
 	}
 }
 
+func TestClient_ListModels_Discovery(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/models" || r.Header.Get("X-API-Key") != "test-key" {
+			t.Errorf("unexpected request %s %s", r.URL.Path, r.Header.Get("X-API-Key"))
+		}
+		w.Write([]byte(`{"data":[
+			{"type":"model","id":"claude-sonnet-4-5-20250929","display_name":"Claude Sonnet 4.5"},
+			{"type":"model","id":"claude-opus-5-20260301","display_name":"Claude Opus 5"}
+		],"has_more":false}`))
+	}))
+	defer server.Close()
+
+	client, _ := New("test-key")
+	client.modelsEndpoint = server.URL + "/v1/models"
+	cacheDir := t.TempDir()
+	client.SetModelCache(providers.NewModelCache(cacheDir, time.Hour))
+
+	models, err := client.ListModels(context.Background())
+	if err != nil {
+		t.Fatalf("ListModels() error = %v", err)
+	}
+	if len(models) != 2 {
+		t.Fatalf("got %d models, want 2", len(models))
+	}
+
+	if models[0].Pricing.InputPer1M != 3.00 || models[0].Pricing.CachedPer1M != 0.30 {
+		t.Errorf("known model pricing = %+v", models[0].Pricing)
+	}
+	if models[1].Name != "Claude Opus 5" || models[1].ContextSize != 200000 || !models[1].SupportsTools {
+		t.Errorf("new model = %+v", models[1])
+	}
+
+	if _, err := os.Stat(filepath.Join(cacheDir, "anthropic.json")); err != nil {
+		t.Errorf("model list not cached: %v", err)
+	}
+}
+
+func TestClient_ListModels_Offline(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	client, _ := New("test-key")
+	client.modelsEndpoint = server.URL
+	client.SetModelCache(providers.NewModelCache(t.TempDir(), time.Hour))
+
+	models, err := client.ListModels(context.Background())
+	if err != nil {
+		t.Fatalf("ListModels() error = %v", err)
+	}
+	if len(models) != len(knownModels) {
+		t.Errorf("got %d models, want the %d known models", len(models), len(knownModels))
+	}
+}
+
 func TestClient_Chat(t *testing.T) {
 	t.Run("successful streaming response", func(t *testing.T) {
 		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -236,6 +297,26 @@ func TestClient_BuildRequest(t *testing.T) {
 		}
 	})
 
+	t.Run("tool results sent as user turn", func(t *testing.T) {
+		req := &providers.ChatRequest{
+			Model:     "claude-3-sonnet",
+			MaxTokens: 1024,
+			Messages: []providers.Message{
+				{
+					Role:    providers.RoleTool,
+					Content: []providers.ContentBlock{&providers.ToolResultContent{ToolUseID: "toolu_1", Content: "ok"}},
+				},
+			},
+		}
+
+		apiReq := client.buildRequest(req)
+
+		messages := apiReq["messages"].([]map[string]interface{})
+		if messages[0]["role"] != "user" {
+			t.Errorf("role = %v, want user", messages[0]["role"])
+		}
+	})
+
 	t.Run("with system prompt", func(t *testing.T) {
 		req := &providers.ChatRequest{
 			Model:     "claude-3-sonnet",
@@ -251,8 +332,88 @@ func TestClient_BuildRequest(t *testing.T) {
 
 		apiReq := client.buildRequest(req)
 
-		if apiReq["system"] != "You are a helpful assistant" {
-			t.Errorf("system = %v, want 'You are a helpful assistant'", apiReq["system"])
+		system, ok := apiReq["system"].([]map[string]interface{})
+		if !ok || len(system) != 1 {
+			t.Fatalf("system = %v, want one text block", apiReq["system"])
+		}
+		if system[0]["text"] != "You are a helpful assistant" {
+			t.Errorf("system text = %v, want 'You are a helpful assistant'", system[0]["text"])
+		}
+		if system[0]["cache_control"] == nil {
+			t.Error("system prompt should be a cache breakpoint")
+		}
+	})
+
+	t.Run("cache breakpoints", func(t *testing.T) {
+		req := &providers.ChatRequest{
+			Model:     "claude-sonnet-4-5-20250929",
+			MaxTokens: 1024,
+			Messages: []providers.Message{
+				{
+					Role:    providers.RoleUser,
+					Content: []providers.ContentBlock{&providers.TextContent{Text: "Read go.mod"}},
+				},
+				{
+					Role: providers.RoleAssistant,
+					Content: []providers.ContentBlock{
+						&providers.ThinkingContent{Thinking: "Read it.", Signature: "EqQB"},
+						&providers.ToolUseContent{ID: "toolu_1", Name: "file_read"},
+					},
+				},
+				{
+					Role:    providers.RoleTool,
+					Content: []providers.ContentBlock{&providers.ToolResultContent{ToolUseID: "toolu_1", Content: "module x"}},
+				},
+			},
+			Tools: []providers.Tool{
+				{Name: "file_read", InputSchema: map[string]interface{}{"type": "object"}},
+				{Name: "bash", InputSchema: map[string]interface{}{"type": "object"}},
+			},
+		}
+
+		apiReq := client.buildRequest(req)
+
+		tools := apiReq["tools"].([]map[string]interface{})
+		if tools[0]["cache_control"] != nil || tools[1]["cache_control"] == nil {
+			t.Errorf("only the last tool should be a breakpoint: %v", tools)
+		}
+
+		messages := apiReq["messages"].([]map[string]interface{})
+		if messages[0]["content"] != "Read go.mod" {
+			t.Errorf("earlier messages should be unchanged, got %v", messages[0]["content"])
+		}
+		for _, block := range messages[1]["content"].([]map[string]interface{}) {
+			if block["cache_control"] != nil {
+				t.Errorf("assistant block %v should not be a breakpoint", block["type"])
+			}
+		}
+		last := messages[2]["content"].([]map[string]interface{})
+		if last[0]["cache_control"] == nil {
+			t.Error("last message should be a breakpoint")
+		}
+	})
+
+	t.Run("cache breakpoint on plain text message", func(t *testing.T) {
+		req := &providers.ChatRequest{
+			Model:     "claude-sonnet-4-5-20250929",
+			MaxTokens: 1024,
+			Messages: []providers.Message{
+				{
+					Role:    providers.RoleUser,
+					Content: []providers.ContentBlock{&providers.TextContent{Text: "Hello"}},
+				},
+			},
+		}
+
+		apiReq := client.buildRequest(req)
+
+		messages := apiReq["messages"].([]map[string]interface{})
+		content, ok := messages[0]["content"].([]map[string]interface{})
+		if !ok || len(content) != 1 {
+			t.Fatalf("content = %v, want one text block", messages[0]["content"])
+		}
+		if content[0]["text"] != "Hello" || content[0]["cache_control"] == nil {
+			t.Errorf("content = %v", content[0])
 		}
 	})
 
@@ -276,6 +437,55 @@ func TestClient_BuildRequest(t *testing.T) {
 		}
 	})
 
+	t.Run("with thinking budget", func(t *testing.T) {
+		req := &providers.ChatRequest{
+			Model:          "claude-sonnet-4-5-20250929",
+			MaxTokens:      4096,
+			Temperature:    0.7,
+			ThinkingBudget: 8000,
+			Messages: []providers.Message{
+				{
+					Role:    providers.RoleUser,
+					Content: []providers.ContentBlock{&providers.TextContent{Text: "Hello"}},
+				},
+			},
+		}
+
+		apiReq := client.buildRequest(req)
+
+		thinking, ok := apiReq["thinking"].(map[string]interface{})
+		if !ok {
+			t.Fatalf("thinking = %v, want an object", apiReq["thinking"])
+		}
+		if thinking["type"] != "enabled" || thinking["budget_tokens"] != 8000 {
+			t.Errorf("thinking = %v", thinking)
+		}
+		if apiReq["max_tokens"] != 12096 {
+			t.Errorf("max_tokens = %v, want 12096 (budget plus response)", apiReq["max_tokens"])
+		}
+		if _, ok := apiReq["temperature"]; ok {
+			t.Error("temperature should not be sent with thinking enabled")
+		}
+	})
+
+	t.Run("thinking budget below minimum", func(t *testing.T) {
+		req := &providers.ChatRequest{
+			Model:          "claude-sonnet-4-5-20250929",
+			MaxTokens:      8192,
+			ThinkingBudget: 100,
+		}
+
+		apiReq := client.buildRequest(req)
+
+		thinking := apiReq["thinking"].(map[string]interface{})
+		if thinking["budget_tokens"] != 1024 {
+			t.Errorf("budget_tokens = %v, want 1024", thinking["budget_tokens"])
+		}
+		if apiReq["max_tokens"] != 8192 {
+			t.Errorf("max_tokens = %v, want 8192", apiReq["max_tokens"])
+		}
+	})
+
 	t.Run("with tools", func(t *testing.T) {
 		req := &providers.ChatRequest{
 			Model:     "claude-3-sonnet",
@@ -314,6 +524,115 @@ func TestClient_BuildRequest(t *testing.T) {
 		}
 	})
 
+	t.Run("with tool choice", func(t *testing.T) {
+		tests := []struct {
+			choice       providers.ToolChoice
+			format       *providers.ResponseFormat
+			want         map[string]interface{}
+			wantThinking bool
+		}{
+			{"", nil, nil, true},
+			{providers.ToolChoiceNone, nil, map[string]interface{}{"type": "none"}, true},
+			{providers.ToolChoiceRequired, nil, map[string]interface{}{"type": "any"}, false},
+			{"file_read", nil, map[string]interface{}{"type": "tool", "name": "file_read"}, false},
+			{providers.ToolChoiceNone, &providers.ResponseFormat{Name: "answer"}, map[string]interface{}{"type": "tool", "name": "answer"}, false},
+			{"file_read", &providers.ResponseFormat{Name: "answer"}, map[string]interface{}{"type": "tool", "name": "file_read"}, false},
+		}
+
+		for _, tt := range tests {
+			req := &providers.ChatRequest{
+				Model:          "claude-3-sonnet",
+				MaxTokens:      1024,
+				ThinkingBudget: 2048,
+				Messages: []providers.Message{
+					{
+						Role:    providers.RoleUser,
+						Content: []providers.ContentBlock{&providers.TextContent{Text: "Hello"}},
+					},
+				},
+				Tools:          []providers.Tool{{Name: "file_read", InputSchema: map[string]interface{}{"type": "object"}}},
+				ToolChoice:     tt.choice,
+				ResponseFormat: tt.format,
+			}
+
+			apiReq := client.buildRequest(req)
+
+			got, _ := apiReq["tool_choice"].(map[string]interface{})
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("%q: tool_choice = %v, want %v", tt.choice, got, tt.want)
+			}
+			if _, ok := apiReq["thinking"]; ok != tt.wantThinking {
+				t.Errorf("%q: thinking sent = %v, want %v", tt.choice, ok, tt.wantThinking)
+			}
+		}
+	})
+
+	t.Run("response format forces its tool", func(t *testing.T) {
+		req := &providers.ChatRequest{
+			Model:          "claude-3-sonnet",
+			MaxTokens:      1024,
+			ThinkingBudget: 2048,
+			Messages: []providers.Message{
+				{
+					Role:    providers.RoleUser,
+					Content: []providers.ContentBlock{&providers.TextContent{Text: "Hello"}},
+				},
+			},
+			ResponseFormat: &providers.ResponseFormat{
+				Name:   "answer",
+				Schema: map[string]interface{}{"type": "object"},
+			},
+		}
+
+		apiReq := client.buildRequest(req)
+
+		tools := apiReq["tools"].([]map[string]interface{})
+		if len(tools) != 1 || tools[0]["name"] != "answer" || tools[0]["input_schema"] == nil {
+			t.Errorf("tools = %v, want the answer tool", tools)
+		}
+		choice := apiReq["tool_choice"].(map[string]interface{})
+		if choice["type"] != "tool" || choice["name"] != "answer" {
+			t.Errorf("tool_choice = %v, want the answer tool", choice)
+		}
+		if _, ok := apiReq["thinking"]; ok {
+			t.Error("thinking should be disabled with a forced tool")
+		}
+	})
+
+	t.Run("response format with other tools", func(t *testing.T) {
+		req := &providers.ChatRequest{
+			Model:     "claude-3-sonnet",
+			MaxTokens: 1024,
+			Messages: []providers.Message{
+				{
+					Role:    providers.RoleUser,
+					Content: []providers.ContentBlock{&providers.TextContent{Text: "Hello"}},
+				},
+			},
+			Tools: []providers.Tool{
+				{Name: "file_read", Description: "Read a file", InputSchema: map[string]interface{}{"type": "object"}},
+			},
+			ResponseFormat: &providers.ResponseFormat{
+				Name:   "answer",
+				Schema: map[string]interface{}{"type": "object"},
+			},
+		}
+
+		apiReq := client.buildRequest(req)
+
+		tools := apiReq["tools"].([]map[string]interface{})
+		if len(tools) != 2 || tools[1]["name"] != "answer" {
+			t.Fatalf("tools = %v, want file_read then answer", tools)
+		}
+		if _, ok := tools[1]["cache_control"]; !ok {
+			t.Error("last tool should carry the cache breakpoint")
+		}
+		choice := apiReq["tool_choice"].(map[string]interface{})
+		if choice["type"] != "any" {
+			t.Errorf("tool_choice = %v, want any", choice)
+		}
+	})
+
 	t.Run("skips system role messages", func(t *testing.T) {
 		req := &providers.ChatRequest{
 			Model:     "claude-3-sonnet",
@@ -390,6 +709,51 @@ func TestClient_ConvertContent(t *testing.T) {
 		}
 	})
 
+	t.Run("tool use content", func(t *testing.T) {
+		blocks := []providers.ContentBlock{
+			&providers.ToolUseContent{
+				ID:    "toolu_1",
+				Name:  "file_read",
+				Input: map[string]interface{}{"path": "go.mod"},
+			},
+		}
+
+		result := client.convertContent(blocks)
+
+		if len(result) != 1 {
+			t.Fatalf("result length = %d, want 1", len(result))
+		}
+		if result[0]["type"] != "tool_use" {
+			t.Errorf("type = %v, want tool_use", result[0]["type"])
+		}
+		if result[0]["id"] != "toolu_1" || result[0]["name"] != "file_read" {
+			t.Errorf("id/name = %v/%v, want toolu_1/file_read", result[0]["id"], result[0]["name"])
+		}
+		input := result[0]["input"].(map[string]interface{})
+		if input["path"] != "go.mod" {
+			t.Errorf("input.path = %v, want go.mod", input["path"])
+		}
+	})
+
+	t.Run("thinking content", func(t *testing.T) {
+		blocks := []providers.ContentBlock{
+			&providers.ThinkingContent{Thinking: "Read go.mod first.", Signature: "EqQBCkYIBxgC"},
+			&providers.ThinkingContent{Data: "EmwKAhgBEgy3va3pzix"},
+		}
+
+		result := client.convertContent(blocks)
+
+		if len(result) != 2 {
+			t.Fatalf("result length = %d, want 2", len(result))
+		}
+		if result[0]["type"] != "thinking" || result[0]["thinking"] != "Read go.mod first." || result[0]["signature"] != "EqQBCkYIBxgC" {
+			t.Errorf("thinking block = %v", result[0])
+		}
+		if result[1]["type"] != "redacted_thinking" || result[1]["data"] != "EmwKAhgBEgy3va3pzix" {
+			t.Errorf("redacted block = %v", result[1])
+		}
+	})
+
 	t.Run("tool result content", func(t *testing.T) {
 		blocks := []providers.ContentBlock{
 			&providers.ToolResultContent{
@@ -411,6 +775,28 @@ func TestClient_ConvertContent(t *testing.T) {
 			t.Errorf("tool_use_id = %v, want tool_123", result[0]["tool_use_id"])
 		}
 	})
+
+	t.Run("tool result with image", func(t *testing.T) {
+		blocks := []providers.ContentBlock{
+			&providers.ToolResultContent{
+				ToolUseID: "tool_123",
+				Content:   "Image file shot.png",
+				Images: []*providers.ImageContent{
+					{Source: providers.ImageSource{Type: "base64", MediaType: "image/png", Data: "iVBORw0KGgo="}},
+				},
+			},
+		}
+
+		result := client.convertContent(blocks)
+
+		content, ok := result[0]["content"].([]map[string]interface{})
+		if !ok || len(content) != 2 {
+			t.Fatalf("content = %v, want text and image blocks", result[0]["content"])
+		}
+		if content[0]["type"] != "text" || content[1]["type"] != "image" {
+			t.Errorf("content types = %v, %v", content[0]["type"], content[1]["type"])
+		}
+	})
 }
 
 func TestClient_HandleEvent(t *testing.T) {
@@ -423,7 +809,7 @@ func TestClient_HandleEvent(t *testing.T) {
 			"type": "message_start",
 		}
 
-		client.handleEvent(event, eventChan)
+		client.handleEvent(newStreamState(&providers.ChatRequest{}), event, eventChan)
 		close(eventChan)
 
 		received := <-eventChan
@@ -443,7 +829,7 @@ func TestClient_HandleEvent(t *testing.T) {
 			},
 		}
 
-		client.handleEvent(event, eventChan)
+		client.handleEvent(newStreamState(&providers.ChatRequest{}), event, eventChan)
 		close(eventChan)
 
 		received := <-eventChan
@@ -462,7 +848,7 @@ func TestClient_HandleEvent(t *testing.T) {
 			"type": "message_stop",
 		}
 
-		client.handleEvent(event, eventChan)
+		client.handleEvent(newStreamState(&providers.ChatRequest{}), event, eventChan)
 		close(eventChan)
 
 		received := <-eventChan
@@ -470,4 +856,233 @@ func TestClient_HandleEvent(t *testing.T) {
 			t.Errorf("Type = %v, want message_done", received.Type)
 		}
 	})
+
+	t.Run("prompt cache usage", func(t *testing.T) {
+		eventChan := make(chan providers.ChatEvent, 10)
+		state := newStreamState(&providers.ChatRequest{})
+
+		client.handleEvent(state, map[string]interface{}{
+			"type": "message_start",
+			"message": map[string]interface{}{
+				"usage": map[string]interface{}{
+					"input_tokens":                float64(12),
+					"cache_creation_input_tokens": float64(300),
+					"cache_read_input_tokens":     float64(4000),
+					"output_tokens":               float64(1),
+				},
+			},
+		}, eventChan)
+		client.handleEvent(state, map[string]interface{}{
+			"type":  "message_delta",
+			"usage": map[string]interface{}{"output_tokens": float64(50)},
+		}, eventChan)
+		client.handleEvent(state, map[string]interface{}{"type": "message_stop"}, eventChan)
+		close(eventChan)
+
+		var usage *providers.Usage
+		for event := range eventChan {
+			if event.Type == providers.EventTypeMessageDone {
+				usage = event.Usage
+			}
+		}
+		if usage == nil {
+			t.Fatal("no usage reported")
+		}
+		want := providers.Usage{
+			InputTokens:              12,
+			OutputTokens:             50,
+			TotalTokens:              4362,
+			CacheCreationInputTokens: 300,
+			CacheReadInputTokens:     4000,
+		}
+		if *usage != want {
+			t.Errorf("usage = %+v, want %+v", *usage, want)
+		}
+	})
+}
+
+// streamFixture replays a recorded SSE stream from testdata through
+// streamResponse and collects the emitted events.
+func streamFixture(t *testing.T, name string) []providers.ChatEvent {
+	t.Helper()
+	return streamFixtureFor(t, name, &providers.ChatRequest{})
+}
+
+// streamFixtureFor is streamFixture for a stream answering req.
+func streamFixtureFor(t *testing.T, name string, req *providers.ChatRequest) []providers.ChatEvent {
+	t.Helper()
+
+	f, err := os.Open(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatalf("failed to open fixture: %v", err)
+	}
+
+	client := &Client{}
+	eventChan := make(chan providers.ChatEvent, 100)
+	client.streamResponse(f, newStreamState(req), eventChan)
+
+	var events []providers.ChatEvent
+	for event := range eventChan {
+		events = append(events, event)
+	}
+	return events
+}
+
+func TestClient_StreamResponse_ParallelToolUse(t *testing.T) {
+	events := streamFixture(t, "parallel_tool_use.sse")
+
+	var text string
+	var toolUses []*providers.ToolUseContent
+	for _, event := range events {
+		switch event.Type {
+		case providers.EventTypeTextDelta:
+			text += event.Content
+		case providers.EventTypeToolUse:
+			toolUses = append(toolUses, event.ToolUse)
+		case providers.EventTypeError:
+			t.Fatalf("unexpected error: %v", event.Error)
+		}
+	}
+
+	if text != "I'll read both files to compare them." {
+		t.Errorf("text = %q", text)
+	}
+
+	if len(toolUses) != 3 {
+		t.Fatalf("got %d tool uses, want 3", len(toolUses))
+	}
+
+	if toolUses[0].ID != "toolu_01T1x1fJ34qAmk2tNTrN7Up6" || toolUses[0].Name != "file_read" {
+		t.Errorf("tool 0 = %s %s", toolUses[0].ID, toolUses[0].Name)
+	}
+	if toolUses[0].Input["path"] != "go.mod" {
+		t.Errorf("tool 0 input = %v", toolUses[0].Input)
+	}
+
+	if toolUses[1].ID != "toolu_01PZ8e6q4kVg2bH9Ys4uFbVJ" {
+		t.Errorf("tool 1 ID = %s", toolUses[1].ID)
+	}
+	if toolUses[1].Input["path"] != "go.sum" || toolUses[1].Input["start_line"] != float64(10) {
+		t.Errorf("tool 1 input = %v", toolUses[1].Input)
+	}
+
+	if toolUses[2].Name != "git_status" {
+		t.Errorf("tool 2 name = %s", toolUses[2].Name)
+	}
+	if toolUses[2].Input == nil || len(toolUses[2].Input) != 0 {
+		t.Errorf("tool 2 input = %v, want empty object", toolUses[2].Input)
+	}
+
+	last := events[len(events)-1]
+	if last.Type != providers.EventTypeMessageDone {
+		t.Fatalf("last event = %v, want message_done", last.Type)
+	}
+	if last.Usage == nil {
+		t.Fatal("message_done has no usage")
+	}
+	if last.Usage.InputTokens != 1423 || last.Usage.OutputTokens != 112 || last.Usage.TotalTokens != 1535 {
+		t.Errorf("usage = %+v, want 1423 in / 112 out / 1535 total", *last.Usage)
+	}
+}
+
+func TestClient_StreamResponse_Thinking(t *testing.T) {
+	events := streamFixture(t, "thinking.sse")
+
+	var deltas string
+	var blocks []*providers.ThinkingContent
+	var order []providers.EventType
+	for _, event := range events {
+		switch event.Type {
+		case providers.EventTypeThinkingDelta:
+			deltas += event.Content
+		case providers.EventTypeThinking:
+			blocks = append(blocks, event.Thinking)
+			order = append(order, event.Type)
+		case providers.EventTypeTextDelta:
+			order = append(order, event.Type)
+		case providers.EventTypeError:
+			t.Fatalf("unexpected error: %v", event.Error)
+		}
+	}
+
+	if deltas != "The user wants the module path. Reading go.mod answers that." {
+		t.Errorf("thinking deltas = %q", deltas)
+	}
+
+	if len(blocks) != 2 {
+		t.Fatalf("got %d thinking blocks, want 2", len(blocks))
+	}
+	if blocks[0].Thinking != deltas || blocks[0].Signature != "EqQBCkYIBxgCKkBvR3n8Jm1t" || blocks[0].Redacted() {
+		t.Errorf("thinking block = %+v", *blocks[0])
+	}
+	if !blocks[1].Redacted() || blocks[1].Thinking != "" {
+		t.Errorf("redacted block = %+v", *blocks[1])
+	}
+
+	want := []providers.EventType{providers.EventTypeThinking, providers.EventTypeThinking, providers.EventTypeTextDelta}
+	if len(order) != len(want) {
+		t.Fatalf("event order = %v, want %v", order, want)
+	}
+	for i := range want {
+		if order[i] != want[i] {
+			t.Errorf("event order = %v, want %v", order, want)
+			break
+		}
+	}
+}
+
+func TestClient_StreamResponse_ResponseFormat(t *testing.T) {
+	req := &providers.ChatRequest{
+		ResponseFormat: &providers.ResponseFormat{Name: "answer", Schema: map[string]interface{}{"type": "object"}},
+	}
+	events := streamFixtureFor(t, "response_format.sse", req)
+
+	var text string
+	for _, event := range events {
+		switch event.Type {
+		case providers.EventTypeTextDelta:
+			text += event.Content
+		case providers.EventTypeToolUse:
+			t.Errorf("unexpected tool use %s", event.ToolUse.Name)
+		case providers.EventTypeError:
+			t.Fatalf("unexpected error: %v", event.Error)
+		}
+	}
+
+	if text != `{"module":"github.com/taaha3244/potus","go":"1.24"}` {
+		t.Errorf("text = %q", text)
+	}
+	if events[len(events)-1].Type != providers.EventTypeMessageDone {
+		t.Errorf("last event = %v, want message_done", events[len(events)-1].Type)
+	}
+}
+
+func TestClient_StreamResponse_Errors(t *testing.T) {
+	tests := []struct {
+		fixture string
+		wantErr string
+	}{
+		{"malformed_tool_input.sse", "invalid input for tool bash (toolu_01D7FLrfh4GYq7yT1ULmeyz8)"},
+		{"overloaded_error.sse", "stream error: overloaded_error: Overloaded"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.fixture, func(t *testing.T) {
+			events := streamFixture(t, tt.fixture)
+
+			for _, event := range events {
+				if event.Type == providers.EventTypeToolUse || event.Type == providers.EventTypeMessageDone {
+					t.Errorf("unexpected %s event", event.Type)
+				}
+			}
+
+			last := events[len(events)-1]
+			if last.Type != providers.EventTypeError {
+				t.Fatalf("last event = %v, want error", last.Type)
+			}
+			if !strings.Contains(last.Error.Error(), tt.wantErr) {
+				t.Errorf("error = %q, want it to contain %q", last.Error, tt.wantErr)
+			}
+		})
+	}
 }
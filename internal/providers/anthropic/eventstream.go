@@ -0,0 +1,117 @@
+package anthropic
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+)
+
+// maxEventStreamMessage bounds one event-stream message; Bedrock sends one
+// model event per message.
+const maxEventStreamMessage = 16 * 1024 * 1024
+
+// eventStreamMessage is one message of the AWS event-stream encoding:
+// headers, of which only string values are kept, and a payload.
+type eventStreamMessage struct {
+	Headers map[string]string
+	Payload []byte
+}
+
+// eventStreamReader decodes the binary framing of AWS event streams. Each
+// message is a 12 byte prelude (total length, headers length, prelude
+// CRC), the headers, the payload and a CRC of everything before it.
+type eventStreamReader struct {
+	r io.Reader
+}
+
+func newEventStreamReader(r io.Reader) *eventStreamReader {
+	return &eventStreamReader{r: r}
+}
+
+// ReadMessage returns the next message, or io.EOF at the end of the
+// stream.
+func (e *eventStreamReader) ReadMessage() (*eventStreamMessage, error) {
+	prelude := make([]byte, 12)
+	if _, err := io.ReadFull(e.r, prelude); err != nil {
+		if errors.Is(err, io.ErrUnexpectedEOF) {
+			return nil, fmt.Errorf("truncated event-stream prelude")
+		}
+		return nil, err
+	}
+
+	totalLen := binary.BigEndian.Uint32(prelude[0:4])
+	headersLen := binary.BigEndian.Uint32(prelude[4:8])
+	if crc32.ChecksumIEEE(prelude[0:8]) != binary.BigEndian.Uint32(prelude[8:12]) {
+		return nil, fmt.Errorf("event-stream prelude checksum mismatch")
+	}
+	// Compared in 64 bits so that a huge headers length can't wrap around
+	if uint64(totalLen) < 16+uint64(headersLen) || totalLen > maxEventStreamMessage {
+		return nil, fmt.Errorf("invalid event-stream message length %d", totalLen)
+	}
+
+	message := make([]byte, totalLen)
+	copy(message, prelude)
+	if _, err := io.ReadFull(e.r, message[12:]); err != nil {
+		return nil, fmt.Errorf("truncated event-stream message: %w", err)
+	}
+
+	crcOffset := totalLen - 4
+	if crc32.ChecksumIEEE(message[:crcOffset]) != binary.BigEndian.Uint32(message[crcOffset:]) {
+		return nil, fmt.Errorf("event-stream message checksum mismatch")
+	}
+
+	headers, err := decodeEventStreamHeaders(message[12 : 12+headersLen])
+	if err != nil {
+		return nil, err
+	}
+
+	return &eventStreamMessage{
+		Headers: headers,
+		Payload: message[12+headersLen : crcOffset],
+	}, nil
+}
+
+// Sizes of the fixed-length header value types, by type ID. Boolean
+// values (0 and 1) have no value bytes; byte arrays (6) and strings (7)
+// are length-prefixed.
+var eventStreamValueSizes = map[byte]int{0: 0, 1: 0, 2: 1, 3: 2, 4: 4, 5: 8, 8: 8, 9: 16}
+
+func decodeEventStreamHeaders(b []byte) (map[string]string, error) {
+	headers := make(map[string]string)
+	for len(b) > 0 {
+		nameLen := int(b[0])
+		if len(b) < 1+nameLen+1 {
+			return nil, fmt.Errorf("truncated event-stream header")
+		}
+		name := string(b[1 : 1+nameLen])
+		valueType := b[1+nameLen]
+		b = b[2+nameLen:]
+
+		switch valueType {
+		case 6, 7:
+			if len(b) < 2 {
+				return nil, fmt.Errorf("truncated event-stream header %s", name)
+			}
+			valueLen := int(binary.BigEndian.Uint16(b))
+			if len(b) < 2+valueLen {
+				return nil, fmt.Errorf("truncated event-stream header %s", name)
+			}
+			if valueType == 7 {
+				headers[name] = string(b[2 : 2+valueLen])
+			}
+			b = b[2+valueLen:]
+		default:
+			size, ok := eventStreamValueSizes[valueType]
+			if !ok {
+				return nil, fmt.Errorf("unknown type %d of event-stream header %s", valueType, name)
+			}
+			if len(b) < size {
+				return nil, fmt.Errorf("truncated event-stream header %s", name)
+			}
+			b = b[size:]
+		}
+	}
+	return headers, nil
+}
@@ -0,0 +1,123 @@
+package anthropic
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"io"
+	"strings"
+	"testing"
+)
+
+// encodeEventStreamMessage frames a message with string headers the way
+// AWS services do.
+func encodeEventStreamMessage(headers map[string]string, payload []byte) []byte {
+	var h bytes.Buffer
+	for name, value := range headers {
+		h.WriteByte(byte(len(name)))
+		h.WriteString(name)
+		h.WriteByte(7)
+		binary.Write(&h, binary.BigEndian, uint16(len(value)))
+		h.WriteString(value)
+	}
+
+	total := 12 + h.Len() + len(payload) + 4
+	var m bytes.Buffer
+	binary.Write(&m, binary.BigEndian, uint32(total))
+	binary.Write(&m, binary.BigEndian, uint32(h.Len()))
+	binary.Write(&m, binary.BigEndian, crc32.ChecksumIEEE(m.Bytes()))
+	m.Write(h.Bytes())
+	m.Write(payload)
+	binary.Write(&m, binary.BigEndian, crc32.ChecksumIEEE(m.Bytes()))
+	return m.Bytes()
+}
+
+func TestEventStreamReader(t *testing.T) {
+	var stream bytes.Buffer
+	stream.Write(encodeEventStreamMessage(map[string]string{":event-type": "chunk", ":message-type": "event"}, []byte(`{"bytes":"e30="}`)))
+	stream.Write(encodeEventStreamMessage(map[string]string{":message-type": "event"}, nil))
+
+	reader := newEventStreamReader(&stream)
+
+	first, err := reader.ReadMessage()
+	if err != nil {
+		t.Fatalf("ReadMessage() error = %v", err)
+	}
+	if first.Headers[":event-type"] != "chunk" || first.Headers[":message-type"] != "event" {
+		t.Errorf("headers = %v", first.Headers)
+	}
+	if string(first.Payload) != `{"bytes":"e30="}` {
+		t.Errorf("payload = %s", first.Payload)
+	}
+
+	second, err := reader.ReadMessage()
+	if err != nil || len(second.Payload) != 0 {
+		t.Errorf("second message = %+v, %v", second, err)
+	}
+
+	if _, err := reader.ReadMessage(); err != io.EOF {
+		t.Errorf("at end of stream error = %v, want io.EOF", err)
+	}
+}
+
+func TestEventStreamReader_NonStringHeaders(t *testing.T) {
+	// A boolean, an int32 and a timestamp header before a string header
+	var h bytes.Buffer
+	h.Write([]byte{5, 'f', 'l', 'a', 'g', 's', 0})
+	h.Write([]byte{3, 'n', 'u', 'm', 4, 0, 0, 0, 42})
+	h.Write([]byte{2, 't', 's', 8, 0, 0, 0, 0, 0, 0, 0, 1})
+	h.Write([]byte{4, 'n', 'a', 'm', 'e', 7, 0, 2, 'o', 'k'})
+
+	var m bytes.Buffer
+	binary.Write(&m, binary.BigEndian, uint32(12+h.Len()+4))
+	binary.Write(&m, binary.BigEndian, uint32(h.Len()))
+	binary.Write(&m, binary.BigEndian, crc32.ChecksumIEEE(m.Bytes()))
+	m.Write(h.Bytes())
+	binary.Write(&m, binary.BigEndian, crc32.ChecksumIEEE(m.Bytes()))
+
+	message, err := newEventStreamReader(&m).ReadMessage()
+	if err != nil {
+		t.Fatalf("ReadMessage() error = %v", err)
+	}
+	if len(message.Headers) != 1 || message.Headers["name"] != "ok" {
+		t.Errorf("headers = %v, want only the string header", message.Headers)
+	}
+}
+
+// craftedMessage frames a message whose prelude declares the given headers
+// length, with valid checksums throughout.
+func craftedMessage(total, headersLen uint32) []byte {
+	var m bytes.Buffer
+	binary.Write(&m, binary.BigEndian, total)
+	binary.Write(&m, binary.BigEndian, headersLen)
+	binary.Write(&m, binary.BigEndian, crc32.ChecksumIEEE(m.Bytes()))
+	m.Write(make([]byte, total-16))
+	binary.Write(&m, binary.BigEndian, crc32.ChecksumIEEE(m.Bytes()))
+	return m.Bytes()
+}
+
+func TestEventStreamReader_Corrupt(t *testing.T) {
+	valid := encodeEventStreamMessage(map[string]string{":message-type": "event"}, []byte("{}"))
+
+	tests := []struct {
+		name    string
+		data    []byte
+		wantErr string
+	}{
+		{"prelude checksum", append([]byte{0, 0, 0, 99}, valid[4:]...), "prelude checksum mismatch"},
+		{"message checksum", append(append([]byte{}, valid[:len(valid)-1]...), valid[len(valid)-1]^0xff), "message checksum mismatch"},
+		{"truncated message", valid[:len(valid)-3], "truncated event-stream message"},
+		{"truncated prelude", valid[:5], "truncated event-stream prelude"},
+		{"headers longer than message", craftedMessage(32, 20), "invalid event-stream message length"},
+		{"headers length overflow", craftedMessage(32, 0xFFFFFFF8), "invalid event-stream message length"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := newEventStreamReader(bytes.NewReader(tt.data)).ReadMessage()
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
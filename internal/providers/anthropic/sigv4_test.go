@@ -0,0 +1,225 @@
+package anthropic
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSignV4(t *testing.T) {
+	// get-vanilla from the AWS Signature Version 4 test suite
+	req, _ := http.NewRequest("GET", "https://example.amazonaws.com/", nil)
+	creds := AWSCredentials{AccessKeyID: "AKIDEXAMPLE", SecretAccessKey: "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY"}
+	signV4(req, nil, creds, "us-east-1", "service", time.Date(2015, 8, 30, 12, 36, 0, 0, time.UTC))
+
+	want := "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/service/aws4_request, SignedHeaders=host;x-amz-date, Signature=5fa00fa31553b73ebf1942676e86291e8372ff2a2260956d9b8aae1d763fbf31"
+	if got := req.Header.Get("Authorization"); got != want {
+		t.Errorf("Authorization =\n%s\nwant\n%s", got, want)
+	}
+	if req.Header.Get("X-Amz-Date") != "20150830T123600Z" {
+		t.Errorf("X-Amz-Date = %s", req.Header.Get("X-Amz-Date"))
+	}
+}
+
+func TestSignV4_SessionToken(t *testing.T) {
+	req, _ := http.NewRequest("POST", "https://bedrock-runtime.us-east-1.amazonaws.com/model/m/invoke", strings.NewReader("{}"))
+	req.Header.Set("Content-Type", "application/json")
+	creds := AWSCredentials{AccessKeyID: "ASIA", SecretAccessKey: "secret", SessionToken: "token"}
+	signV4(req, []byte("{}"), creds, "us-east-1", "bedrock", time.Now())
+
+	if req.Header.Get("X-Amz-Security-Token") != "token" {
+		t.Error("session token header not set")
+	}
+	if auth := req.Header.Get("Authorization"); !strings.Contains(auth, "SignedHeaders=content-type;host;x-amz-date;x-amz-security-token,") {
+		t.Errorf("Authorization = %s", auth)
+	}
+}
+
+func TestCanonicalURI(t *testing.T) {
+	client, _ := NewBedrock(BedrockConfig{Region: "us-east-1", Credentials: AWSCredentials{AccessKeyID: "a", SecretAccessKey: "b"}})
+	req, _ := http.NewRequest("POST", client.bedrockChatURL("us.anthropic.claude-sonnet-4-5-20250929-v1:0"), nil)
+
+	if got := req.URL.EscapedPath(); got != "/model/us.anthropic.claude-sonnet-4-5-20250929-v1%3A0/invoke-with-response-stream" {
+		t.Errorf("request path = %s", got)
+	}
+	if got := canonicalURI(req); got != "/model/us.anthropic.claude-sonnet-4-5-20250929-v1%253A0/invoke-with-response-stream" {
+		t.Errorf("canonical URI = %s", got)
+	}
+}
+
+// clearAWSEnv unsets the credential sources that LoadAWSCredentials
+// consults besides the files, and keeps it off instance metadata.
+func clearAWSEnv(t *testing.T) {
+	t.Helper()
+	for _, name := range []string{
+		"AWS_ACCESS_KEY_ID", "AWS_SECRET_ACCESS_KEY", "AWS_SESSION_TOKEN",
+		"AWS_CONTAINER_CREDENTIALS_RELATIVE_URI", "AWS_CONTAINER_CREDENTIALS_FULL_URI",
+		"AWS_CONTAINER_AUTHORIZATION_TOKEN", "AWS_CONTAINER_AUTHORIZATION_TOKEN_FILE",
+		"AWS_EC2_METADATA_SERVICE_ENDPOINT",
+	} {
+		t.Setenv(name, "")
+	}
+	t.Setenv("AWS_EC2_METADATA_DISABLED", "true")
+}
+
+func TestLoadAWSCredentials(t *testing.T) {
+	dir := t.TempDir()
+	credentialsFile := filepath.Join(dir, "credentials")
+	os.WriteFile(credentialsFile, []byte(`[default]
+aws_access_key_id = AKIADEFAULT
+aws_secret_access_key = default-secret
+
+[work]
+aws_access_key_id = AKIAWORK
+aws_secret_access_key = work-secret
+aws_session_token = work-token
+`), 0600)
+	configFile := filepath.Join(dir, "config")
+	os.WriteFile(configFile, []byte(`[profile sandbox]
+region = eu-west-1
+aws_access_key_id = AKIASANDBOX
+aws_secret_access_key = sandbox-secret
+`), 0600)
+
+	t.Setenv("AWS_SHARED_CREDENTIALS_FILE", credentialsFile)
+	t.Setenv("AWS_CONFIG_FILE", configFile)
+	clearAWSEnv(t)
+
+	tests := []struct {
+		profile string
+		want    AWSCredentials
+	}{
+		{"", AWSCredentials{AccessKeyID: "AKIADEFAULT", SecretAccessKey: "default-secret"}},
+		{"work", AWSCredentials{AccessKeyID: "AKIAWORK", SecretAccessKey: "work-secret", SessionToken: "work-token"}},
+		{"sandbox", AWSCredentials{AccessKeyID: "AKIASANDBOX", SecretAccessKey: "sandbox-secret"}},
+	}
+	for _, tt := range tests {
+		t.Setenv("AWS_PROFILE", tt.profile)
+		got, err := LoadAWSCredentials()
+		if err != nil {
+			t.Fatalf("profile %q: %v", tt.profile, err)
+		}
+		if got != tt.want {
+			t.Errorf("profile %q = %+v, want %+v", tt.profile, got, tt.want)
+		}
+	}
+
+	t.Setenv("AWS_PROFILE", "missing")
+	if _, err := LoadAWSCredentials(); err == nil {
+		t.Error("expected an error for a missing profile")
+	}
+
+	// The environment takes precedence over the files
+	t.Setenv("AWS_ACCESS_KEY_ID", "AKIAENV")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "env-secret")
+	if got, _ := LoadAWSCredentials(); got.AccessKeyID != "AKIAENV" {
+		t.Errorf("got %+v, want credentials from the environment", got)
+	}
+}
+
+func TestLoadAWSCredentials_CredentialProcess(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the test command needs a POSIX shell")
+	}
+
+	dir := t.TempDir()
+	configFile := filepath.Join(dir, "config")
+	os.WriteFile(configFile, []byte(`[profile sso]
+credential_process = printf '{"Version":1,"AccessKeyId":"ASIAPROC","SecretAccessKey":"proc-secret","SessionToken":"proc-token","Expiration":"2026-01-02T16:00:00Z"}'
+
+[profile failing]
+credential_process = echo "token expired, run aws sso login" >&2; exit 1
+`), 0600)
+
+	clearAWSEnv(t)
+	t.Setenv("AWS_SHARED_CREDENTIALS_FILE", filepath.Join(dir, "credentials"))
+	t.Setenv("AWS_CONFIG_FILE", configFile)
+
+	t.Setenv("AWS_PROFILE", "sso")
+	got, err := LoadAWSCredentials()
+	if err != nil {
+		t.Fatalf("LoadAWSCredentials() error = %v", err)
+	}
+	want := AWSCredentials{
+		AccessKeyID:     "ASIAPROC",
+		SecretAccessKey: "proc-secret",
+		SessionToken:    "proc-token",
+		Expires:         time.Date(2026, 1, 2, 16, 0, 0, 0, time.UTC),
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+
+	t.Setenv("AWS_PROFILE", "failing")
+	if _, err := LoadAWSCredentials(); err == nil || !strings.Contains(err.Error(), "run aws sso login") {
+		t.Errorf("error = %v, want the command's stderr", err)
+	}
+}
+
+func TestLoadAWSCredentials_Container(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "pod-token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte(`{"AccessKeyId":"ASIATASK","SecretAccessKey":"task-secret","Token":"task-token","Expiration":"2026-01-02T16:00:00Z"}`))
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	tokenFile := filepath.Join(dir, "token")
+	os.WriteFile(tokenFile, []byte("pod-token\n"), 0600)
+
+	clearAWSEnv(t)
+	t.Setenv("AWS_SHARED_CREDENTIALS_FILE", filepath.Join(dir, "credentials"))
+	t.Setenv("AWS_CONFIG_FILE", filepath.Join(dir, "config"))
+	t.Setenv("AWS_CONTAINER_CREDENTIALS_FULL_URI", server.URL+"/v1/credentials")
+	t.Setenv("AWS_CONTAINER_AUTHORIZATION_TOKEN_FILE", tokenFile)
+
+	got, err := LoadAWSCredentials()
+	if err != nil {
+		t.Fatalf("LoadAWSCredentials() error = %v", err)
+	}
+	if got.AccessKeyID != "ASIATASK" || got.SessionToken != "task-token" || got.Expires.IsZero() {
+		t.Errorf("got %+v", got)
+	}
+}
+
+func TestLoadAWSCredentials_InstanceMetadata(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodPut && r.URL.Path == "/latest/api/token":
+			w.Write([]byte("imds-token"))
+		case r.Header.Get("X-aws-ec2-metadata-token") != "imds-token":
+			w.WriteHeader(http.StatusUnauthorized)
+		case r.URL.Path == "/latest/meta-data/iam/security-credentials/":
+			w.Write([]byte("bedrock-role"))
+		case r.URL.Path == "/latest/meta-data/iam/security-credentials/bedrock-role":
+			w.Write([]byte(`{"Code":"Success","AccessKeyId":"ASIAEC2","SecretAccessKey":"ec2-secret","Token":"ec2-token","Expiration":"2026-01-02T16:00:00Z"}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	clearAWSEnv(t)
+	t.Setenv("AWS_SHARED_CREDENTIALS_FILE", filepath.Join(dir, "credentials"))
+	t.Setenv("AWS_CONFIG_FILE", filepath.Join(dir, "config"))
+	t.Setenv("AWS_EC2_METADATA_DISABLED", "")
+	t.Setenv("AWS_EC2_METADATA_SERVICE_ENDPOINT", server.URL)
+
+	got, err := LoadAWSCredentials()
+	if err != nil {
+		t.Fatalf("LoadAWSCredentials() error = %v", err)
+	}
+	if got.AccessKeyID != "ASIAEC2" || got.SecretAccessKey != "ec2-secret" || got.SessionToken != "ec2-token" {
+		t.Errorf("got %+v", got)
+	}
+}
@@ -0,0 +1,117 @@
+package providers
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// MarshalJSON encodes each content block as an object tagged with its
+// "type" so that messages can be persisted and decoded again.
+func (m Message) MarshalJSON() ([]byte, error) {
+	blocks := make([]json.RawMessage, len(m.Content))
+	for i, block := range m.Content {
+		data, err := MarshalContentBlock(block)
+		if err != nil {
+			return nil, err
+		}
+		blocks[i] = data
+	}
+
+	return json.Marshal(struct {
+		Role    MessageRole       `json:"role"`
+		Content []json.RawMessage `json:"content"`
+	}{
+		Role:    m.Role,
+		Content: blocks,
+	})
+}
+
+func (m *Message) UnmarshalJSON(data []byte) error {
+	var raw struct {
+		Role    MessageRole       `json:"role"`
+		Content []json.RawMessage `json:"content"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	m.Role = raw.Role
+	m.Content = make([]ContentBlock, len(raw.Content))
+	for i, blockData := range raw.Content {
+		block, err := UnmarshalContentBlock(blockData)
+		if err != nil {
+			return err
+		}
+		m.Content[i] = block
+	}
+	return nil
+}
+
+func MarshalContentBlock(block ContentBlock) ([]byte, error) {
+	switch b := block.(type) {
+	case *TextContent:
+		return json.Marshal(struct {
+			Type ContentType `json:"type"`
+			*TextContent
+		}{b.Type(), b})
+	case *ImageContent:
+		return json.Marshal(struct {
+			Type ContentType `json:"type"`
+			*ImageContent
+		}{b.Type(), b})
+	case *ToolUseContent:
+		return json.Marshal(struct {
+			Type ContentType `json:"type"`
+			*ToolUseContent
+		}{b.Type(), b})
+	case *ToolResultContent:
+		return json.Marshal(struct {
+			Type ContentType `json:"type"`
+			*ToolResultContent
+		}{b.Type(), b})
+	case *ReasoningContent:
+		return json.Marshal(struct {
+			Type ContentType `json:"type"`
+			*ReasoningContent
+		}{b.Type(), b})
+	case *ThinkingContent:
+		return json.Marshal(struct {
+			Type ContentType `json:"type"`
+			*ThinkingContent
+		}{b.Type(), b})
+	default:
+		return nil, fmt.Errorf("unsupported content block type: %T", block)
+	}
+}
+
+func UnmarshalContentBlock(data []byte) (ContentBlock, error) {
+	var header struct {
+		Type ContentType `json:"type"`
+	}
+	if err := json.Unmarshal(data, &header); err != nil {
+		return nil, err
+	}
+
+	var block ContentBlock
+	switch header.Type {
+	case ContentTypeText:
+		block = &TextContent{}
+	case ContentTypeImage:
+		block = &ImageContent{}
+	case ContentTypeToolUse:
+		block = &ToolUseContent{}
+	case ContentTypeToolResult:
+		block = &ToolResultContent{}
+	case ContentTypeReasoning:
+		block = &ReasoningContent{}
+	case ContentTypeThinking:
+		block = &ThinkingContent{}
+	default:
+		return nil, fmt.Errorf("unknown content block type: %q", header.Type)
+	}
+
+	if err := json.Unmarshal(data, block); err != nil {
+		return nil, fmt.Errorf("invalid %s block: %w", header.Type, err)
+	}
+	return block, nil
+}
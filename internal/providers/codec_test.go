@@ -0,0 +1,70 @@
+package providers
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestMessage_JSONRoundTrip(t *testing.T) {
+	messages := []Message{
+		{
+			Role: RoleUser,
+			Content: []ContentBlock{
+				&TextContent{Text: "look at this"},
+				&ImageContent{Source: ImageSource{Type: "base64", MediaType: "image/png", Data: "aGVsbG8="}},
+			},
+		},
+		{
+			Role: RoleAssistant,
+			Content: []ContentBlock{
+				&ReasoningContent{ID: "rs_1", Text: "Need the file first.", Encrypted: "gAAAAB"},
+				&ThinkingContent{Thinking: "Check main.go.", Signature: "EqQBCkYIBxgC"},
+				&ThinkingContent{Data: "EmwKAhgBEgy3va3pzix"},
+				&TextContent{Text: "Reading it."},
+				&ToolUseContent{ID: "t1", Name: "file_read", Input: map[string]interface{}{"path": "main.go"}, Signature: "sig-1"},
+			},
+		},
+		{
+			Role: RoleTool,
+			Content: []ContentBlock{
+				&ToolResultContent{ToolUseID: "t1", Content: "package main", IsError: false},
+				&ToolResultContent{ToolUseID: "t2", Content: "Image file shot.png", Images: []*ImageContent{
+					{Source: ImageSource{Type: "base64", MediaType: "image/png", Data: "aGVsbG8="}},
+				}},
+			},
+		},
+	}
+
+	data, err := json.Marshal(messages)
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+
+	var decoded []Message
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+
+	if !reflect.DeepEqual(messages, decoded) {
+		t.Errorf("round trip mismatch:\n got  %#v\n want %#v", decoded, messages)
+	}
+}
+
+func TestMarshalContentBlock_Tagged(t *testing.T) {
+	data, err := MarshalContentBlock(&ToolResultContent{ToolUseID: "t1", Content: "ok"})
+	if err != nil {
+		t.Fatalf("MarshalContentBlock() error = %v", err)
+	}
+
+	want := `{"type":"tool_result","tool_use_id":"t1","content":"ok","is_error":false}`
+	if string(data) != want {
+		t.Errorf("got %s, want %s", data, want)
+	}
+}
+
+func TestUnmarshalContentBlock_Unknown(t *testing.T) {
+	if _, err := UnmarshalContentBlock([]byte(`{"type":"video"}`)); err == nil {
+		t.Error("expected error for unknown block type")
+	}
+}
@@ -0,0 +1,217 @@
+package providers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// FallbackEntry is one model in a fallback chain.
+type FallbackEntry struct {
+	Label    string // provider/model, as reported in events
+	Provider Provider
+	Model    string
+}
+
+// Fallback is a provider that sends each request to the first entry of a
+// chain and moves on to the next one when a model fails before it starts
+// answering. The model that answered is reported in the Model field of
+// EventTypeMessageDone.
+type Fallback struct {
+	entries []FallbackEntry
+
+	// answered records which entries have produced answers, so that the
+	// history keeps its reasoning only for the entry that wrote all of it
+	mu       sync.Mutex
+	answered map[int]bool
+}
+
+// NewFallback creates a chain; the first entry is the primary model.
+func NewFallback(entries ...FallbackEntry) (*Fallback, error) {
+	if len(entries) == 0 {
+		return nil, fmt.Errorf("fallback chain is empty")
+	}
+	return &Fallback{entries: entries, answered: make(map[int]bool)}, nil
+}
+
+func (f *Fallback) Name() string {
+	return f.entries[0].Provider.Name()
+}
+
+// SupportsTools reports whether any model in the chain can use tools.
+// Tools are left out of requests to the ones that can't.
+func (f *Fallback) SupportsTools() bool {
+	for _, entry := range f.entries {
+		if entry.Provider.SupportsTools() {
+			return true
+		}
+	}
+	return false
+}
+
+// SupportsVision reports whether every model in the chain can read images,
+// since any of them may be sent the conversation.
+func (f *Fallback) SupportsVision() bool {
+	for _, entry := range f.entries {
+		if !entry.Provider.SupportsVision() {
+			return false
+		}
+	}
+	return true
+}
+
+// SelectModel selects each entry's own model; the argument names the
+// primary model, which the first entry already holds.
+func (f *Fallback) SelectModel(ctx context.Context, model string) error {
+	var errs []error
+	for _, entry := range f.entries {
+		if selector, ok := entry.Provider.(ModelSelector); ok {
+			if err := selector.SelectModel(ctx, entry.Model); err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", entry.Label, err))
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// ListModels lists the models of every provider in the chain. Only a
+// failure of the primary provider is returned.
+func (f *Fallback) ListModels(ctx context.Context) ([]Model, error) {
+	var models []Model
+	for i, entry := range f.entries {
+		list, err := entry.Provider.ListModels(ctx)
+		if err != nil {
+			if i == 0 {
+				return nil, err
+			}
+			continue
+		}
+		models = append(models, list...)
+	}
+	return models, nil
+}
+
+func (f *Fallback) Chat(ctx context.Context, req *ChatRequest) (<-chan ChatEvent, error) {
+	eventChan := make(chan ChatEvent, 10)
+	go f.chat(ctx, req, eventChan)
+	return eventChan, nil
+}
+
+func (f *Fallback) chat(ctx context.Context, req *ChatRequest, eventChan chan<- ChatEvent) {
+	defer close(eventChan)
+
+	var errs []error
+	for i, entry := range f.entries {
+		err := f.try(ctx, i, req, eventChan)
+		if err == nil {
+			return
+		}
+		errs = append(errs, fmt.Errorf("%s: %w", entry.Label, err))
+
+		if ctx.Err() != nil || i == len(f.entries)-1 {
+			break
+		}
+		eventChan <- ChatEvent{
+			Type:    EventTypeRetry,
+			Content: fmt.Sprintf("%s failed (%s); falling back to %s", entry.Label, failureReason(err), f.entries[i+1].Label),
+		}
+	}
+
+	eventChan <- ChatEvent{
+		Type:  EventTypeError,
+		Error: fmt.Errorf("all models failed: %w", errors.Join(errs...)),
+	}
+}
+
+// try streams the response of one entry. It returns an error, having
+// forwarded nothing but notices, if the model fails before answering.
+// Once the answer has started, later errors are forwarded as they are.
+func (f *Fallback) try(ctx context.Context, i int, req *ChatRequest, eventChan chan<- ChatEvent) error {
+	entry := f.entries[i]
+
+	entryReq := *req
+	entryReq.Model = entry.Model
+	if !f.ownsHistory(i) {
+		entryReq.Messages = portableMessages(req.Messages)
+	}
+	if !entry.Provider.SupportsTools() {
+		entryReq.Tools = nil
+	}
+
+	events, err := entry.Provider.Chat(ctx, &entryReq)
+	if err != nil {
+		return err
+	}
+
+	answering := false
+	for event := range events {
+		switch event.Type {
+		case EventTypeMessageStart, EventTypeRetry:
+		case EventTypeError:
+			if !answering {
+				// Let the stream finish without blocking its producer
+				go func() {
+					for range events {
+					}
+				}()
+				return event.Error
+			}
+		case EventTypeMessageDone:
+			answering = true
+			event.Model = entry.Label
+		default:
+			answering = true
+		}
+		eventChan <- event
+	}
+	if answering {
+		f.mu.Lock()
+		f.answered[i] = true
+		f.mu.Unlock()
+	}
+	return nil
+}
+
+// ownsHistory reports whether entry i wrote every answer so far. Before any
+// answer the history is taken to be the primary model's, e.g. a resumed
+// session.
+func (f *Fallback) ownsHistory(i int) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if len(f.answered) == 0 {
+		return i == 0
+	}
+	return len(f.answered) == 1 && f.answered[i]
+}
+
+func failureReason(err error) string {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return fmt.Sprintf("status %d", apiErr.StatusCode)
+	}
+	return err.Error()
+}
+
+// portableMessages drops reasoning and thinking blocks, which only the
+// model that produced them can read back, so the history can be sent to a
+// different model.
+func portableMessages(msgs []Message) []Message {
+	result := make([]Message, 0, len(msgs))
+	for _, msg := range msgs {
+		content := make([]ContentBlock, 0, len(msg.Content))
+		for _, block := range msg.Content {
+			switch block.(type) {
+			case *ReasoningContent, *ThinkingContent:
+				continue
+			}
+			content = append(content, block)
+		}
+		if len(content) == 0 {
+			continue
+		}
+		result = append(result, Message{Role: msg.Role, Content: content})
+	}
+	return result
+}
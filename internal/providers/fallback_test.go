@@ -0,0 +1,295 @@
+package providers
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// scriptedProvider fails Chat with chatErr or replays events, and records
+// the request it was sent.
+type scriptedProvider struct {
+	mockProvider
+	noTools     bool
+	vision      bool
+	chatErr     error
+	events      []ChatEvent
+	lastRequest *ChatRequest
+}
+
+func (s *scriptedProvider) SupportsTools() bool { return !s.noTools }
+
+func (s *scriptedProvider) SupportsVision() bool { return s.vision }
+
+func (s *scriptedProvider) Chat(ctx context.Context, req *ChatRequest) (<-chan ChatEvent, error) {
+	s.lastRequest = req
+	if s.chatErr != nil {
+		return nil, s.chatErr
+	}
+
+	ch := make(chan ChatEvent, len(s.events))
+	for _, event := range s.events {
+		ch <- event
+	}
+	close(ch)
+	return ch, nil
+}
+
+func answer(text string) []ChatEvent {
+	return []ChatEvent{
+		{Type: EventTypeMessageStart},
+		{Type: EventTypeTextDelta, Content: text},
+		{Type: EventTypeMessageDone, Usage: &Usage{InputTokens: 1, OutputTokens: 1}},
+	}
+}
+
+func TestFallback_ChatError(t *testing.T) {
+	primary := &scriptedProvider{chatErr: &APIError{StatusCode: 401, Body: "invalid x-api-key"}}
+	backup := &scriptedProvider{noTools: true, events: answer("hi from qwen")}
+
+	fallback, err := NewFallback(
+		FallbackEntry{Label: "anthropic/claude-sonnet-4-5", Provider: primary, Model: "claude-sonnet-4-5"},
+		FallbackEntry{Label: "ollama/qwen2.5-coder", Provider: backup, Model: "qwen2.5-coder"},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req := &ChatRequest{
+		Model: "claude-sonnet-4-5",
+		Messages: []Message{
+			{Role: RoleUser, Content: []ContentBlock{&TextContent{Text: "hi"}}},
+			{Role: RoleAssistant, Content: []ContentBlock{
+				&ThinkingContent{Thinking: "greet", Signature: "sig"},
+				&TextContent{Text: "hello"},
+			}},
+			{Role: RoleAssistant, Content: []ContentBlock{&ReasoningContent{Encrypted: "gAAA"}}},
+			{Role: RoleUser, Content: []ContentBlock{&TextContent{Text: "again"}}},
+		},
+		Tools: []Tool{{Name: "bash"}},
+	}
+
+	events, err := fallback.Chat(context.Background(), req)
+	if err != nil {
+		t.Fatalf("Chat() error = %v", err)
+	}
+	got := collect(t, events)
+
+	if got[0].Type != EventTypeRetry || got[0].Content != "anthropic/claude-sonnet-4-5 failed (status 401); falling back to ollama/qwen2.5-coder" {
+		t.Errorf("first event = %+v, want fallback notice", got[0])
+	}
+
+	var text string
+	for _, event := range got {
+		if event.Type == EventTypeTextDelta {
+			text += event.Content
+		}
+	}
+	if text != "hi from qwen" {
+		t.Errorf("text = %q", text)
+	}
+
+	last := got[len(got)-1]
+	if last.Type != EventTypeMessageDone || last.Model != "ollama/qwen2.5-coder" {
+		t.Errorf("last event = %+v, want message_done from ollama/qwen2.5-coder", last)
+	}
+
+	if primary.lastRequest.Model != "claude-sonnet-4-5" || len(primary.lastRequest.Messages) != 4 {
+		t.Errorf("primary request was changed: %+v", primary.lastRequest)
+	}
+
+	sent := backup.lastRequest
+	if sent.Model != "qwen2.5-coder" {
+		t.Errorf("backup model = %s", sent.Model)
+	}
+	if sent.Tools != nil {
+		t.Errorf("tools sent to a model without tool support: %v", sent.Tools)
+	}
+	if len(sent.Messages) != 3 {
+		t.Fatalf("backup got %d messages, want 3 (reasoning-only message dropped)", len(sent.Messages))
+	}
+	if len(sent.Messages[1].Content) != 1 || sent.Messages[1].Content[0].Type() != ContentTypeText {
+		t.Errorf("thinking block not stripped: %#v", sent.Messages[1].Content)
+	}
+	// The caller's history is left alone
+	if len(req.Messages[1].Content) != 2 {
+		t.Error("fallback modified the original messages")
+	}
+}
+
+func TestFallback_StreamErrorBeforeAnswer(t *testing.T) {
+	primary := &scriptedProvider{events: []ChatEvent{
+		{Type: EventTypeMessageStart},
+		{Type: EventTypeRetry, Content: "rate limited; retrying in 1s (1/1)"},
+		{Type: EventTypeError, Error: errors.New("stream error: overloaded_error: Overloaded")},
+	}}
+	backup := &scriptedProvider{events: answer("ok")}
+
+	fallback, _ := NewFallback(
+		FallbackEntry{Label: "anthropic/a", Provider: primary, Model: "a"},
+		FallbackEntry{Label: "openai/b", Provider: backup, Model: "b"},
+	)
+
+	events, _ := fallback.Chat(context.Background(), &ChatRequest{})
+	got := collect(t, events)
+
+	var types []string
+	for _, event := range got {
+		types = append(types, string(event.Type))
+		if event.Type == EventTypeError {
+			t.Errorf("error forwarded: %v", event.Error)
+		}
+	}
+	want := "message_start retry retry message_start text_delta message_done"
+	if strings.Join(types, " ") != want {
+		t.Errorf("events = %v, want %s", types, want)
+	}
+	if got[2].Content != "anthropic/a failed (stream error: overloaded_error: Overloaded); falling back to openai/b" {
+		t.Errorf("notice = %q", got[2].Content)
+	}
+}
+
+func TestFallback_ErrorAfterAnswerStarted(t *testing.T) {
+	primary := &scriptedProvider{events: []ChatEvent{
+		{Type: EventTypeTextDelta, Content: "partial"},
+		{Type: EventTypeError, Error: errors.New("connection reset")},
+	}}
+	backup := &scriptedProvider{events: answer("unused")}
+
+	fallback, _ := NewFallback(
+		FallbackEntry{Label: "anthropic/a", Provider: primary, Model: "a"},
+		FallbackEntry{Label: "openai/b", Provider: backup, Model: "b"},
+	)
+
+	events, _ := fallback.Chat(context.Background(), &ChatRequest{})
+	got := collect(t, events)
+
+	if len(got) != 2 || got[1].Type != EventTypeError || got[1].Error.Error() != "connection reset" {
+		t.Errorf("events = %+v, want the partial answer and its error", got)
+	}
+	if backup.lastRequest != nil {
+		t.Error("fell back after the answer had started")
+	}
+}
+
+func TestFallback_AllFail(t *testing.T) {
+	fallback, _ := NewFallback(
+		FallbackEntry{Label: "anthropic/a", Provider: &scriptedProvider{chatErr: &APIError{StatusCode: 529}}, Model: "a"},
+		FallbackEntry{Label: "openai/b", Provider: &scriptedProvider{chatErr: errors.New("no route to host")}, Model: "b"},
+	)
+
+	events, _ := fallback.Chat(context.Background(), &ChatRequest{})
+	got := collect(t, events)
+
+	last := got[len(got)-1]
+	if last.Type != EventTypeError {
+		t.Fatalf("last event = %+v, want error", last)
+	}
+	msg := last.Error.Error()
+	for _, want := range []string{"all models failed", "anthropic/a: API error (status 529)", "openai/b: no route to host"} {
+		if !strings.Contains(msg, want) {
+			t.Errorf("error %q does not mention %q", msg, want)
+		}
+	}
+}
+
+func TestFallback_Canceled(t *testing.T) {
+	backup := &scriptedProvider{events: answer("unused")}
+	fallback, _ := NewFallback(
+		FallbackEntry{Label: "anthropic/a", Provider: &scriptedProvider{chatErr: context.Canceled}, Model: "a"},
+		FallbackEntry{Label: "openai/b", Provider: backup, Model: "b"},
+	)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	events, _ := fallback.Chat(ctx, &ChatRequest{})
+	got := collect(t, events)
+
+	if len(got) != 1 || got[0].Type != EventTypeError {
+		t.Errorf("events = %+v, want a single error", got)
+	}
+	if backup.lastRequest != nil {
+		t.Error("fell back after cancellation")
+	}
+}
+
+// TestFallback_HistoryAfterFallback checks that once a fallback model has
+// answered, the primary model no longer gets the history with reasoning it
+// can't read, and that the fallback model keeps its own.
+func TestFallback_HistoryAfterFallback(t *testing.T) {
+	primary := &scriptedProvider{chatErr: &APIError{StatusCode: 529, Body: "overloaded"}}
+	backup := &scriptedProvider{events: answer("from gpt")}
+
+	fallback, _ := NewFallback(
+		FallbackEntry{Label: "anthropic/claude-sonnet-4-5", Provider: primary, Model: "claude-sonnet-4-5"},
+		FallbackEntry{Label: "openai/o4-mini", Provider: backup, Model: "o4-mini"},
+	)
+
+	history := []Message{
+		{Role: RoleUser, Content: []ContentBlock{&TextContent{Text: "hi"}}},
+		{Role: RoleAssistant, Content: []ContentBlock{
+			&ReasoningContent{Encrypted: "gAAA"},
+			&TextContent{Text: "from gpt"},
+		}},
+		{Role: RoleUser, Content: []ContentBlock{&TextContent{Text: "again"}}},
+	}
+
+	events, _ := fallback.Chat(context.Background(), &ChatRequest{Messages: history[:1]})
+	collect(t, events)
+
+	// Only the backup has answered: it may read its reasoning back
+	events, _ = fallback.Chat(context.Background(), &ChatRequest{Messages: history})
+	collect(t, events)
+	if len(backup.lastRequest.Messages[1].Content) != 2 {
+		t.Errorf("backup's own reasoning was stripped: %#v", backup.lastRequest.Messages[1].Content)
+	}
+
+	// The primary is back and gets the history without the reasoning
+	primary.chatErr = nil
+	primary.events = answer("from claude")
+	events, _ = fallback.Chat(context.Background(), &ChatRequest{Messages: history})
+	collect(t, events)
+	if content := primary.lastRequest.Messages[1].Content; len(content) != 1 || content[0].Type() != ContentTypeText {
+		t.Errorf("primary got reasoning from another model: %#v", content)
+	}
+
+	// Both have answered now, so neither gets raw history
+	primary.chatErr = &APIError{StatusCode: 529, Body: "overloaded"}
+	events, _ = fallback.Chat(context.Background(), &ChatRequest{Messages: history})
+	collect(t, events)
+	if len(backup.lastRequest.Messages[1].Content) != 1 {
+		t.Errorf("backup got a mixed history unchanged: %#v", backup.lastRequest.Messages[1].Content)
+	}
+}
+
+func TestFallback_SupportsVision(t *testing.T) {
+	tests := []struct {
+		name          string
+		primary, next bool
+		want          bool
+	}{
+		{"both", true, true, true},
+		{"primary only", true, false, false},
+		{"fallback only", false, true, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fallback, _ := NewFallback(
+				FallbackEntry{Label: "a/1", Provider: &scriptedProvider{vision: tt.primary}},
+				FallbackEntry{Label: "b/2", Provider: &scriptedProvider{vision: tt.next}},
+			)
+			if got := fallback.SupportsVision(); got != tt.want {
+				t.Errorf("SupportsVision() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewFallback_Empty(t *testing.T) {
+	if _, err := NewFallback(); err == nil {
+		t.Error("expected an error for an empty chain")
+	}
+}
@@ -0,0 +1,627 @@
+package gemini
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/taaha3244/potus/internal/providers"
+)
+
+const (
+	defaultBaseURL = "https://generativelanguage.googleapis.com/v1beta"
+
+	// defaultContextSize applies to models missing from knownModels
+	defaultContextSize = 1048576
+)
+
+type Client struct {
+	apiKey     string
+	baseURL    string
+	modelCache *providers.ModelCache
+	client     *http.Client
+}
+
+func New(apiKey string) (*Client, error) {
+	if apiKey == "" {
+		return nil, fmt.Errorf("API key is required")
+	}
+
+	return &Client{
+		apiKey:  apiKey,
+		baseURL: defaultBaseURL,
+		client:  &http.Client{},
+	}, nil
+}
+
+func (c *Client) Name() string {
+	return "gemini"
+}
+
+func (c *Client) SupportsTools() bool {
+	return true
+}
+
+func (c *Client) SupportsVision() bool {
+	return true
+}
+
+// knownModels holds the pricing of models, which the models endpoint
+// doesn't report. It is also the list used offline.
+var knownModels = []providers.Model{
+	{
+		ID:          "gemini-2.5-pro",
+		Name:        "Gemini 2.5 Pro",
+		Provider:    "gemini",
+		ContextSize: 1048576,
+		Pricing: providers.ModelPricing{
+			InputPer1M:  1.25,
+			OutputPer1M: 10.00,
+			CachedPer1M: 0.125,
+		},
+		SupportsTools:  true,
+		SupportsVision: true,
+	},
+	{
+		ID:          "gemini-2.5-flash",
+		Name:        "Gemini 2.5 Flash",
+		Provider:    "gemini",
+		ContextSize: 1048576,
+		Pricing: providers.ModelPricing{
+			InputPer1M:  0.30,
+			OutputPer1M: 2.50,
+			CachedPer1M: 0.03,
+		},
+		SupportsTools:  true,
+		SupportsVision: true,
+	},
+	{
+		ID:          "gemini-2.5-flash-lite",
+		Name:        "Gemini 2.5 Flash-Lite",
+		Provider:    "gemini",
+		ContextSize: 1048576,
+		Pricing: providers.ModelPricing{
+			InputPer1M:  0.10,
+			OutputPer1M: 0.40,
+			CachedPer1M: 0.01,
+		},
+		SupportsTools:  true,
+		SupportsVision: true,
+	},
+}
+
+func (c *Client) ListModels(ctx context.Context) ([]providers.Model, error) {
+	if c.modelCache == nil {
+		return slices.Clone(knownModels), nil
+	}
+	return providers.DiscoverModels(ctx, c.modelCache, "gemini", c.fetchModels, slices.Clone(knownModels)), nil
+}
+
+// SetModelCache makes ListModels query the models endpoint, keeping the
+// result in cache. Without a cache only the known models are listed.
+func (c *Client) SetModelCache(cache *providers.ModelCache) {
+	c.modelCache = cache
+}
+
+func (c *Client) fetchModels(ctx context.Context) ([]providers.Model, error) {
+	httpReq, err := http.NewRequestWithContext(ctx, "GET", c.baseURL+"/models?pageSize=1000", nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	httpReq.Header.Set("X-Goog-Api-Key", c.apiKey)
+
+	resp, err := c.client.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch models: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, providers.NewAPIError(resp)
+	}
+	defer resp.Body.Close()
+
+	var result struct {
+		Models []struct {
+			Name                       string   `json:"name"`
+			DisplayName                string   `json:"displayName"`
+			InputTokenLimit            int      `json:"inputTokenLimit"`
+			SupportedGenerationMethods []string `json:"supportedGenerationMethods"`
+		} `json:"models"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("failed to decode models: %w", err)
+	}
+
+	// The list also has embedding and image models, which can't chat
+	var models []providers.Model
+	for _, m := range result.Models {
+		if !slices.Contains(m.SupportedGenerationMethods, "generateContent") {
+			continue
+		}
+		contextSize := m.InputTokenLimit
+		if contextSize == 0 {
+			contextSize = defaultContextSize
+		}
+		models = append(models, providers.Model{
+			ID:             strings.TrimPrefix(m.Name, "models/"),
+			Name:           m.DisplayName,
+			Provider:       "gemini",
+			ContextSize:    contextSize,
+			SupportsTools:  true,
+			SupportsVision: true,
+		})
+	}
+	return providers.MergeModelMetadata(models, knownModels), nil
+}
+
+func (c *Client) Chat(ctx context.Context, req *providers.ChatRequest) (<-chan providers.ChatEvent, error) {
+	apiReq := c.buildRequest(req)
+
+	body, err := json.Marshal(apiReq)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	url := c.baseURL + "/models/" + req.Model + ":streamGenerateContent?alt=sse"
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("X-Goog-Api-Key", c.apiKey)
+
+	resp, err := c.client.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send request: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, providers.NewAPIError(resp)
+	}
+
+	eventChan := make(chan providers.ChatEvent, 10)
+
+	go c.streamResponse(resp.Body, eventChan)
+
+	return eventChan, nil
+}
+
+func (c *Client) buildRequest(req *providers.ChatRequest) map[string]interface{} {
+	apiReq := map[string]interface{}{}
+
+	generationConfig := map[string]interface{}{}
+	if req.MaxTokens > 0 {
+		generationConfig["maxOutputTokens"] = req.MaxTokens
+	}
+	if req.Temperature > 0 {
+		generationConfig["temperature"] = req.Temperature
+	}
+	if req.ThinkingBudget > 0 {
+		generationConfig["thinkingConfig"] = map[string]interface{}{
+			"thinkingBudget":  req.ThinkingBudget,
+			"includeThoughts": true,
+		}
+	}
+	// Gemini rejects a JSON response type alongside function declarations,
+	// so with tools the answer is only validated afterwards
+	if req.ResponseFormat != nil && len(req.Tools) == 0 {
+		generationConfig["responseMimeType"] = "application/json"
+		generationConfig["responseSchema"] = convertSchema(req.ResponseFormat.Schema)
+	}
+	if len(generationConfig) > 0 {
+		apiReq["generationConfig"] = generationConfig
+	}
+
+	if req.System != "" {
+		apiReq["systemInstruction"] = map[string]interface{}{
+			"parts": []map[string]interface{}{{"text": req.System}},
+		}
+	}
+
+	// Function responses are matched to calls by name, so remember which
+	// call each result answers
+	toolNames := make(map[string]string)
+	for _, msg := range req.Messages {
+		for _, block := range msg.Content {
+			if toolUse, ok := block.(*providers.ToolUseContent); ok {
+				toolNames[toolUse.ID] = toolUse.Name
+			}
+		}
+	}
+
+	contents := make([]map[string]interface{}, 0, len(req.Messages))
+	for _, msg := range req.Messages {
+		if msg.Role == providers.RoleSystem {
+			continue
+		}
+
+		role := "user"
+		if msg.Role == providers.RoleAssistant {
+			role = "model"
+		}
+
+		parts := c.convertContent(msg.Content, toolNames)
+		if len(parts) == 0 {
+			continue
+		}
+
+		// Turns have to alternate, so tool results and a following user
+		// message are sent as one turn
+		if n := len(contents); n > 0 && contents[n-1]["role"] == role {
+			previous := contents[n-1]["parts"].([]map[string]interface{})
+			contents[n-1]["parts"] = append(previous, parts...)
+			continue
+		}
+
+		contents = append(contents, map[string]interface{}{
+			"role":  role,
+			"parts": parts,
+		})
+	}
+	apiReq["contents"] = contents
+
+	if len(req.Tools) > 0 {
+		declarations := make([]map[string]interface{}, 0, len(req.Tools))
+		for _, tool := range req.Tools {
+			declaration := map[string]interface{}{
+				"name":        tool.Name,
+				"description": tool.Description,
+			}
+			// Functions without arguments must leave parameters out
+			if properties, _ := tool.InputSchema["properties"].(map[string]interface{}); len(properties) > 0 {
+				declaration["parameters"] = convertSchema(tool.InputSchema)
+			}
+			declarations = append(declarations, declaration)
+		}
+		apiReq["tools"] = []map[string]interface{}{
+			{"functionDeclarations": declarations},
+		}
+
+		if config := functionCallingConfig(req.ToolChoice); config != nil {
+			apiReq["toolConfig"] = map[string]interface{}{"functionCallingConfig": config}
+		}
+	}
+
+	return apiReq
+}
+
+// functionCallingConfig translates a tool choice; mode ANY with a single
+// allowed function forces that function.
+func functionCallingConfig(choice providers.ToolChoice) map[string]interface{} {
+	switch choice {
+	case "", providers.ToolChoiceAuto:
+		return nil
+	case providers.ToolChoiceNone:
+		return map[string]interface{}{"mode": "NONE"}
+	case providers.ToolChoiceRequired:
+		return map[string]interface{}{"mode": "ANY"}
+	}
+	return map[string]interface{}{
+		"mode":                 "ANY",
+		"allowedFunctionNames": []string{string(choice)},
+	}
+}
+
+func (c *Client) convertContent(blocks []providers.ContentBlock, toolNames map[string]string) []map[string]interface{} {
+	parts := make([]map[string]interface{}, 0, len(blocks))
+
+	// A function response carries no images, so those of tool results
+	// follow the responses as parts of their own
+	var toolImages []providers.ContentBlock
+
+	for _, block := range blocks {
+		switch b := block.(type) {
+		case *providers.TextContent:
+			parts = append(parts, map[string]interface{}{
+				"text": b.Text,
+			})
+		case *providers.ImageContent:
+			parts = append(parts, map[string]interface{}{
+				"inlineData": map[string]interface{}{
+					"mimeType": b.Source.MediaType,
+					"data":     b.Source.Data,
+				},
+			})
+		case *providers.ToolUseContent:
+			args := b.Input
+			if args == nil {
+				args = map[string]interface{}{}
+			}
+			part := map[string]interface{}{
+				"functionCall": map[string]interface{}{
+					"name": b.Name,
+					"args": args,
+				},
+			}
+			// Thinking models reject a call without the signature they
+			// gave it, and lose their reasoning between turns
+			if b.Signature != "" {
+				part["thoughtSignature"] = b.Signature
+			}
+			parts = append(parts, part)
+		case *providers.ToolResultContent:
+			// The response has to be a JSON object
+			key := "output"
+			if b.IsError {
+				key = "error"
+			}
+			parts = append(parts, map[string]interface{}{
+				"functionResponse": map[string]interface{}{
+					"name":     toolNames[b.ToolUseID],
+					"response": map[string]interface{}{key: b.Content},
+				},
+			})
+			for _, img := range b.Images {
+				toolImages = append(toolImages, img)
+			}
+		}
+	}
+
+	if len(toolImages) > 0 {
+		parts = append(parts, c.convertContent(toolImages, toolNames)...)
+	}
+
+	return parts
+}
+
+// schemaKeys are the JSON Schema keywords that function declarations
+// accept; their parameters are an OpenAPI subset that rejects the rest,
+// e.g. additionalProperties or $schema.
+var schemaKeys = map[string]bool{
+	"type":        true,
+	"format":      true,
+	"title":       true,
+	"description": true,
+	"nullable":    true,
+	"enum":        true,
+	"default":     true,
+	"required":    true,
+	"minItems":    true,
+	"maxItems":    true,
+	"minLength":   true,
+	"maxLength":   true,
+	"minimum":     true,
+	"maximum":     true,
+	"pattern":     true,
+}
+
+// convertSchema converts a tool's JSON schema to Gemini's schema format.
+func convertSchema(schema map[string]interface{}) map[string]interface{} {
+	result := make(map[string]interface{}, len(schema))
+
+	for key, value := range schema {
+		switch key {
+		case "properties":
+			properties, _ := value.(map[string]interface{})
+			converted := make(map[string]interface{}, len(properties))
+			for name, property := range properties {
+				if p, ok := property.(map[string]interface{}); ok {
+					converted[name] = convertSchema(p)
+				}
+			}
+			result[key] = converted
+		case "items":
+			if items, ok := value.(map[string]interface{}); ok {
+				result[key] = convertSchema(items)
+			}
+		case "anyOf":
+			variants, _ := value.([]interface{})
+			converted := make([]interface{}, 0, len(variants))
+			for _, variant := range variants {
+				if v, ok := variant.(map[string]interface{}); ok {
+					converted = append(converted, convertSchema(v))
+				}
+			}
+			result[key] = converted
+		case "type":
+			// A type list such as ["string", "null"] becomes a nullable type
+			types, ok := value.([]interface{})
+			if !ok {
+				result[key] = value
+				continue
+			}
+			for _, t := range types {
+				if t == "null" {
+					result["nullable"] = true
+				} else if _, set := result[key]; !set {
+					result[key] = t
+				}
+			}
+		default:
+			if schemaKeys[key] {
+				result[key] = value
+			}
+		}
+	}
+
+	return result
+}
+
+// maxLineSize bounds a single SSE line; each chunk arrives on one line.
+const maxLineSize = 1024 * 1024
+
+// streamState collects what a response reports across chunks.
+type streamState struct {
+	started      bool
+	usage        providers.Usage
+	finishReason string
+}
+
+// streamChunk is one GenerateContentResponse.
+type streamChunk struct {
+	Candidates []struct {
+		Content struct {
+			Parts []struct {
+				Text             string `json:"text"`
+				Thought          bool   `json:"thought"`
+				ThoughtSignature string `json:"thoughtSignature"`
+				FunctionCall     *struct {
+					ID   string                 `json:"id"`
+					Name string                 `json:"name"`
+					Args map[string]interface{} `json:"args"`
+				} `json:"functionCall"`
+			} `json:"parts"`
+		} `json:"content"`
+		FinishReason string `json:"finishReason"`
+	} `json:"candidates"`
+	PromptFeedback *struct {
+		BlockReason string `json:"blockReason"`
+	} `json:"promptFeedback"`
+	UsageMetadata *struct {
+		PromptTokenCount        int `json:"promptTokenCount"`
+		CandidatesTokenCount    int `json:"candidatesTokenCount"`
+		ThoughtsTokenCount      int `json:"thoughtsTokenCount"`
+		CachedContentTokenCount int `json:"cachedContentTokenCount"`
+		TotalTokenCount         int `json:"totalTokenCount"`
+	} `json:"usageMetadata"`
+	Error *struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+		Status  string `json:"status"`
+	} `json:"error"`
+}
+
+func (c *Client) streamResponse(body io.ReadCloser, eventChan chan<- providers.ChatEvent) {
+	defer close(eventChan)
+	defer body.Close()
+
+	state := &streamState{}
+
+	scanner := bufio.NewScanner(body)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxLineSize)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if !strings.HasPrefix(line, "data: ") {
+			continue
+		}
+
+		var chunk streamChunk
+		if err := json.Unmarshal([]byte(strings.TrimPrefix(line, "data: ")), &chunk); err != nil {
+			eventChan <- providers.ChatEvent{
+				Type:  providers.EventTypeError,
+				Error: fmt.Errorf("failed to parse chunk: %w", err),
+			}
+			return
+		}
+
+		if err := c.handleChunk(state, &chunk, eventChan); err != nil {
+			eventChan <- providers.ChatEvent{
+				Type:  providers.EventTypeError,
+				Error: err,
+			}
+			return
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		eventChan <- providers.ChatEvent{
+			Type:  providers.EventTypeError,
+			Error: fmt.Errorf("scanner error: %w", err),
+		}
+		return
+	}
+
+	if err := finishError(state.finishReason); err != nil {
+		eventChan <- providers.ChatEvent{
+			Type:  providers.EventTypeError,
+			Error: err,
+		}
+		return
+	}
+
+	usage := state.usage
+	eventChan <- providers.ChatEvent{
+		Type:  providers.EventTypeMessageDone,
+		Usage: &usage,
+	}
+}
+
+func (c *Client) handleChunk(state *streamState, chunk *streamChunk, eventChan chan<- providers.ChatEvent) error {
+	if chunk.Error != nil {
+		message := strings.TrimPrefix(chunk.Error.Status+": "+chunk.Error.Message, ": ")
+		return fmt.Errorf("stream error: %s", message)
+	}
+	if chunk.PromptFeedback != nil && chunk.PromptFeedback.BlockReason != "" {
+		return fmt.Errorf("prompt blocked: %s", chunk.PromptFeedback.BlockReason)
+	}
+
+	if !state.started {
+		state.started = true
+		eventChan <- providers.ChatEvent{Type: providers.EventTypeMessageStart}
+	}
+
+	// Usage counts are cumulative, so the last chunk's are kept. Cached
+	// tokens are part of the prompt count and thoughts are billed as output.
+	if u := chunk.UsageMetadata; u != nil {
+		state.usage = providers.Usage{
+			InputTokens:          u.PromptTokenCount - u.CachedContentTokenCount,
+			OutputTokens:         u.CandidatesTokenCount + u.ThoughtsTokenCount,
+			TotalTokens:          u.TotalTokenCount,
+			CacheReadInputTokens: u.CachedContentTokenCount,
+		}
+	}
+
+	if len(chunk.Candidates) == 0 {
+		return nil
+	}
+	candidate := chunk.Candidates[0]
+	if candidate.FinishReason != "" {
+		state.finishReason = candidate.FinishReason
+	}
+
+	for _, part := range candidate.Content.Parts {
+		switch {
+		case part.FunctionCall != nil:
+			args := part.FunctionCall.Args
+			if args == nil {
+				args = map[string]interface{}{}
+			}
+
+			// Only some models assign call IDs
+			id := part.FunctionCall.ID
+			if id == "" {
+				id = providers.NewToolCallID()
+			}
+
+			eventChan <- providers.ChatEvent{
+				Type: providers.EventTypeToolUse,
+				ToolUse: &providers.ToolUseContent{
+					ID:        id,
+					Name:      part.FunctionCall.Name,
+					Input:     args,
+					Signature: part.ThoughtSignature,
+				},
+			}
+		case part.Thought:
+			if part.Text != "" {
+				eventChan <- providers.ChatEvent{
+					Type:    providers.EventTypeThinkingDelta,
+					Content: part.Text,
+				}
+			}
+		case part.Text != "":
+			eventChan <- providers.ChatEvent{
+				Type:    providers.EventTypeTextDelta,
+				Content: part.Text,
+			}
+		}
+	}
+
+	return nil
+}
+
+// finishError turns a finish reason other than a normal stop into an
+// error, e.g. a response withheld by safety filters.
+func finishError(reason string) error {
+	switch reason {
+	case "", "STOP", "MAX_TOKENS", "FINISH_REASON_UNSPECIFIED":
+		return nil
+	}
+	return fmt.Errorf("response stopped: %s", reason)
+}
@@ -0,0 +1,571 @@
+package gemini
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/taaha3244/potus/internal/providers"
+)
+
+func TestNew(t *testing.T) {
+	t.Run("missing API key", func(t *testing.T) {
+		_, err := New("")
+		if err == nil {
+			t.Error("Expected error for missing API key")
+		}
+	})
+
+	t.Run("with API key", func(t *testing.T) {
+		client, err := New("test-key-123")
+		if err != nil {
+			t.Fatalf("New() error = %v", err)
+		}
+
+		if client.apiKey != "test-key-123" {
+			t.Errorf("apiKey = %s, want test-key-123", client.apiKey)
+		}
+		if client.baseURL != defaultBaseURL {
+			t.Errorf("baseURL = %s, want %s", client.baseURL, defaultBaseURL)
+		}
+	})
+}
+
+func TestClient_Name(t *testing.T) {
+	client := &Client{}
+	if client.Name() != "gemini" {
+		t.Errorf("Name() = %s, want gemini", client.Name())
+	}
+}
+
+func TestClient_ListModels(t *testing.T) {
+	client := &Client{}
+	models, err := client.ListModels(context.Background())
+	if err != nil {
+		t.Fatalf("ListModels() error = %v", err)
+	}
+
+	if len(models) != len(knownModels) {
+		t.Fatalf("got %d models, want the %d known models", len(models), len(knownModels))
+	}
+	for _, model := range models {
+		if model.Provider != "gemini" || model.ContextSize <= 0 || model.Pricing.InputPer1M <= 0 {
+			t.Errorf("incomplete model %+v", model)
+		}
+	}
+}
+
+func TestClient_ListModels_Discovery(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1beta/models" || r.Header.Get("X-Goog-Api-Key") != "test-key" {
+			t.Errorf("unexpected request %s %s", r.URL.Path, r.Header.Get("X-Goog-Api-Key"))
+		}
+		w.Write([]byte(`{"models":[
+			{"name":"models/gemini-2.5-pro","displayName":"Gemini 2.5 Pro","inputTokenLimit":1048576,"supportedGenerationMethods":["generateContent","countTokens"]},
+			{"name":"models/gemini-3-pro-preview","displayName":"Gemini 3 Pro Preview","inputTokenLimit":1048576,"supportedGenerationMethods":["generateContent"]},
+			{"name":"models/text-embedding-004","displayName":"Text Embedding 004","inputTokenLimit":2048,"supportedGenerationMethods":["embedContent"]}
+		]}`))
+	}))
+	defer server.Close()
+
+	client, _ := New("test-key")
+	client.baseURL = server.URL + "/v1beta"
+	cacheDir := t.TempDir()
+	client.SetModelCache(providers.NewModelCache(cacheDir, time.Hour))
+
+	models, err := client.ListModels(context.Background())
+	if err != nil {
+		t.Fatalf("ListModels() error = %v", err)
+	}
+	if len(models) != 2 {
+		t.Fatalf("got %d models, want 2 (embedding model left out)", len(models))
+	}
+
+	if models[0].ID != "gemini-2.5-pro" || models[0].Pricing.InputPer1M != 1.25 {
+		t.Errorf("known model = %+v", models[0])
+	}
+	if models[1].Name != "Gemini 3 Pro Preview" || models[1].ContextSize != 1048576 || !models[1].SupportsTools {
+		t.Errorf("new model = %+v", models[1])
+	}
+
+	if _, err := os.Stat(filepath.Join(cacheDir, "gemini.json")); err != nil {
+		t.Errorf("model list not cached: %v", err)
+	}
+}
+
+func TestClient_Chat(t *testing.T) {
+	t.Run("successful streaming response", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path != "/v1beta/models/gemini-2.5-flash:streamGenerateContent" || r.URL.Query().Get("alt") != "sse" {
+				t.Errorf("URL = %s", r.URL)
+			}
+			if r.Header.Get("X-Goog-Api-Key") != "test-key" {
+				t.Error("Missing or incorrect X-Goog-Api-Key header")
+			}
+
+			var req map[string]interface{}
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				t.Fatalf("Failed to decode request: %v", err)
+			}
+			if _, ok := req["contents"]; !ok {
+				t.Error("request has no contents")
+			}
+
+			w.Header().Set("Content-Type", "text/event-stream")
+			f, _ := os.ReadFile(filepath.Join("testdata", "thinking.sse"))
+			w.Write(f)
+		}))
+		defer server.Close()
+
+		client, _ := New("test-key")
+		client.baseURL = server.URL + "/v1beta"
+
+		events, err := client.Chat(context.Background(), &providers.ChatRequest{
+			Model:     "gemini-2.5-flash",
+			MaxTokens: 1024,
+			Messages: []providers.Message{
+				{Role: providers.RoleUser, Content: []providers.ContentBlock{&providers.TextContent{Text: "What is the module path?"}}},
+			},
+		})
+		if err != nil {
+			t.Fatalf("Chat() error = %v", err)
+		}
+
+		var text string
+		var gotMessageStart, gotMessageDone bool
+		for event := range events {
+			switch event.Type {
+			case providers.EventTypeMessageStart:
+				gotMessageStart = true
+			case providers.EventTypeTextDelta:
+				text += event.Content
+			case providers.EventTypeMessageDone:
+				gotMessageDone = true
+			case providers.EventTypeError:
+				t.Errorf("Unexpected error: %v", event.Error)
+			}
+		}
+
+		if !gotMessageStart || !gotMessageDone {
+			t.Errorf("message_start %v, message_done %v, want both", gotMessageStart, gotMessageDone)
+		}
+		if text != "The module is github.com/taaha3244/potus." {
+			t.Errorf("text = %q", text)
+		}
+	})
+
+	t.Run("API error response", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusTooManyRequests)
+			w.Write([]byte(`{"error":{"code":429,"message":"Resource has been exhausted","status":"RESOURCE_EXHAUSTED"}}`))
+		}))
+		defer server.Close()
+
+		client, _ := New("test-key")
+		client.baseURL = server.URL
+
+		_, err := client.Chat(context.Background(), &providers.ChatRequest{Model: "gemini-2.5-pro"})
+		if !providers.IsRetryable(err) {
+			t.Errorf("Chat() error = %v, want a retryable API error", err)
+		}
+	})
+}
+
+func TestClient_BuildRequest(t *testing.T) {
+	client := &Client{}
+
+	req := &providers.ChatRequest{
+		Model:          "gemini-2.5-pro",
+		System:         "You are a coding assistant.",
+		MaxTokens:      2048,
+		Temperature:    0.7,
+		ThinkingBudget: 4096,
+		Messages: []providers.Message{
+			{Role: providers.RoleUser, Content: []providers.ContentBlock{
+				&providers.TextContent{Text: "What's in this screenshot?"},
+				&providers.ImageContent{Source: providers.ImageSource{Type: "base64", MediaType: "image/png", Data: "iVBORw0KGgo="}},
+			}},
+			{Role: providers.RoleAssistant, Content: []providers.ContentBlock{
+				&providers.TextContent{Text: "Let me check the file."},
+				&providers.ToolUseContent{ID: "call_1", Name: "file_read", Input: map[string]interface{}{"path": "main.go"}},
+			}},
+			{Role: providers.RoleTool, Content: []providers.ContentBlock{
+				&providers.ToolResultContent{ToolUseID: "call_1", Content: "file not found", IsError: true},
+			}},
+			{Role: providers.RoleUser, Content: []providers.ContentBlock{&providers.TextContent{Text: "Try cmd/main.go"}}},
+		},
+		Tools: []providers.Tool{
+			{
+				Name:        "file_read",
+				Description: "Read a file",
+				InputSchema: map[string]interface{}{
+					"$schema":              "http://json-schema.org/draft-07/schema#",
+					"type":                 "object",
+					"additionalProperties": false,
+					"properties": map[string]interface{}{
+						"path":  map[string]interface{}{"type": "string", "description": "File path"},
+						"limit": map[string]interface{}{"type": []interface{}{"integer", "null"}},
+					},
+					"required": []interface{}{"path"},
+				},
+			},
+			{
+				Name:        "git_status",
+				Description: "Show the working tree status",
+				InputSchema: map[string]interface{}{"type": "object", "properties": map[string]interface{}{}},
+			},
+		},
+	}
+
+	data, err := json.Marshal(client.buildRequest(req))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got struct {
+		SystemInstruction struct {
+			Parts []map[string]interface{} `json:"parts"`
+		} `json:"systemInstruction"`
+		Contents []struct {
+			Role  string                   `json:"role"`
+			Parts []map[string]interface{} `json:"parts"`
+		} `json:"contents"`
+		GenerationConfig map[string]interface{} `json:"generationConfig"`
+		Tools            []struct {
+			FunctionDeclarations []map[string]interface{} `json:"functionDeclarations"`
+		} `json:"tools"`
+	}
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatal(err)
+	}
+
+	if got.SystemInstruction.Parts[0]["text"] != "You are a coding assistant." {
+		t.Errorf("systemInstruction = %v", got.SystemInstruction)
+	}
+
+	if got.GenerationConfig["maxOutputTokens"] != float64(2048) || got.GenerationConfig["temperature"] != 0.7 {
+		t.Errorf("generationConfig = %v", got.GenerationConfig)
+	}
+	if thinking, _ := got.GenerationConfig["thinkingConfig"].(map[string]interface{}); thinking["thinkingBudget"] != float64(4096) {
+		t.Errorf("thinkingConfig = %v", got.GenerationConfig["thinkingConfig"])
+	}
+
+	// The tool result and the next user message share one turn
+	if len(got.Contents) != 3 {
+		t.Fatalf("got %d contents, want 3", len(got.Contents))
+	}
+	if got.Contents[0].Role != "user" || got.Contents[1].Role != "model" || got.Contents[2].Role != "user" {
+		t.Errorf("roles = %s %s %s", got.Contents[0].Role, got.Contents[1].Role, got.Contents[2].Role)
+	}
+
+	image, _ := got.Contents[0].Parts[1]["inlineData"].(map[string]interface{})
+	if image["mimeType"] != "image/png" || image["data"] != "iVBORw0KGgo=" {
+		t.Errorf("image part = %v", got.Contents[0].Parts[1])
+	}
+
+	call, _ := got.Contents[1].Parts[1]["functionCall"].(map[string]interface{})
+	if call["name"] != "file_read" || call["args"].(map[string]interface{})["path"] != "main.go" {
+		t.Errorf("function call part = %v", got.Contents[1].Parts[1])
+	}
+
+	response, _ := got.Contents[2].Parts[0]["functionResponse"].(map[string]interface{})
+	if response["name"] != "file_read" || response["response"].(map[string]interface{})["error"] != "file not found" {
+		t.Errorf("function response part = %v", got.Contents[2].Parts[0])
+	}
+	if got.Contents[2].Parts[1]["text"] != "Try cmd/main.go" {
+		t.Errorf("user text part = %v", got.Contents[2].Parts[1])
+	}
+
+	declarations := got.Tools[0].FunctionDeclarations
+	if len(declarations) != 2 {
+		t.Fatalf("got %d function declarations, want 2", len(declarations))
+	}
+	params := declarations[0]["parameters"].(map[string]interface{})
+	if _, ok := params["additionalProperties"]; ok {
+		t.Error("additionalProperties not removed")
+	}
+	if _, ok := params["$schema"]; ok {
+		t.Error("$schema not removed")
+	}
+	limit := params["properties"].(map[string]interface{})["limit"].(map[string]interface{})
+	if limit["type"] != "integer" || limit["nullable"] != true {
+		t.Errorf("limit schema = %v, want nullable integer", limit)
+	}
+	if _, ok := declarations[1]["parameters"]; ok {
+		t.Errorf("parameters sent for a function without arguments: %v", declarations[1])
+	}
+}
+
+func TestClient_BuildRequest_ToolChoice(t *testing.T) {
+	client := &Client{}
+
+	tests := []struct {
+		choice providers.ToolChoice
+		want   map[string]interface{}
+	}{
+		{"", nil},
+		{providers.ToolChoiceNone, map[string]interface{}{"mode": "NONE"}},
+		{providers.ToolChoiceRequired, map[string]interface{}{"mode": "ANY"}},
+		{"file_read", map[string]interface{}{"mode": "ANY", "allowedFunctionNames": []string{"file_read"}}},
+	}
+
+	for _, tt := range tests {
+		req := &providers.ChatRequest{
+			Model:      "gemini-2.5-flash",
+			Messages:   []providers.Message{{Role: providers.RoleUser, Content: []providers.ContentBlock{&providers.TextContent{Text: "Hi"}}}},
+			Tools:      []providers.Tool{{Name: "file_read", InputSchema: map[string]interface{}{"type": "object"}}},
+			ToolChoice: tt.choice,
+		}
+
+		var got map[string]interface{}
+		if toolConfig, ok := client.buildRequest(req)["toolConfig"].(map[string]interface{}); ok {
+			got = toolConfig["functionCallingConfig"].(map[string]interface{})
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%q: functionCallingConfig = %v, want %v", tt.choice, got, tt.want)
+		}
+	}
+}
+
+func TestClient_BuildRequest_ResponseFormat(t *testing.T) {
+	client := &Client{}
+
+	req := &providers.ChatRequest{
+		Model: "gemini-2.5-flash",
+		Messages: []providers.Message{
+			{Role: providers.RoleUser, Content: []providers.ContentBlock{&providers.TextContent{Text: "Summarize"}}},
+		},
+		ResponseFormat: &providers.ResponseFormat{
+			Name: "summary",
+			Schema: map[string]interface{}{
+				"type":                 "object",
+				"additionalProperties": false,
+				"properties": map[string]interface{}{
+					"title": map[string]interface{}{"type": "string"},
+				},
+			},
+		},
+	}
+
+	config := client.buildRequest(req)["generationConfig"].(map[string]interface{})
+	if config["responseMimeType"] != "application/json" {
+		t.Errorf("responseMimeType = %v, want application/json", config["responseMimeType"])
+	}
+	schema := config["responseSchema"].(map[string]interface{})
+	if _, ok := schema["additionalProperties"]; ok || schema["type"] != "object" {
+		t.Errorf("responseSchema = %v", schema)
+	}
+
+	req.Tools = []providers.Tool{{Name: "file_read", InputSchema: map[string]interface{}{"type": "object"}}}
+	if _, ok := client.buildRequest(req)["generationConfig"]; ok {
+		t.Error("generationConfig should be omitted when tools are sent")
+	}
+}
+
+// streamFixture replays a recorded SSE stream from testdata through
+// streamResponse and collects the emitted events.
+func streamFixture(t *testing.T, name string) []providers.ChatEvent {
+	t.Helper()
+
+	f, err := os.Open(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatalf("failed to open fixture: %v", err)
+	}
+
+	client := &Client{}
+	eventChan := make(chan providers.ChatEvent, 100)
+	client.streamResponse(f, eventChan)
+
+	var events []providers.ChatEvent
+	for event := range eventChan {
+		events = append(events, event)
+	}
+	return events
+}
+
+func TestClient_StreamResponse_FunctionCall(t *testing.T) {
+	events := streamFixture(t, "function_call.sse")
+
+	var text string
+	var toolUses []*providers.ToolUseContent
+	for _, event := range events {
+		switch event.Type {
+		case providers.EventTypeTextDelta:
+			text += event.Content
+		case providers.EventTypeToolUse:
+			toolUses = append(toolUses, event.ToolUse)
+		case providers.EventTypeError:
+			t.Fatalf("unexpected error: %v", event.Error)
+		}
+	}
+
+	if text != "I'll read both files to compare them." {
+		t.Errorf("text = %q", text)
+	}
+
+	if len(toolUses) != 3 {
+		t.Fatalf("got %d tool uses, want 3", len(toolUses))
+	}
+	if toolUses[0].Name != "file_read" || toolUses[0].Input["path"] != "go.mod" {
+		t.Errorf("tool 0 = %s %v", toolUses[0].Name, toolUses[0].Input)
+	}
+	if toolUses[1].Input["start_line"] != float64(10) {
+		t.Errorf("tool 1 input = %v", toolUses[1].Input)
+	}
+	if toolUses[2].Name != "git_status" || toolUses[2].Input == nil {
+		t.Errorf("tool 2 = %s %v, want git_status with empty input", toolUses[2].Name, toolUses[2].Input)
+	}
+	if toolUses[0].Signature != "CtcBAdHtim8Qr" || toolUses[1].Signature != "" {
+		t.Errorf("signatures = %q, %q, want only the first call signed", toolUses[0].Signature, toolUses[1].Signature)
+	}
+	if toolUses[0].ID == "" || toolUses[0].ID == toolUses[1].ID {
+		t.Errorf("tool call IDs %q and %q are not unique", toolUses[0].ID, toolUses[1].ID)
+	}
+
+	last := events[len(events)-1]
+	if last.Type != providers.EventTypeMessageDone || last.Usage == nil {
+		t.Fatalf("last event = %+v, want message_done with usage", last)
+	}
+	want := providers.Usage{InputTokens: 356, OutputTokens: 201, TotalTokens: 1581, CacheReadInputTokens: 1024}
+	if *last.Usage != want {
+		t.Errorf("usage = %+v, want %+v", *last.Usage, want)
+	}
+}
+
+// TestClient_Chat_ToolLoop checks that a thought signature returned with a
+// function call is sent back with that call on the next turn.
+func TestClient_Chat_ToolLoop(t *testing.T) {
+	var turn int
+	var history []map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		turn++
+		var req struct {
+			Contents []struct {
+				Role  string                   `json:"role"`
+				Parts []map[string]interface{} `json:"parts"`
+			} `json:"contents"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Fatalf("failed to decode request: %v", err)
+		}
+		if turn == 2 {
+			history = req.Contents[1].Parts
+		}
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		if turn == 1 {
+			w.Write([]byte(`data: {"candidates":[{"content":{"parts":[{"functionCall":{"name":"git_status","args":{}},"thoughtSignature":"sig-1"}],"role":"model"},"finishReason":"STOP"}]}` + "\n\n"))
+			return
+		}
+		w.Write([]byte(`data: {"candidates":[{"content":{"parts":[{"text":"The tree is clean."}],"role":"model"},"finishReason":"STOP"}]}` + "\n\n"))
+	}))
+	defer server.Close()
+
+	client, _ := New("test-key")
+	client.baseURL = server.URL + "/v1beta"
+
+	messages := []providers.Message{
+		{Role: providers.RoleUser, Content: []providers.ContentBlock{&providers.TextContent{Text: "Is the tree clean?"}}},
+	}
+	req := &providers.ChatRequest{
+		Model:          "gemini-2.5-pro",
+		ThinkingBudget: 1024,
+		Tools:          []providers.Tool{{Name: "git_status", InputSchema: map[string]interface{}{"type": "object"}}},
+	}
+
+	req.Messages = messages
+	events, err := client.Chat(context.Background(), req)
+	if err != nil {
+		t.Fatalf("Chat() error = %v", err)
+	}
+	var call *providers.ToolUseContent
+	for event := range events {
+		if event.Type == providers.EventTypeToolUse {
+			call = event.ToolUse
+		}
+	}
+	if call == nil {
+		t.Fatal("expected a function call")
+	}
+
+	req.Messages = append(messages,
+		providers.Message{Role: providers.RoleAssistant, Content: []providers.ContentBlock{call}},
+		providers.Message{Role: providers.RoleTool, Content: []providers.ContentBlock{
+			&providers.ToolResultContent{ToolUseID: call.ID, Content: "nothing to commit"},
+		}},
+	)
+	events, err = client.Chat(context.Background(), req)
+	if err != nil {
+		t.Fatalf("Chat() error = %v", err)
+	}
+	for event := range events {
+		if event.Type == providers.EventTypeError {
+			t.Fatalf("unexpected error: %v", event.Error)
+		}
+	}
+
+	if len(history) != 1 || history[0]["functionCall"] == nil {
+		t.Fatalf("model turn = %v, want the function call", history)
+	}
+	if history[0]["thoughtSignature"] != "sig-1" {
+		t.Errorf("thoughtSignature = %v, want sig-1", history[0]["thoughtSignature"])
+	}
+}
+
+func TestClient_StreamResponse_Thinking(t *testing.T) {
+	events := streamFixture(t, "thinking.sse")
+
+	var thinking, text string
+	for _, event := range events {
+		switch event.Type {
+		case providers.EventTypeThinkingDelta:
+			thinking += event.Content
+		case providers.EventTypeTextDelta:
+			text += event.Content
+		}
+	}
+
+	if !strings.HasPrefix(thinking, "**Finding the module path**") {
+		t.Errorf("thinking = %q", thinking)
+	}
+	if text != "The module is github.com/taaha3244/potus." {
+		t.Errorf("text = %q, want the answer without thoughts", text)
+	}
+}
+
+func TestClient_StreamResponse_Errors(t *testing.T) {
+	tests := []struct {
+		fixture string
+		wantErr string
+	}{
+		{"safety.sse", "response stopped: SAFETY"},
+		{"stream_error.sse", "stream error: UNAVAILABLE: The model is overloaded"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.fixture, func(t *testing.T) {
+			events := streamFixture(t, tt.fixture)
+
+			for _, event := range events {
+				if event.Type == providers.EventTypeMessageDone {
+					t.Errorf("unexpected %s event", event.Type)
+				}
+			}
+
+			last := events[len(events)-1]
+			if last.Type != providers.EventTypeError {
+				t.Fatalf("last event = %v, want error", last.Type)
+			}
+			if !strings.Contains(last.Error.Error(), tt.wantErr) {
+				t.Errorf("error = %q, want it to contain %q", last.Error, tt.wantErr)
+			}
+		})
+	}
+}
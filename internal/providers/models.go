@@ -0,0 +1,171 @@
+package providers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// DefaultModelCacheTTL is how long a fetched model list is used before the
+// provider is asked again.
+const DefaultModelCacheTTL = 24 * time.Hour
+
+// discoveryTimeout keeps startup quick when the models endpoint can't be
+// reached.
+const discoveryTimeout = 10 * time.Second
+
+// ModelCache keeps the model lists fetched from provider APIs on disk, one
+// JSON file per provider.
+type ModelCache struct {
+	dir string
+	ttl time.Duration
+	now func() time.Time
+}
+
+type cachedModels struct {
+	FetchedAt time.Time `json:"fetched_at"`
+	Models    []Model   `json:"models"`
+}
+
+func NewModelCache(dir string, ttl time.Duration) *ModelCache {
+	return &ModelCache{dir: dir, ttl: ttl, now: time.Now}
+}
+
+// DefaultModelCacheDir is ~/.cache/potus/models, or the platform's
+// equivalent.
+func DefaultModelCacheDir() string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		dir = "."
+	}
+	return filepath.Join(dir, "potus", "models")
+}
+
+// Load returns the cached models of a provider and whether they are still
+// within the TTL. ok is false if nothing usable is cached.
+func (c *ModelCache) Load(provider string) (models []Model, fresh, ok bool) {
+	data, err := os.ReadFile(c.path(provider))
+	if err != nil {
+		return nil, false, false
+	}
+
+	var cached cachedModels
+	if err := json.Unmarshal(data, &cached); err != nil || len(cached.Models) == 0 {
+		return nil, false, false
+	}
+	return cached.Models, c.now().Sub(cached.FetchedAt) < c.ttl, true
+}
+
+func (c *ModelCache) Save(provider string, models []Model) error {
+	data, err := json.MarshalIndent(cachedModels{FetchedAt: c.now(), Models: models}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode model cache: %w", err)
+	}
+	if err := os.MkdirAll(c.dir, 0755); err != nil {
+		return fmt.Errorf("failed to create model cache directory: %w", err)
+	}
+	if err := os.WriteFile(c.path(provider), data, 0644); err != nil {
+		return fmt.Errorf("failed to write model cache: %w", err)
+	}
+	return nil
+}
+
+func (c *ModelCache) path(provider string) string {
+	return filepath.Join(c.dir, provider+".json")
+}
+
+// DiscoverModels lists a provider's models from the cache while it is
+// fresh, and otherwise from fetch, saving the result. When fetching fails,
+// e.g. offline, a stale cache or else the static table is returned.
+func DiscoverModels(ctx context.Context, cache *ModelCache, provider string, fetch func(ctx context.Context) ([]Model, error), static []Model) []Model {
+	cached, fresh, ok := cache.Load(provider)
+	if fresh {
+		return cached
+	}
+
+	fetchCtx, cancel := context.WithTimeout(ctx, discoveryTimeout)
+	defer cancel()
+
+	models, err := fetch(fetchCtx)
+	if err == nil && len(models) > 0 {
+		_ = cache.Save(provider, models)
+		return models
+	}
+
+	if ok {
+		return cached
+	}
+	return static
+}
+
+// MergeModelMetadata fills in context size, pricing and capabilities of
+// fetched models from a table of known models. Dated snapshots such as
+// gpt-4.1-2025-04-14 take the metadata of their base model.
+func MergeModelMetadata(fetched []Model, known []Model) []Model {
+	merged := make([]Model, 0, len(fetched))
+	for _, m := range fetched {
+		if meta, ok := lookupModel(known, m.ID); ok {
+			meta.ID = m.ID
+			if m.Name != "" && m.Name != m.ID {
+				meta.Name = m.Name
+			}
+			m = meta
+		}
+		if m.Name == "" {
+			m.Name = m.ID
+		}
+		merged = append(merged, m)
+	}
+	return merged
+}
+
+// FindModel looks a model up by ID or name. An alias without a date, such
+// as claude-sonnet-4-5, finds its dated snapshot and a dated ID finds its
+// base model.
+func FindModel(models []Model, id string) (Model, bool) {
+	for _, m := range models {
+		if m.ID == id || m.Name == id {
+			return m, true
+		}
+	}
+	for _, m := range models {
+		if rest, ok := strings.CutPrefix(m.ID, id+"-"); ok && isDateSuffix(rest) {
+			return m, true
+		}
+	}
+	return lookupModel(models, id)
+}
+
+func lookupModel(known []Model, id string) (Model, bool) {
+	var best Model
+	found := false
+	for _, k := range known {
+		if k.ID == id {
+			return k, true
+		}
+		rest, ok := strings.CutPrefix(id, k.ID+"-")
+		if ok && isDateSuffix(rest) && len(k.ID) > len(best.ID) {
+			best = k
+			found = true
+		}
+	}
+	return best, found
+}
+
+// isDateSuffix reports whether a model ID suffix starts with a year, as in
+// 2025-04-14 or 20250929, rather than a version or variant.
+func isDateSuffix(s string) bool {
+	if len(s) < 4 {
+		return false
+	}
+	for _, r := range s[:4] {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,146 @@
+package providers
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestModelCache_SaveLoad(t *testing.T) {
+	cache := NewModelCache(t.TempDir(), time.Hour)
+	now := time.Date(2026, 1, 2, 15, 0, 0, 0, time.UTC)
+	cache.now = func() time.Time { return now }
+
+	if _, _, ok := cache.Load("anthropic"); ok {
+		t.Fatal("empty cache reported a hit")
+	}
+
+	models := []Model{{ID: "claude-sonnet-4-5-20250929", Name: "Claude Sonnet 4.5", ContextSize: 200000, Pricing: ModelPricing{InputPer1M: 3}}}
+	if err := cache.Save("anthropic", models); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	got, fresh, ok := cache.Load("anthropic")
+	if !ok || !fresh {
+		t.Fatalf("Load() fresh=%v ok=%v, want a fresh hit", fresh, ok)
+	}
+	if len(got) != 1 || got[0].ID != models[0].ID || got[0].Pricing.InputPer1M != 3 {
+		t.Errorf("Load() = %+v", got)
+	}
+
+	now = now.Add(2 * time.Hour)
+	if _, fresh, ok := cache.Load("anthropic"); !ok || fresh {
+		t.Errorf("after TTL fresh=%v ok=%v, want a stale hit", fresh, ok)
+	}
+}
+
+func TestDiscoverModels(t *testing.T) {
+	static := []Model{{ID: "static"}}
+	fetched := []Model{{ID: "fetched"}}
+	failing := func(ctx context.Context) ([]Model, error) { return nil, errors.New("offline") }
+
+	t.Run("fetches and caches", func(t *testing.T) {
+		cache := NewModelCache(t.TempDir(), time.Hour)
+		calls := 0
+		fetch := func(ctx context.Context) ([]Model, error) {
+			calls++
+			return fetched, nil
+		}
+
+		for i := 0; i < 2; i++ {
+			if got := DiscoverModels(context.Background(), cache, "openai", fetch, static); got[0].ID != "fetched" {
+				t.Errorf("call %d = %v, want fetched models", i, got)
+			}
+		}
+		if calls != 1 {
+			t.Errorf("fetched %d times, want 1 (second call cached)", calls)
+		}
+	})
+
+	t.Run("offline without cache", func(t *testing.T) {
+		cache := NewModelCache(t.TempDir(), time.Hour)
+		if got := DiscoverModels(context.Background(), cache, "openai", failing, static); got[0].ID != "static" {
+			t.Errorf("got %v, want the static table", got)
+		}
+	})
+
+	t.Run("offline with stale cache", func(t *testing.T) {
+		cache := NewModelCache(t.TempDir(), time.Hour)
+		cache.Save("openai", fetched)
+		cache.now = func() time.Time { return time.Now().Add(48 * time.Hour) }
+
+		if got := DiscoverModels(context.Background(), cache, "openai", failing, static); got[0].ID != "fetched" {
+			t.Errorf("got %v, want the stale cache", got)
+		}
+	})
+}
+
+func TestMergeModelMetadata(t *testing.T) {
+	known := []Model{
+		{ID: "gpt-5", Name: "GPT-5", ContextSize: 400000, Pricing: ModelPricing{InputPer1M: 1.25}, SupportsVision: true},
+		{ID: "gpt-5-mini", Name: "GPT-5 Mini", ContextSize: 400000, Pricing: ModelPricing{InputPer1M: 0.25}},
+	}
+	fetched := []Model{
+		{ID: "gpt-5"},
+		{ID: "gpt-5-2025-08-07"},
+		{ID: "gpt-5-mini-2025-08-07"},
+		{ID: "gpt-5-nano"},
+		{ID: "claude-new", Name: "Claude New", ContextSize: 200000},
+	}
+
+	got := MergeModelMetadata(fetched, known)
+
+	tests := []struct {
+		id      string
+		name    string
+		context int
+		input   float64
+	}{
+		{"gpt-5", "GPT-5", 400000, 1.25},
+		{"gpt-5-2025-08-07", "GPT-5", 400000, 1.25},
+		{"gpt-5-mini-2025-08-07", "GPT-5 Mini", 400000, 0.25},
+		{"gpt-5-nano", "gpt-5-nano", 0, 0},
+		{"claude-new", "Claude New", 200000, 0},
+	}
+	for i, tt := range tests {
+		m := got[i]
+		if m.ID != tt.id || m.Name != tt.name || m.ContextSize != tt.context || m.Pricing.InputPer1M != tt.input {
+			t.Errorf("model %d = %+v, want %s %q %d $%.2f", i, m, tt.id, tt.name, tt.context, tt.input)
+		}
+	}
+	if !got[1].SupportsVision {
+		t.Error("capabilities not copied from the known model")
+	}
+}
+
+func TestFindModel(t *testing.T) {
+	models := []Model{
+		{ID: "claude-sonnet-4-5-20250929", Name: "Claude Sonnet 4.5"},
+		{ID: "gpt-4.1", Name: "GPT-4.1"},
+	}
+
+	tests := []struct {
+		query string
+		want  string
+	}{
+		{"claude-sonnet-4-5-20250929", "claude-sonnet-4-5-20250929"},
+		{"Claude Sonnet 4.5", "claude-sonnet-4-5-20250929"},
+		{"claude-sonnet-4-5", "claude-sonnet-4-5-20250929"},
+		{"gpt-4.1-2025-04-14", "gpt-4.1"},
+		{"claude-sonnet-4", ""},
+		{"gpt-4", ""},
+	}
+	for _, tt := range tests {
+		m, ok := FindModel(models, tt.query)
+		if tt.want == "" {
+			if ok {
+				t.Errorf("FindModel(%q) = %s, want no match", tt.query, m.ID)
+			}
+			continue
+		}
+		if !ok || m.ID != tt.want {
+			t.Errorf("FindModel(%q) = %s, %v, want %s", tt.query, m.ID, ok, tt.want)
+		}
+	}
+}
@@ -8,6 +8,8 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"strings"
+	"sync"
 
 	"github.com/taaha3244/potus/internal/providers"
 )
@@ -15,6 +17,9 @@ import (
 type Client struct {
 	endpoint string
 	client   *http.Client
+
+	mu           sync.RWMutex
+	capabilities map[string]bool // nil until a model is selected
 }
 
 func New(endpoint string) (*Client, error) {
@@ -33,11 +38,70 @@ func (c *Client) Name() string {
 }
 
 func (c *Client) SupportsTools() bool {
-	return true
+	return c.hasCapability("tools")
 }
 
 func (c *Client) SupportsVision() bool {
-	return true
+	return c.hasCapability("vision")
+}
+
+// hasCapability reports whether the selected model has a capability. Without
+// capability information (no model selected, or an Ollama too old to report
+// it) every capability is assumed.
+func (c *Client) hasCapability(name string) bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	if c.capabilities == nil {
+		return true
+	}
+	return c.capabilities[name]
+}
+
+// SelectModel fetches the model's capabilities from /api/show.
+func (c *Client) SelectModel(ctx context.Context, model string) error {
+	body, err := json.Marshal(map[string]string{"model": model})
+	if err != nil {
+		return fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", c.endpoint+"/api/show", bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.client.Do(httpReq)
+	if err != nil {
+		return fmt.Errorf("failed to fetch model info: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("API error (status %d): %s", resp.StatusCode, string(body))
+	}
+
+	var result struct {
+		Capabilities []string `json:"capabilities"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	var capabilities map[string]bool
+	if result.Capabilities != nil {
+		capabilities = make(map[string]bool, len(result.Capabilities))
+		for _, capability := range result.Capabilities {
+			capabilities[capability] = true
+		}
+	}
+
+	c.mu.Lock()
+	c.capabilities = capabilities
+	c.mu.Unlock()
+
+	return nil
 }
 
 func (c *Client) ListModels(ctx context.Context) ([]providers.Model, error) {
@@ -96,9 +160,7 @@ func (c *Client) Chat(ctx context.Context, req *providers.ChatRequest) (<-chan p
 	}
 
 	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		resp.Body.Close()
-		return nil, fmt.Errorf("API error (status %d): %s", resp.StatusCode, string(body))
+		return nil, providers.NewAPIError(resp)
 	}
 
 	eventChan := make(chan providers.ChatEvent, 10)
@@ -129,34 +191,49 @@ func (c *Client) buildRequest(req *providers.ChatRequest) map[string]interface{}
 		})
 	}
 
+	// Ollama identifies tool results by tool name, so remember which call
+	// each result answers
+	toolNames := make(map[string]string)
+	for _, msg := range req.Messages {
+		for _, block := range msg.Content {
+			if toolUse, ok := block.(*providers.ToolUseContent); ok {
+				toolNames[toolUse.ID] = toolUse.Name
+			}
+		}
+	}
+
 	for _, msg := range req.Messages {
+		if msg.Role == providers.RoleTool {
+			messages = append(messages, c.toolResultMessages(msg.Content, toolNames)...)
+			continue
+		}
+
 		apiMsg := map[string]interface{}{
 			"role": c.convertRole(msg.Role),
 		}
 
-		content := ""
+		var texts []string
 		images := []string{}
 		var toolCalls []map[string]interface{}
 
 		for _, block := range msg.Content {
 			switch b := block.(type) {
 			case *providers.TextContent:
-				content = b.Text
+				texts = append(texts, b.Text)
 			case *providers.ImageContent:
 				images = append(images, b.Source.Data)
 			case *providers.ToolUseContent:
 				toolCalls = append(toolCalls, map[string]interface{}{
+					"id": b.ID,
 					"function": map[string]interface{}{
 						"name":      b.Name,
 						"arguments": b.Input,
 					},
 				})
-			case *providers.ToolResultContent:
-				content = b.Content
 			}
 		}
 
-		apiMsg["content"] = content
+		apiMsg["content"] = strings.Join(texts, "\n\n")
 
 		if len(images) > 0 {
 			apiMsg["images"] = images
@@ -171,10 +248,25 @@ func (c *Client) buildRequest(req *providers.ChatRequest) map[string]interface{}
 
 	apiReq["messages"] = messages
 
-	if len(req.Tools) > 0 {
-		tools := make([]map[string]interface{}, 0, len(req.Tools))
+	// Ollama has no tool choice: forbidding tools leaves them out, and a
+	// named tool is the only one offered, though the model may still answer
+	// without it
+	tools := req.Tools
+	if req.ToolChoice == providers.ToolChoiceNone {
+		tools = nil
+	} else if name := req.ToolChoice.ForcedTool(); name != "" {
+		tools = nil
 		for _, tool := range req.Tools {
-			tools = append(tools, map[string]interface{}{
+			if tool.Name == name {
+				tools = append(tools, tool)
+			}
+		}
+	}
+
+	if len(tools) > 0 {
+		apiTools := make([]map[string]interface{}, 0, len(tools))
+		for _, tool := range tools {
+			apiTools = append(apiTools, map[string]interface{}{
 				"type": "function",
 				"function": map[string]interface{}{
 					"name":        tool.Name,
@@ -183,12 +275,44 @@ func (c *Client) buildRequest(req *providers.ChatRequest) map[string]interface{}
 				},
 			})
 		}
-		apiReq["tools"] = tools
+		apiReq["tools"] = apiTools
+	}
+
+	// Ollama constrains the output with the schema itself
+	if req.ResponseFormat != nil {
+		apiReq["format"] = req.ResponseFormat.Schema
 	}
 
 	return apiReq
 }
 
+func (c *Client) toolResultMessages(blocks []providers.ContentBlock, toolNames map[string]string) []map[string]interface{} {
+	var messages []map[string]interface{}
+	// Images of tool results follow in a user message, which is where
+	// vision models look for them
+	var images []string
+	for _, block := range blocks {
+		if toolResult, ok := block.(*providers.ToolResultContent); ok {
+			messages = append(messages, map[string]interface{}{
+				"role":      "tool",
+				"content":   toolResult.Content,
+				"tool_name": toolNames[toolResult.ToolUseID],
+			})
+			for _, img := range toolResult.Images {
+				images = append(images, img.Source.Data)
+			}
+		}
+	}
+	if len(images) > 0 {
+		messages = append(messages, map[string]interface{}{
+			"role":    "user",
+			"content": "",
+			"images":  images,
+		})
+	}
+	return messages
+}
+
 func (c *Client) convertRole(role providers.MessageRole) string {
 	switch role {
 	case providers.RoleTool:
@@ -218,7 +342,17 @@ func (c *Client) streamResponse(body io.ReadCloser, eventChan chan<- providers.C
 		}
 
 		if done, ok := chunk["done"].(bool); ok && done {
-			eventChan <- providers.ChatEvent{Type: providers.EventTypeMessageDone}
+			promptTokens, _ := chunk["prompt_eval_count"].(float64)
+			evalTokens, _ := chunk["eval_count"].(float64)
+
+			eventChan <- providers.ChatEvent{
+				Type: providers.EventTypeMessageDone,
+				Usage: &providers.Usage{
+					InputTokens:  int(promptTokens),
+					OutputTokens: int(evalTokens),
+					TotalTokens:  int(promptTokens + evalTokens),
+				},
+			}
 			break
 		}
 
@@ -236,11 +370,20 @@ func (c *Client) streamResponse(body io.ReadCloser, eventChan chan<- providers.C
 					if function, ok := toolCall["function"].(map[string]interface{}); ok {
 						name, _ := function["name"].(string)
 						arguments, _ := function["arguments"].(map[string]interface{})
+						if arguments == nil {
+							arguments = map[string]interface{}{}
+						}
+
+						// Recent Ollama versions assign IDs; older ones don't
+						id, _ := toolCall["id"].(string)
+						if id == "" {
+							id = providers.NewToolCallID()
+						}
 
 						eventChan <- providers.ChatEvent{
 							Type: providers.EventTypeToolUse,
 							ToolUse: &providers.ToolUseContent{
-								ID:    fmt.Sprintf("tool_%s", name),
+								ID:    id,
 								Name:  name,
 								Input: arguments,
 							},
@@ -44,10 +44,65 @@ func TestClient_Name(t *testing.T) {
 func TestClient_SupportsTools(t *testing.T) {
 	client := &Client{}
 	if !client.SupportsTools() {
-		t.Error("SupportsTools() should return true")
+		t.Error("SupportsTools() should return true before a model is selected")
 	}
 }
 
+func TestClient_SelectModel(t *testing.T) {
+	tests := []struct {
+		name       string
+		response   string
+		wantTools  bool
+		wantVision bool
+	}{
+		{"tools only", `{"capabilities":["completion","tools"]}`, true, false},
+		{"vision only", `{"capabilities":["completion","vision"]}`, false, true},
+		{"no capability info", `{"modelfile":"FROM llama2"}`, true, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path != "/api/show" {
+					t.Errorf("URL path = %s, want /api/show", r.URL.Path)
+				}
+				var req map[string]string
+				json.NewDecoder(r.Body).Decode(&req)
+				if req["model"] != "llama3.2" {
+					t.Errorf("model = %s, want llama3.2", req["model"])
+				}
+				w.Write([]byte(tt.response))
+			}))
+			defer server.Close()
+
+			client := &Client{endpoint: server.URL, client: &http.Client{}}
+			if err := client.SelectModel(context.Background(), "llama3.2"); err != nil {
+				t.Fatalf("SelectModel() error = %v", err)
+			}
+
+			if client.SupportsTools() != tt.wantTools {
+				t.Errorf("SupportsTools() = %v, want %v", client.SupportsTools(), tt.wantTools)
+			}
+			if client.SupportsVision() != tt.wantVision {
+				t.Errorf("SupportsVision() = %v, want %v", client.SupportsVision(), tt.wantVision)
+			}
+		})
+	}
+
+	t.Run("unknown model", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"error":"model 'nope' not found"}`))
+		}))
+		defer server.Close()
+
+		client := &Client{endpoint: server.URL, client: &http.Client{}}
+		if err := client.SelectModel(context.Background(), "nope"); err == nil {
+			t.Error("Expected error for unknown model")
+		}
+	})
+}
+
 func TestClient_SupportsVision(t *testing.T) {
 	client := &Client{}
 	if !client.SupportsVision() {
@@ -169,7 +224,9 @@ func TestClient_Chat(t *testing.T) {
 						"role":    "assistant",
 						"content": "",
 					},
-					"done": true,
+					"done":              true,
+					"prompt_eval_count": 26,
+					"eval_count":        3,
 				},
 			}
 
@@ -202,6 +259,7 @@ func TestClient_Chat(t *testing.T) {
 
 		var textContent string
 		var gotMessageStart, gotMessageDone bool
+		var usage *providers.Usage
 
 		for event := range events {
 			switch event.Type {
@@ -211,6 +269,7 @@ func TestClient_Chat(t *testing.T) {
 				textContent += event.Content
 			case providers.EventTypeMessageDone:
 				gotMessageDone = true
+				usage = event.Usage
 			case providers.EventTypeError:
 				t.Errorf("Unexpected error: %v", event.Error)
 			}
@@ -225,6 +284,12 @@ func TestClient_Chat(t *testing.T) {
 		if textContent != "Hello World" {
 			t.Errorf("textContent = %s, want 'Hello World'", textContent)
 		}
+		if usage == nil {
+			t.Fatal("Expected usage on message_done")
+		}
+		if usage.InputTokens != 26 || usage.OutputTokens != 3 || usage.TotalTokens != 29 {
+			t.Errorf("usage = %+v, want 26 in / 3 out / 29 total", *usage)
+		}
 	})
 
 	t.Run("with tool calls", func(t *testing.T) {
@@ -245,6 +310,12 @@ func TestClient_Chat(t *testing.T) {
 									"arguments": map[string]interface{}{"path": "/test.txt"},
 								},
 							},
+							{
+								"function": map[string]interface{}{
+									"name":      "file_read",
+									"arguments": map[string]interface{}{"path": "/other.txt"},
+								},
+							},
 						},
 					},
 					"done": false,
@@ -289,21 +360,21 @@ func TestClient_Chat(t *testing.T) {
 			t.Fatalf("Chat() error = %v", err)
 		}
 
-		var gotToolUse bool
-		var toolName string
-
+		var toolUses []*providers.ToolUseContent
 		for event := range events {
 			if event.Type == providers.EventTypeToolUse {
-				gotToolUse = true
-				toolName = event.ToolUse.Name
+				toolUses = append(toolUses, event.ToolUse)
 			}
 		}
 
-		if !gotToolUse {
-			t.Error("Expected tool_use event")
+		if len(toolUses) != 2 {
+			t.Fatalf("got %d tool_use events, want 2", len(toolUses))
+		}
+		if toolUses[0].Name != "file_read" {
+			t.Errorf("toolName = %s, want file_read", toolUses[0].Name)
 		}
-		if toolName != "file_read" {
-			t.Errorf("toolName = %s, want file_read", toolName)
+		if toolUses[0].ID == "" || toolUses[0].ID == toolUses[1].ID {
+			t.Errorf("tool IDs should be unique, got %q and %q", toolUses[0].ID, toolUses[1].ID)
 		}
 	})
 
@@ -439,6 +510,60 @@ func TestClient_BuildRequest(t *testing.T) {
 		}
 	})
 
+	t.Run("with tool choice", func(t *testing.T) {
+		req := &providers.ChatRequest{
+			Model: "llama2",
+			Messages: []providers.Message{
+				{
+					Role:    providers.RoleUser,
+					Content: []providers.ContentBlock{&providers.TextContent{Text: "Hello"}},
+				},
+			},
+			Tools: []providers.Tool{
+				{Name: "file_read", InputSchema: map[string]interface{}{"type": "object"}},
+				{Name: "bash", InputSchema: map[string]interface{}{"type": "object"}},
+			},
+			ToolChoice: "bash",
+		}
+
+		tools := client.buildRequest(req)["tools"].([]map[string]interface{})
+		if len(tools) != 1 || tools[0]["function"].(map[string]interface{})["name"] != "bash" {
+			t.Errorf("tools = %v, want only bash", tools)
+		}
+
+		req.ToolChoice = providers.ToolChoiceNone
+		if _, ok := client.buildRequest(req)["tools"]; ok {
+			t.Error("tools should be omitted when forbidden")
+		}
+	})
+
+	t.Run("with response format", func(t *testing.T) {
+		schema := map[string]interface{}{
+			"type":     "object",
+			"required": []string{"answer"},
+		}
+		req := &providers.ChatRequest{
+			Model: "llama2",
+			Messages: []providers.Message{
+				{
+					Role:    providers.RoleUser,
+					Content: []providers.ContentBlock{&providers.TextContent{Text: "Hello"}},
+				},
+			},
+			ResponseFormat: &providers.ResponseFormat{Name: "response", Schema: schema},
+		}
+
+		apiReq := client.buildRequest(req)
+
+		format, ok := apiReq["format"].(map[string]interface{})
+		if !ok {
+			t.Fatalf("format = %v, want the schema", apiReq["format"])
+		}
+		if format["type"] != "object" {
+			t.Errorf("format type = %v, want object", format["type"])
+		}
+	})
+
 	t.Run("with image content", func(t *testing.T) {
 		req := &providers.ChatRequest{
 			Model: "llava",
@@ -521,6 +646,44 @@ func TestClient_BuildRequest(t *testing.T) {
 			t.Errorf("content = %v, want 'File contents here'", messages[0]["content"])
 		}
 	})
+
+	t.Run("tool results mapped to tool names", func(t *testing.T) {
+		req := &providers.ChatRequest{
+			Model: "llama2",
+			Messages: []providers.Message{
+				{
+					Role: providers.RoleAssistant,
+					Content: []providers.ContentBlock{
+						&providers.ToolUseContent{ID: "call_a", Name: "file_read", Input: map[string]interface{}{}},
+						&providers.ToolUseContent{ID: "call_b", Name: "git_status", Input: map[string]interface{}{}},
+					},
+				},
+				{
+					Role: providers.RoleTool,
+					Content: []providers.ContentBlock{
+						&providers.ToolResultContent{ToolUseID: "call_a", Content: "contents"},
+						&providers.ToolResultContent{ToolUseID: "call_b", Content: "clean"},
+					},
+				},
+			},
+		}
+
+		apiReq := client.buildRequest(req)
+
+		messages := apiReq["messages"].([]map[string]interface{})
+		if len(messages) != 3 {
+			t.Fatalf("messages length = %d, want 3", len(messages))
+		}
+		for i, want := range []string{"file_read", "git_status"} {
+			msg := messages[i+1]
+			if msg["role"] != "tool" || msg["tool_name"] != want {
+				t.Errorf("message %d = %v, want tool result for %s", i+1, msg, want)
+			}
+		}
+		if messages[2]["content"] != "clean" {
+			t.Errorf("content = %v, want clean", messages[2]["content"])
+		}
+	})
 }
 
 func TestClient_ConvertRole(t *testing.T) {
@@ -0,0 +1,98 @@
+package openai
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/taaha3244/potus/internal/providers"
+)
+
+const defaultAzureAPIVersion = "2024-10-21"
+
+// AzureConfig describes an Azure OpenAI resource. Requests go to a
+// deployment rather than a model: the model part of --model names the
+// deployment, falling back to Deployment.
+type AzureConfig struct {
+	Endpoint   string // e.g. https://my-resource.openai.azure.com
+	APIKey     string
+	Deployment string
+	APIVersion string
+	Models     []providers.Model // optional context sizes and pricing per deployment
+}
+
+type azureConfig struct {
+	endpoint   string
+	deployment string
+	apiVersion string
+	models     []providers.Model
+}
+
+func NewAzure(cfg AzureConfig) (*Client, error) {
+	if cfg.APIKey == "" {
+		return nil, fmt.Errorf("API key is required")
+	}
+	if cfg.Endpoint == "" {
+		return nil, fmt.Errorf("endpoint is required")
+	}
+
+	apiVersion := cfg.APIVersion
+	if apiVersion == "" {
+		apiVersion = defaultAzureAPIVersion
+	}
+
+	models := make([]providers.Model, len(cfg.Models))
+	for i, m := range cfg.Models {
+		if m.Name == "" {
+			m.Name = m.ID
+		}
+		m.Provider = "azure"
+		models[i] = m
+	}
+
+	return &Client{
+		apiKey: cfg.APIKey,
+		client: &http.Client{},
+		name:   "azure",
+		azure: &azureConfig{
+			endpoint:   strings.TrimSuffix(cfg.Endpoint, "/"),
+			deployment: cfg.Deployment,
+			apiVersion: apiVersion,
+			models:     models,
+		},
+	}, nil
+}
+
+func (c *Client) azureChatURL(model string) (string, error) {
+	deployment := model
+	if deployment == "" {
+		deployment = c.azure.deployment
+	}
+	if deployment == "" {
+		return "", fmt.Errorf("no Azure deployment configured (set providers.azure.deployment or use --model azure/<deployment>)")
+	}
+
+	return fmt.Sprintf("%s/openai/deployments/%s/chat/completions?api-version=%s",
+		c.azure.endpoint,
+		url.PathEscape(deployment),
+		url.QueryEscape(c.azure.apiVersion)), nil
+}
+
+// azureModels lists the configured models, or the default deployment when
+// none are configured. Azure has no data-plane API to list deployments.
+func (c *Client) azureModels() []providers.Model {
+	if len(c.azure.models) > 0 {
+		return c.azure.models
+	}
+	if c.azure.deployment == "" {
+		return nil
+	}
+	return []providers.Model{
+		{
+			ID:       c.azure.deployment,
+			Name:     c.azure.deployment,
+			Provider: "azure",
+		},
+	}
+}
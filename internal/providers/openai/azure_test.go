@@ -0,0 +1,133 @@
+package openai
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/taaha3244/potus/internal/providers"
+)
+
+func TestNewAzure(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     AzureConfig
+		wantErr bool
+	}{
+		{"valid", AzureConfig{Endpoint: "https://res.openai.azure.com", APIKey: "key", Deployment: "gpt-4o"}, false},
+		{"missing key", AzureConfig{Endpoint: "https://res.openai.azure.com"}, true},
+		{"missing endpoint", AzureConfig{APIKey: "key"}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, err := NewAzure(tt.cfg)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("NewAzure() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && client.Name() != "azure" {
+				t.Errorf("Name() = %s, want azure", client.Name())
+			}
+		})
+	}
+}
+
+func TestAzure_ChatURL(t *testing.T) {
+	client, _ := NewAzure(AzureConfig{
+		Endpoint:   "https://res.openai.azure.com/",
+		APIKey:     "key",
+		Deployment: "prod-gpt4o",
+	})
+
+	tests := []struct {
+		model string
+		want  string
+	}{
+		{"", "https://res.openai.azure.com/openai/deployments/prod-gpt4o/chat/completions?api-version=2024-10-21"},
+		{"gpt-4o-mini", "https://res.openai.azure.com/openai/deployments/gpt-4o-mini/chat/completions?api-version=2024-10-21"},
+	}
+
+	for _, tt := range tests {
+		got, err := client.azureChatURL(tt.model)
+		if err != nil {
+			t.Fatalf("azureChatURL(%q) error = %v", tt.model, err)
+		}
+		if got != tt.want {
+			t.Errorf("azureChatURL(%q) = %s, want %s", tt.model, got, tt.want)
+		}
+	}
+
+	noDeployment, _ := NewAzure(AzureConfig{Endpoint: "https://res.openai.azure.com", APIKey: "key"})
+	if _, err := noDeployment.azureChatURL(""); err == nil {
+		t.Error("expected error without a deployment")
+	}
+}
+
+func TestAzure_Chat(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/openai/deployments/prod-gpt4o/chat/completions" {
+			t.Errorf("URL path = %s", r.URL.Path)
+		}
+		if r.URL.Query().Get("api-version") != "2024-06-01" {
+			t.Errorf("api-version = %s, want 2024-06-01", r.URL.Query().Get("api-version"))
+		}
+		if r.Header.Get("api-key") != "azure-key" {
+			t.Error("Missing or incorrect api-key header")
+		}
+		if r.Header.Get("Authorization") != "" {
+			t.Error("Authorization header should not be sent to Azure")
+		}
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Write([]byte(`data: {"choices":[],"prompt_filter_results":[{"prompt_index":0}]}` + "\n"))
+		w.Write([]byte(`data: {"choices":[{"index":0,"delta":{"content":"Hello"}}]}` + "\n"))
+		w.Write([]byte(`data: {"choices":[{"index":0,"delta":{},"finish_reason":"stop"}]}` + "\n"))
+		w.Write([]byte("data: [DONE]\n"))
+	}))
+	defer server.Close()
+
+	client, err := NewAzure(AzureConfig{
+		Endpoint:   server.URL,
+		APIKey:     "azure-key",
+		APIVersion: "2024-06-01",
+	})
+	if err != nil {
+		t.Fatalf("NewAzure() error = %v", err)
+	}
+
+	events, err := client.Chat(context.Background(), &providers.ChatRequest{
+		Model: "prod-gpt4o",
+		Messages: []providers.Message{
+			{Role: providers.RoleUser, Content: []providers.ContentBlock{&providers.TextContent{Text: "Hi"}}},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Chat() error = %v", err)
+	}
+
+	var text string
+	for event := range events {
+		switch event.Type {
+		case providers.EventTypeTextDelta:
+			text += event.Content
+		case providers.EventTypeError:
+			t.Fatalf("unexpected error: %v", event.Error)
+		}
+	}
+	if text != "Hello" {
+		t.Errorf("text = %q, want Hello", text)
+	}
+}
+
+func TestAzure_ListModels(t *testing.T) {
+	client, _ := NewAzure(AzureConfig{Endpoint: "https://res.openai.azure.com", APIKey: "key", Deployment: "prod-gpt4o"})
+
+	models, err := client.ListModels(context.Background())
+	if err != nil {
+		t.Fatalf("ListModels() error = %v", err)
+	}
+	if len(models) != 1 || models[0].ID != "prod-gpt4o" || models[0].Provider != "azure" {
+		t.Errorf("models = %+v", models)
+	}
+}
@@ -8,18 +8,42 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"slices"
+	"sort"
 	"strings"
 
 	"github.com/taaha3244/potus/internal/providers"
 )
 
-const defaultEndpoint = "https://api.openai.com/v1/chat/completions"
+const (
+	defaultEndpoint          = "https://api.openai.com/v1/chat/completions"
+	defaultResponsesEndpoint = "https://api.openai.com/v1/responses"
+	defaultModelsEndpoint    = "https://api.openai.com/v1/models"
+)
 
 type Client struct {
 	apiKey       string
 	organization string
 	endpoint     string
 	client       *http.Client
+
+	// ListModels queries modelsEndpoint when a cache is set
+	modelsEndpoint string
+	modelCache     *providers.ModelCache
+
+	// Reasoning models are sent to the Responses API when this is set;
+	// api and modelAPIs override the choice, see SetAPI
+	responsesEndpoint string
+	api               string
+	modelAPIs         map[string]string
+
+	// Set for OpenAI-compatible servers, see NewCompatible
+	name    string
+	headers map[string]string
+	models  []providers.Model
+
+	// Set for Azure OpenAI, see NewAzure
+	azure *azureConfig
 }
 
 func New(apiKey, organization string) (*Client, error) {
@@ -28,14 +52,19 @@ func New(apiKey, organization string) (*Client, error) {
 	}
 
 	return &Client{
-		apiKey:       apiKey,
-		organization: organization,
-		endpoint:     defaultEndpoint,
-		client:       &http.Client{},
+		apiKey:            apiKey,
+		organization:      organization,
+		endpoint:          defaultEndpoint,
+		client:            &http.Client{},
+		responsesEndpoint: defaultResponsesEndpoint,
+		modelsEndpoint:    defaultModelsEndpoint,
 	}, nil
 }
 
 func (c *Client) Name() string {
+	if c.name != "" {
+		return c.name
+	}
 	return "openai"
 }
 
@@ -47,62 +76,170 @@ func (c *Client) SupportsVision() bool {
 	return true
 }
 
-func (c *Client) ListModels(ctx context.Context) ([]providers.Model, error) {
-	return []providers.Model{
-		{
-			ID:          "gpt-5.2",
-			Name:        "GPT-5.2",
-			Provider:    "openai",
-			ContextSize: 400000,
-			Pricing: providers.ModelPricing{
-				InputPer1M:  5.00,
-				OutputPer1M: 15.00,
-			},
+// knownModels holds the context size and pricing of models, which the
+// models endpoint doesn't report. It is also the list used offline.
+var knownModels = []providers.Model{
+	{
+		ID:          "gpt-5.2",
+		Name:        "GPT-5.2",
+		Provider:    "openai",
+		ContextSize: 400000,
+		Pricing: providers.ModelPricing{
+			InputPer1M:  5.00,
+			OutputPer1M: 15.00,
 		},
-		{
-			ID:          "gpt-5",
-			Name:        "GPT-5",
-			Provider:    "openai",
-			ContextSize: 200000,
-			Pricing: providers.ModelPricing{
-				InputPer1M:  5.00,
-				OutputPer1M: 15.00,
-			},
+		SupportsTools:  true,
+		SupportsVision: true,
+	},
+	{
+		ID:          "gpt-5",
+		Name:        "GPT-5",
+		Provider:    "openai",
+		ContextSize: 200000,
+		Pricing: providers.ModelPricing{
+			InputPer1M:  5.00,
+			OutputPer1M: 15.00,
 		},
-		{
-			ID:          "gpt-5-mini",
-			Name:        "GPT-5 Mini",
-			Provider:    "openai",
-			ContextSize: 200000,
-			Pricing: providers.ModelPricing{
-				InputPer1M:  0.30,
-				OutputPer1M: 1.20,
-			},
+		SupportsTools:  true,
+		SupportsVision: true,
+	},
+	{
+		ID:          "gpt-5-mini",
+		Name:        "GPT-5 Mini",
+		Provider:    "openai",
+		ContextSize: 200000,
+		Pricing: providers.ModelPricing{
+			InputPer1M:  0.30,
+			OutputPer1M: 1.20,
 		},
-		{
-			ID:          "gpt-4.1",
-			Name:        "GPT-4.1",
-			Provider:    "openai",
-			ContextSize: 1000000,
-			Pricing: providers.ModelPricing{
-				InputPer1M:  2.00,
-				OutputPer1M: 8.00,
-			},
+		SupportsTools:  true,
+		SupportsVision: true,
+	},
+	{
+		ID:          "gpt-4.1",
+		Name:        "GPT-4.1",
+		Provider:    "openai",
+		ContextSize: 1000000,
+		Pricing: providers.ModelPricing{
+			InputPer1M:  2.00,
+			OutputPer1M: 8.00,
 		},
-		{
-			ID:          "o4-mini",
-			Name:        "O4 Mini",
-			Provider:    "openai",
-			ContextSize: 200000,
-			Pricing: providers.ModelPricing{
-				InputPer1M:  1.10,
-				OutputPer1M: 4.40,
-			},
+		SupportsTools:  true,
+		SupportsVision: true,
+	},
+	{
+		ID:          "o4-mini",
+		Name:        "O4 Mini",
+		Provider:    "openai",
+		ContextSize: 200000,
+		Pricing: providers.ModelPricing{
+			InputPer1M:  1.10,
+			OutputPer1M: 4.40,
 		},
-	}, nil
+		SupportsTools:  true,
+		SupportsVision: true,
+	},
+}
+
+func (c *Client) ListModels(ctx context.Context) ([]providers.Model, error) {
+	if c.azure != nil {
+		return c.azureModels(), nil
+	}
+	if c.name != "" {
+		return c.listCompatibleModels(ctx)
+	}
+	if c.modelCache == nil {
+		return slices.Clone(knownModels), nil
+	}
+	return providers.DiscoverModels(ctx, c.modelCache, "openai", c.fetchModels, slices.Clone(knownModels)), nil
+}
+
+// SetModelCache makes ListModels query the models endpoint, keeping the
+// result in cache. Without a cache only the known models are listed.
+func (c *Client) SetModelCache(cache *providers.ModelCache) {
+	c.modelCache = cache
+}
+
+func (c *Client) fetchModels(ctx context.Context) ([]providers.Model, error) {
+	ids, err := c.fetchModelIDs(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var models []providers.Model
+	for _, id := range ids {
+		if !isChatModel(id) {
+			continue
+		}
+		models = append(models, providers.Model{
+			ID:            id,
+			Provider:      "openai",
+			SupportsTools: true,
+		})
+	}
+	return providers.MergeModelMetadata(models, knownModels), nil
+}
+
+// fetchModelIDs lists the IDs reported by the models endpoint.
+func (c *Client) fetchModelIDs(ctx context.Context) ([]string, error) {
+	httpReq, err := http.NewRequestWithContext(ctx, "GET", c.modelsEndpoint, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	c.setHeaders(httpReq)
+
+	resp, err := c.client.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch models: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, providers.NewAPIError(resp)
+	}
+	defer resp.Body.Close()
+
+	var result struct {
+		Data []struct {
+			ID string `json:"id"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	ids := make([]string, 0, len(result.Data))
+	for _, m := range result.Data {
+		ids = append(ids, m.ID)
+	}
+	return ids, nil
+}
+
+// isChatModel filters the models endpoint, which also lists embedding,
+// audio and image models, down to models that can chat.
+func isChatModel(id string) bool {
+	chat := false
+	for _, prefix := range []string{"gpt-", "chatgpt-", "o1", "o3", "o4"} {
+		if strings.HasPrefix(id, prefix) {
+			chat = true
+			break
+		}
+	}
+	if !chat {
+		return false
+	}
+
+	for _, excluded := range []string{"audio", "realtime", "transcribe", "tts", "image", "search", "embedding", "instruct"} {
+		if strings.Contains(id, excluded) {
+			return false
+		}
+	}
+	return true
 }
 
 func (c *Client) Chat(ctx context.Context, req *providers.ChatRequest) (<-chan providers.ChatEvent, error) {
+	if c.usesResponses(req.Model) {
+		return c.chatResponses(ctx, req)
+	}
+
 	apiReq := c.buildRequest(req)
 
 	body, err := json.Marshal(apiReq)
@@ -110,16 +247,21 @@ func (c *Client) Chat(ctx context.Context, req *providers.ChatRequest) (<-chan p
 		return nil, fmt.Errorf("failed to marshal request: %w", err)
 	}
 
-	httpReq, err := http.NewRequestWithContext(ctx, "POST", c.endpoint, bytes.NewReader(body))
+	endpoint := c.endpoint
+	if c.azure != nil {
+		endpoint, err = c.azureChatURL(req.Model)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", endpoint, bytes.NewReader(body))
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 
 	httpReq.Header.Set("Content-Type", "application/json")
-	httpReq.Header.Set("Authorization", "Bearer "+c.apiKey)
-	if c.organization != "" {
-		httpReq.Header.Set("OpenAI-Organization", c.organization)
-	}
+	c.setHeaders(httpReq)
 
 	resp, err := c.client.Do(httpReq)
 	if err != nil {
@@ -127,9 +269,7 @@ func (c *Client) Chat(ctx context.Context, req *providers.ChatRequest) (<-chan p
 	}
 
 	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		resp.Body.Close()
-		return nil, fmt.Errorf("API error (status %d): %s", resp.StatusCode, string(body))
+		return nil, providers.NewAPIError(resp)
 	}
 
 	eventChan := make(chan providers.ChatEvent, 10)
@@ -139,10 +279,28 @@ func (c *Client) Chat(ctx context.Context, req *providers.ChatRequest) (<-chan p
 	return eventChan, nil
 }
 
+func (c *Client) setHeaders(httpReq *http.Request) {
+	switch {
+	case c.azure != nil:
+		httpReq.Header.Set("api-key", c.apiKey)
+	case c.apiKey != "":
+		httpReq.Header.Set("Authorization", "Bearer "+c.apiKey)
+	}
+	if c.organization != "" {
+		httpReq.Header.Set("OpenAI-Organization", c.organization)
+	}
+	for k, v := range c.headers {
+		httpReq.Header.Set(k, v)
+	}
+}
+
 func (c *Client) buildRequest(req *providers.ChatRequest) map[string]interface{} {
 	apiReq := map[string]interface{}{
 		"model":  req.Model,
 		"stream": true,
+		"stream_options": map[string]interface{}{
+			"include_usage": true,
+		},
 	}
 
 	if req.MaxTokens > 0 {
@@ -163,6 +321,12 @@ func (c *Client) buildRequest(req *providers.ChatRequest) map[string]interface{}
 	}
 
 	for _, msg := range req.Messages {
+		// Each tool result must be its own message answering one tool_call_id
+		if msg.Role == providers.RoleTool {
+			messages = append(messages, c.toolResultMessages(msg.Content)...)
+			continue
+		}
+
 		apiMsg := map[string]interface{}{
 			"role": c.convertRole(msg.Role),
 		}
@@ -187,15 +351,6 @@ func (c *Client) buildRequest(req *providers.ChatRequest) map[string]interface{}
 			}
 		}
 
-		if msg.Role == providers.RoleTool {
-			if len(msg.Content) > 0 {
-				if toolResult, ok := msg.Content[0].(*providers.ToolResultContent); ok {
-					apiMsg["tool_call_id"] = toolResult.ToolUseID
-					apiMsg["content"] = toolResult.Content
-				}
-			}
-		}
-
 		messages = append(messages, apiMsg)
 	}
 
@@ -214,11 +369,56 @@ func (c *Client) buildRequest(req *providers.ChatRequest) map[string]interface{}
 			})
 		}
 		apiReq["tools"] = tools
+
+		if name := req.ToolChoice.ForcedTool(); name != "" {
+			apiReq["tool_choice"] = map[string]interface{}{
+				"type":     "function",
+				"function": map[string]interface{}{"name": name},
+			}
+		} else if req.ToolChoice != "" {
+			apiReq["tool_choice"] = string(req.ToolChoice)
+		}
+	}
+
+	if req.ResponseFormat != nil {
+		apiReq["response_format"] = map[string]interface{}{
+			"type": "json_schema",
+			"json_schema": map[string]interface{}{
+				"name":   req.ResponseFormat.Name,
+				"schema": req.ResponseFormat.Schema,
+			},
+		}
 	}
 
 	return apiReq
 }
 
+func (c *Client) toolResultMessages(blocks []providers.ContentBlock) []map[string]interface{} {
+	var messages []map[string]interface{}
+	// Tool messages only carry text, so images of tool results follow in
+	// a user message
+	images := []providers.ContentBlock{}
+	for _, block := range blocks {
+		if toolResult, ok := block.(*providers.ToolResultContent); ok {
+			messages = append(messages, map[string]interface{}{
+				"role":         "tool",
+				"tool_call_id": toolResult.ToolUseID,
+				"content":      toolResult.Content,
+			})
+			for _, img := range toolResult.Images {
+				images = append(images, img)
+			}
+		}
+	}
+	if len(images) > 0 {
+		messages = append(messages, map[string]interface{}{
+			"role":    "user",
+			"content": c.convertContent(images),
+		})
+	}
+	return messages
+}
+
 func (c *Client) convertRole(role providers.MessageRole) string {
 	switch role {
 	case providers.RoleTool:
@@ -280,6 +480,14 @@ func (c *Client) extractToolCalls(blocks []providers.ContentBlock) []map[string]
 	return toolCalls
 }
 
+// toolCallState accumulates one streamed tool call. OpenAI sends the id and
+// name in the first delta for an index and the arguments in pieces after it.
+type toolCallState struct {
+	id        string
+	name      string
+	arguments strings.Builder
+}
+
 func (c *Client) streamResponse(body io.ReadCloser, eventChan chan<- providers.ChatEvent) {
 	defer close(eventChan)
 	defer body.Close()
@@ -287,8 +495,8 @@ func (c *Client) streamResponse(body io.ReadCloser, eventChan chan<- providers.C
 	eventChan <- providers.ChatEvent{Type: providers.EventTypeMessageStart}
 
 	scanner := bufio.NewScanner(body)
-	currentToolCall := make(map[string]interface{})
-	accumulatedArgs := ""
+	toolCalls := make(map[int]*toolCallState)
+	var usage *providers.Usage
 
 	for scanner.Scan() {
 		line := scanner.Text()
@@ -310,66 +518,151 @@ func (c *Client) streamResponse(body io.ReadCloser, eventChan chan<- providers.C
 			return
 		}
 
+		// With include_usage the final chunk carries usage and no choices
+		if u := parseUsage(chunk["usage"]); u != nil {
+			usage = u
+		}
+
 		choices, ok := chunk["choices"].([]interface{})
 		if !ok || len(choices) == 0 {
 			continue
 		}
 
-		choice := choices[0].(map[string]interface{})
-		delta, ok := choice["delta"].(map[string]interface{})
+		choice, ok := choices[0].(map[string]interface{})
 		if !ok {
 			continue
 		}
 
-		if content, ok := delta["content"].(string); ok && content != "" {
-			eventChan <- providers.ChatEvent{
-				Type:    providers.EventTypeTextDelta,
-				Content: content,
-			}
-		}
-
-		if toolCalls, ok := delta["tool_calls"].([]interface{}); ok {
-			for _, tc := range toolCalls {
-				toolCall := tc.(map[string]interface{})
-
-				if id, ok := toolCall["id"].(string); ok {
-					currentToolCall["id"] = id
+		if delta, ok := choice["delta"].(map[string]interface{}); ok {
+			if content, ok := delta["content"].(string); ok && content != "" {
+				eventChan <- providers.ChatEvent{
+					Type:    providers.EventTypeTextDelta,
+					Content: content,
 				}
+			}
 
-				if function, ok := toolCall["function"].(map[string]interface{}); ok {
-					if name, ok := function["name"].(string); ok {
-						currentToolCall["name"] = name
-					}
-					if args, ok := function["arguments"].(string); ok {
-						accumulatedArgs += args
-					}
-				}
+			if deltas, ok := delta["tool_calls"].([]interface{}); ok {
+				accumulateToolCalls(toolCalls, deltas)
 			}
 		}
 
-		if finishReason, ok := choice["finish_reason"].(string); ok && finishReason == "tool_calls" {
-			if currentToolCall["id"] != nil && currentToolCall["name"] != nil {
-				var input map[string]interface{}
-				json.Unmarshal([]byte(accumulatedArgs), &input)
-
+		// A forced function call ends with "stop" rather than "tool_calls",
+		// as do the calls of some compatible servers
+		if finishReason, ok := choice["finish_reason"].(string); ok && finishReason != "" {
+			if err := emitToolCalls(toolCalls, eventChan); err != nil {
 				eventChan <- providers.ChatEvent{
-					Type: providers.EventTypeToolUse,
-					ToolUse: &providers.ToolUseContent{
-						ID:    currentToolCall["id"].(string),
-						Name:  currentToolCall["name"].(string),
-						Input: input,
-					},
+					Type:  providers.EventTypeError,
+					Error: err,
 				}
+				return
 			}
+			toolCalls = make(map[int]*toolCallState)
 		}
 	}
 
-	eventChan <- providers.ChatEvent{Type: providers.EventTypeMessageDone}
-
 	if err := scanner.Err(); err != nil {
 		eventChan <- providers.ChatEvent{
 			Type:  providers.EventTypeError,
 			Error: fmt.Errorf("scanner error: %w", err),
 		}
+		return
+	}
+
+	// Servers that never send a finish reason
+	if err := emitToolCalls(toolCalls, eventChan); err != nil {
+		eventChan <- providers.ChatEvent{
+			Type:  providers.EventTypeError,
+			Error: err,
+		}
+		return
+	}
+
+	eventChan <- providers.ChatEvent{
+		Type:  providers.EventTypeMessageDone,
+		Usage: usage,
+	}
+}
+
+func accumulateToolCalls(toolCalls map[int]*toolCallState, deltas []interface{}) {
+	for _, d := range deltas {
+		delta, ok := d.(map[string]interface{})
+		if !ok {
+			continue
+		}
+
+		index, _ := delta["index"].(float64)
+		call, ok := toolCalls[int(index)]
+		if !ok {
+			call = &toolCallState{}
+			toolCalls[int(index)] = call
+		}
+
+		if id, ok := delta["id"].(string); ok && id != "" {
+			call.id = id
+		}
+
+		if function, ok := delta["function"].(map[string]interface{}); ok {
+			if name, ok := function["name"].(string); ok && name != "" {
+				call.name = name
+			}
+			if args, ok := function["arguments"].(string); ok {
+				call.arguments.WriteString(args)
+			}
+		}
+	}
+}
+
+// emitToolCalls sends the accumulated calls in index order. Arguments that
+// are not valid JSON are reported rather than passed on as empty input.
+func emitToolCalls(toolCalls map[int]*toolCallState, eventChan chan<- providers.ChatEvent) error {
+	indexes := make([]int, 0, len(toolCalls))
+	for index := range toolCalls {
+		indexes = append(indexes, index)
+	}
+	sort.Ints(indexes)
+
+	for _, index := range indexes {
+		call := toolCalls[index]
+		if call.id == "" || call.name == "" {
+			return fmt.Errorf("tool call %d is missing an id or name", index)
+		}
+
+		input := map[string]interface{}{}
+		if raw := strings.TrimSpace(call.arguments.String()); raw != "" {
+			if err := json.Unmarshal([]byte(raw), &input); err != nil {
+				return fmt.Errorf("invalid arguments for tool %s (%s): %w", call.name, call.id, err)
+			}
+		}
+
+		eventChan <- providers.ChatEvent{
+			Type: providers.EventTypeToolUse,
+			ToolUse: &providers.ToolUseContent{
+				ID:    call.id,
+				Name:  call.name,
+				Input: input,
+			},
+		}
+	}
+
+	return nil
+}
+
+func parseUsage(raw interface{}) *providers.Usage {
+	usage, ok := raw.(map[string]interface{})
+	if !ok {
+		return nil
+	}
+
+	prompt, _ := usage["prompt_tokens"].(float64)
+	completion, _ := usage["completion_tokens"].(float64)
+	total, ok := usage["total_tokens"].(float64)
+	if !ok {
+		total = prompt + completion
+	}
+
+	return &providers.Usage{
+		InputTokens:  int(prompt),
+		OutputTokens: int(completion),
+		TotalTokens:  int(total),
 	}
 }
@@ -3,9 +3,13 @@ package openai
 import (
 	"context"
 	"encoding/json"
+	"io"
 	"net/http"
 	"net/http/httptest"
+	"reflect"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/taaha3244/potus/internal/providers"
 )
@@ -94,6 +98,41 @@ func TestClient_ListModels(t *testing.T) {
 	}
 }
 
+func TestClient_ListModels_Discovery(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer test-key" {
+			t.Errorf("Authorization = %q", r.Header.Get("Authorization"))
+		}
+		w.Write([]byte(`{"object":"list","data":[
+			{"id":"gpt-4.1-2025-04-14","object":"model","owned_by":"system"},
+			{"id":"text-embedding-3-large","object":"model","owned_by":"system"},
+			{"id":"gpt-4o-mini-tts","object":"model","owned_by":"system"},
+			{"id":"gpt-6","object":"model","owned_by":"system"},
+			{"id":"whisper-1","object":"model","owned_by":"openai-internal"}
+		]}`))
+	}))
+	defer server.Close()
+
+	client, _ := New("test-key", "")
+	client.modelsEndpoint = server.URL
+	client.SetModelCache(providers.NewModelCache(t.TempDir(), time.Hour))
+
+	models, err := client.ListModels(context.Background())
+	if err != nil {
+		t.Fatalf("ListModels() error = %v", err)
+	}
+
+	if len(models) != 2 {
+		t.Fatalf("got %d models, want the 2 chat models: %+v", len(models), models)
+	}
+	if models[0].ID != "gpt-4.1-2025-04-14" || models[0].Name != "GPT-4.1" || models[0].ContextSize != 1000000 {
+		t.Errorf("dated snapshot = %+v, want GPT-4.1 metadata", models[0])
+	}
+	if models[1].ID != "gpt-6" || models[1].Name != "gpt-6" || models[1].Provider != "openai" {
+		t.Errorf("new model = %+v", models[1])
+	}
+}
+
 func TestClient_Chat(t *testing.T) {
 	t.Run("successful streaming response", func(t *testing.T) {
 		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -117,6 +156,10 @@ func TestClient_Chat(t *testing.T) {
 			if req["stream"] != true {
 				t.Error("stream should be true")
 			}
+			streamOptions, _ := req["stream_options"].(map[string]interface{})
+			if streamOptions["include_usage"] != true {
+				t.Error("stream_options.include_usage should be true")
+			}
 
 			// Send streaming response
 			w.Header().Set("Content-Type", "text/event-stream")
@@ -127,6 +170,7 @@ func TestClient_Chat(t *testing.T) {
 				`data: {"id":"chatcmpl-123","choices":[{"index":0,"delta":{"content":"Hello"}}]}`,
 				`data: {"id":"chatcmpl-123","choices":[{"index":0,"delta":{"content":" World"}}]}`,
 				`data: {"id":"chatcmpl-123","choices":[{"index":0,"delta":{},"finish_reason":"stop"}]}`,
+				`data: {"id":"chatcmpl-123","choices":[],"usage":{"prompt_tokens":12,"completion_tokens":2,"total_tokens":14}}`,
 				`data: [DONE]`,
 			}
 
@@ -159,6 +203,7 @@ func TestClient_Chat(t *testing.T) {
 
 		var textContent string
 		var gotMessageStart, gotMessageDone bool
+		var usage *providers.Usage
 
 		for event := range events {
 			switch event.Type {
@@ -168,6 +213,7 @@ func TestClient_Chat(t *testing.T) {
 				textContent += event.Content
 			case providers.EventTypeMessageDone:
 				gotMessageDone = true
+				usage = event.Usage
 			case providers.EventTypeError:
 				t.Errorf("Unexpected error: %v", event.Error)
 			}
@@ -182,6 +228,12 @@ func TestClient_Chat(t *testing.T) {
 		if textContent != "Hello World" {
 			t.Errorf("textContent = %s, want 'Hello World'", textContent)
 		}
+		if usage == nil {
+			t.Fatal("Expected usage on message_done")
+		}
+		if usage.InputTokens != 12 || usage.OutputTokens != 2 || usage.TotalTokens != 14 {
+			t.Errorf("usage = %+v, want 12 in / 2 out / 14 total", *usage)
+		}
 	})
 
 	t.Run("with organization header", func(t *testing.T) {
@@ -347,6 +399,76 @@ func TestClient_BuildRequest(t *testing.T) {
 		}
 	})
 
+	t.Run("with tool choice", func(t *testing.T) {
+		tests := []struct {
+			choice providers.ToolChoice
+			want   interface{}
+		}{
+			{"", nil},
+			{providers.ToolChoiceNone, "none"},
+			{providers.ToolChoiceRequired, "required"},
+			{"file_read", map[string]interface{}{
+				"type":     "function",
+				"function": map[string]interface{}{"name": "file_read"},
+			}},
+		}
+
+		for _, tt := range tests {
+			req := &providers.ChatRequest{
+				Model: "gpt-4o",
+				Messages: []providers.Message{
+					{
+						Role:    providers.RoleUser,
+						Content: []providers.ContentBlock{&providers.TextContent{Text: "Hello"}},
+					},
+				},
+				Tools:      []providers.Tool{{Name: "file_read", InputSchema: map[string]interface{}{"type": "object"}}},
+				ToolChoice: tt.choice,
+			}
+
+			got, ok := client.buildRequest(req)["tool_choice"]
+			if tt.want == nil {
+				if ok {
+					t.Errorf("%q: tool_choice = %v, want it omitted", tt.choice, got)
+				}
+				continue
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("%q: tool_choice = %v, want %v", tt.choice, got, tt.want)
+			}
+		}
+	})
+
+	t.Run("with response format", func(t *testing.T) {
+		req := &providers.ChatRequest{
+			Model: "gpt-4o",
+			Messages: []providers.Message{
+				{
+					Role:    providers.RoleUser,
+					Content: []providers.ContentBlock{&providers.TextContent{Text: "Hello"}},
+				},
+			},
+			ResponseFormat: &providers.ResponseFormat{
+				Name:   "answer",
+				Schema: map[string]interface{}{"type": "object"},
+			},
+		}
+
+		apiReq := client.buildRequest(req)
+
+		format, ok := apiReq["response_format"].(map[string]interface{})
+		if !ok {
+			t.Fatal("response_format should be set")
+		}
+		if format["type"] != "json_schema" {
+			t.Errorf("response_format type = %v, want json_schema", format["type"])
+		}
+		jsonSchema := format["json_schema"].(map[string]interface{})
+		if jsonSchema["name"] != "answer" || jsonSchema["schema"] == nil {
+			t.Errorf("json_schema = %v", jsonSchema)
+		}
+	})
+
 	t.Run("with tool result message", func(t *testing.T) {
 		req := &providers.ChatRequest{
 			Model:     "gpt-4",
@@ -376,6 +498,69 @@ func TestClient_BuildRequest(t *testing.T) {
 		}
 	})
 
+	t.Run("with multiple tool results", func(t *testing.T) {
+		req := &providers.ChatRequest{
+			Model: "gpt-4",
+			Messages: []providers.Message{
+				{
+					Role: providers.RoleTool,
+					Content: []providers.ContentBlock{
+						&providers.ToolResultContent{ToolUseID: "call_a", Content: "first"},
+						&providers.ToolResultContent{ToolUseID: "call_b", Content: "second"},
+					},
+				},
+			},
+		}
+
+		apiReq := client.buildRequest(req)
+
+		messages := apiReq["messages"].([]map[string]interface{})
+		if len(messages) != 2 {
+			t.Fatalf("messages length = %d, want 2", len(messages))
+		}
+		if messages[0]["tool_call_id"] != "call_a" || messages[1]["tool_call_id"] != "call_b" {
+			t.Errorf("tool_call_ids = %v, %v", messages[0]["tool_call_id"], messages[1]["tool_call_id"])
+		}
+		if messages[1]["role"] != "tool" || messages[1]["content"] != "second" {
+			t.Errorf("second message = %v", messages[1])
+		}
+	})
+
+	t.Run("with tool result image", func(t *testing.T) {
+		req := &providers.ChatRequest{
+			Model: "gpt-4o",
+			Messages: []providers.Message{
+				{
+					Role: providers.RoleTool,
+					Content: []providers.ContentBlock{
+						&providers.ToolResultContent{
+							ToolUseID: "call_a",
+							Content:   "Image file shot.png",
+							Images: []*providers.ImageContent{
+								{Source: providers.ImageSource{Type: "base64", MediaType: "image/png", Data: "iVBORw0KGgo="}},
+							},
+						},
+					},
+				},
+			},
+		}
+
+		apiReq := client.buildRequest(req)
+
+		messages := apiReq["messages"].([]map[string]interface{})
+		if len(messages) != 2 {
+			t.Fatalf("messages length = %d, want 2", len(messages))
+		}
+		if messages[0]["role"] != "tool" || messages[1]["role"] != "user" {
+			t.Errorf("roles = %v, %v, want tool then user", messages[0]["role"], messages[1]["role"])
+		}
+		parts := messages[1]["content"].([]map[string]interface{})
+		url := parts[0]["image_url"].(map[string]interface{})["url"]
+		if url != "data:image/png;base64,iVBORw0KGgo=" {
+			t.Errorf("image url = %v", url)
+		}
+	})
+
 	t.Run("with assistant tool use", func(t *testing.T) {
 		req := &providers.ChatRequest{
 			Model:     "gpt-4",
@@ -535,3 +720,108 @@ func TestClient_ExtractToolCalls(t *testing.T) {
 		}
 	})
 }
+
+func streamLines(lines []string) []providers.ChatEvent {
+	client := &Client{}
+	eventChan := make(chan providers.ChatEvent, 100)
+	client.streamResponse(io.NopCloser(strings.NewReader(strings.Join(lines, "\n")+"\n")), eventChan)
+
+	var events []providers.ChatEvent
+	for event := range eventChan {
+		events = append(events, event)
+	}
+	return events
+}
+
+func TestClient_StreamResponse_ParallelToolCalls(t *testing.T) {
+	events := streamLines([]string{
+		`data: {"choices":[{"index":0,"delta":{"role":"assistant","tool_calls":[{"index":0,"id":"call_a","type":"function","function":{"name":"file_read","arguments":""}}]}}]}`,
+		`data: {"choices":[{"index":0,"delta":{"tool_calls":[{"index":0,"function":{"arguments":"{\"path\": \"go"}}]}}]}`,
+		`data: {"choices":[{"index":0,"delta":{"tool_calls":[{"index":1,"id":"call_b","type":"function","function":{"name":"git_status","arguments":""}}]}}]}`,
+		`data: {"choices":[{"index":0,"delta":{"tool_calls":[{"index":0,"function":{"arguments":".mod\"}"}}]}}]}`,
+		`data: {"choices":[{"index":0,"delta":{"tool_calls":[{"index":2,"id":"call_c","type":"function","function":{"name":"file_read","arguments":"{\"path\":\"go.sum\"}"}}]}}]}`,
+		`data: {"choices":[{"index":0,"delta":{},"finish_reason":"tool_calls"}]}`,
+		`data: [DONE]`,
+	})
+
+	var toolUses []*providers.ToolUseContent
+	for _, event := range events {
+		switch event.Type {
+		case providers.EventTypeToolUse:
+			toolUses = append(toolUses, event.ToolUse)
+		case providers.EventTypeError:
+			t.Fatalf("unexpected error: %v", event.Error)
+		}
+	}
+
+	want := []struct {
+		id, name, path string
+	}{
+		{"call_a", "file_read", "go.mod"},
+		{"call_b", "git_status", ""},
+		{"call_c", "file_read", "go.sum"},
+	}
+
+	if len(toolUses) != len(want) {
+		t.Fatalf("got %d tool uses, want %d", len(toolUses), len(want))
+	}
+	for i, w := range want {
+		if toolUses[i].ID != w.id || toolUses[i].Name != w.name {
+			t.Errorf("tool %d = %s %s, want %s %s", i, toolUses[i].ID, toolUses[i].Name, w.id, w.name)
+		}
+		if w.path != "" && toolUses[i].Input["path"] != w.path {
+			t.Errorf("tool %d input = %v, want path %s", i, toolUses[i].Input, w.path)
+		}
+	}
+	if toolUses[1].Input == nil {
+		t.Error("tool without arguments should have empty, non-nil input")
+	}
+}
+
+func TestClient_StreamResponse_ForcedToolCall(t *testing.T) {
+	// A call forced through tool_choice finishes with "stop"
+	events := streamLines([]string{
+		`data: {"choices":[{"index":0,"delta":{"role":"assistant","tool_calls":[{"index":0,"id":"call_a","type":"function","function":{"name":"file_read","arguments":""}}]}}]}`,
+		`data: {"choices":[{"index":0,"delta":{"tool_calls":[{"index":0,"function":{"arguments":"{\"path\":\"go.mod\"}"}}]}}]}`,
+		`data: {"choices":[{"index":0,"delta":{},"finish_reason":"stop"}]}`,
+		`data: [DONE]`,
+	})
+
+	var toolUses []*providers.ToolUseContent
+	for _, event := range events {
+		switch event.Type {
+		case providers.EventTypeToolUse:
+			toolUses = append(toolUses, event.ToolUse)
+		case providers.EventTypeError:
+			t.Fatalf("unexpected error: %v", event.Error)
+		}
+	}
+
+	if len(toolUses) != 1 || toolUses[0].Name != "file_read" || toolUses[0].Input["path"] != "go.mod" {
+		t.Fatalf("tool uses = %+v, want the forced file_read call", toolUses)
+	}
+	if last := events[len(events)-1]; last.Type != providers.EventTypeMessageDone {
+		t.Errorf("last event = %v, want message_done", last.Type)
+	}
+}
+
+func TestClient_StreamResponse_InvalidToolArguments(t *testing.T) {
+	events := streamLines([]string{
+		`data: {"choices":[{"index":0,"delta":{"tool_calls":[{"index":0,"id":"call_a","type":"function","function":{"name":"bash","arguments":"{\"command\": \"ls"}}]}}]}`,
+		`data: {"choices":[{"index":0,"delta":{},"finish_reason":"tool_calls"}]}`,
+		`data: [DONE]`,
+	})
+
+	last := events[len(events)-1]
+	if last.Type != providers.EventTypeError {
+		t.Fatalf("last event = %v, want error", last.Type)
+	}
+	if !strings.Contains(last.Error.Error(), "invalid arguments for tool bash (call_a)") {
+		t.Errorf("error = %v", last.Error)
+	}
+	for _, event := range events {
+		if event.Type == providers.EventTypeToolUse {
+			t.Error("unexpected tool_use event")
+		}
+	}
+}
@@ -0,0 +1,73 @@
+package openai
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/taaha3244/potus/internal/providers"
+)
+
+// CompatibleConfig describes a server that speaks the OpenAI chat
+// completions API, such as vLLM, LM Studio or an internal gateway.
+type CompatibleConfig struct {
+	Name    string
+	BaseURL string // e.g. http://localhost:8000/v1
+	APIKey  string // optional, local servers usually don't need one
+	Headers map[string]string
+	Models  []providers.Model // listed as-is; when empty, GET /models is used
+}
+
+func NewCompatible(cfg CompatibleConfig) (*Client, error) {
+	if cfg.Name == "" {
+		return nil, fmt.Errorf("provider name is required")
+	}
+	if cfg.BaseURL == "" {
+		return nil, fmt.Errorf("endpoint is required for provider %s", cfg.Name)
+	}
+
+	base := strings.TrimSuffix(cfg.BaseURL, "/")
+	base = strings.TrimSuffix(base, "/chat/completions")
+
+	models := make([]providers.Model, len(cfg.Models))
+	for i, m := range cfg.Models {
+		if m.Name == "" {
+			m.Name = m.ID
+		}
+		m.Provider = cfg.Name
+		models[i] = m
+	}
+
+	return &Client{
+		apiKey:            cfg.APIKey,
+		endpoint:          base + "/chat/completions",
+		client:            &http.Client{},
+		name:              cfg.Name,
+		modelsEndpoint:    base + "/models",
+		responsesEndpoint: base + "/responses",
+		headers:           cfg.Headers,
+		models:            models,
+	}, nil
+}
+
+func (c *Client) listCompatibleModels(ctx context.Context) ([]providers.Model, error) {
+	if len(c.models) > 0 {
+		return c.models, nil
+	}
+
+	ids, err := c.fetchModelIDs(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	models := make([]providers.Model, 0, len(ids))
+	for _, id := range ids {
+		models = append(models, providers.Model{
+			ID:       id,
+			Name:     id,
+			Provider: c.name,
+		})
+	}
+	return models, nil
+}
@@ -0,0 +1,191 @@
+package openai
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/taaha3244/potus/internal/providers"
+)
+
+func TestNewCompatible(t *testing.T) {
+	tests := []struct {
+		name         string
+		cfg          CompatibleConfig
+		wantErr      bool
+		wantEndpoint string
+	}{
+		{"base URL", CompatibleConfig{Name: "vllm", BaseURL: "http://localhost:8000/v1"}, false, "http://localhost:8000/v1/chat/completions"},
+		{"trailing slash", CompatibleConfig{Name: "vllm", BaseURL: "http://localhost:8000/v1/"}, false, "http://localhost:8000/v1/chat/completions"},
+		{"full path", CompatibleConfig{Name: "vllm", BaseURL: "http://localhost:8000/v1/chat/completions"}, false, "http://localhost:8000/v1/chat/completions"},
+		{"missing endpoint", CompatibleConfig{Name: "vllm"}, true, ""},
+		{"missing name", CompatibleConfig{BaseURL: "http://localhost:8000/v1"}, true, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, err := NewCompatible(tt.cfg)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("NewCompatible() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if client.endpoint != tt.wantEndpoint {
+				t.Errorf("endpoint = %s, want %s", client.endpoint, tt.wantEndpoint)
+			}
+			if client.Name() != tt.cfg.Name {
+				t.Errorf("Name() = %s, want %s", client.Name(), tt.cfg.Name)
+			}
+		})
+	}
+}
+
+func TestCompatible_Chat(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/chat/completions" {
+			t.Errorf("URL path = %s, want /v1/chat/completions", r.URL.Path)
+		}
+		if r.Header.Get("Authorization") != "" {
+			t.Error("Authorization header should not be sent without an API key")
+		}
+		if r.Header.Get("X-Team") != "platform" {
+			t.Errorf("X-Team header = %q, want platform", r.Header.Get("X-Team"))
+		}
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Write([]byte(`data: {"choices":[{"index":0,"delta":{"content":"hi"}}]}` + "\n"))
+		w.Write([]byte("data: [DONE]\n"))
+	}))
+	defer server.Close()
+
+	client, err := NewCompatible(CompatibleConfig{
+		Name:    "gateway",
+		BaseURL: server.URL + "/v1",
+		Headers: map[string]string{"X-Team": "platform"},
+	})
+	if err != nil {
+		t.Fatalf("NewCompatible() error = %v", err)
+	}
+
+	events, err := client.Chat(context.Background(), &providers.ChatRequest{
+		Model: "llama-3.1-70b",
+		Messages: []providers.Message{
+			{Role: providers.RoleUser, Content: []providers.ContentBlock{&providers.TextContent{Text: "Hello"}}},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Chat() error = %v", err)
+	}
+
+	var text string
+	for event := range events {
+		if event.Type == providers.EventTypeTextDelta {
+			text += event.Content
+		}
+	}
+	if text != "hi" {
+		t.Errorf("text = %q, want hi", text)
+	}
+}
+
+// TestCompatible_ToolCalls covers servers such as vLLM, LM Studio and
+// llama.cpp that end tool calls with "stop", or with no finish reason.
+func TestCompatible_ToolCalls(t *testing.T) {
+	tests := []struct {
+		name   string
+		finish string
+	}{
+		{"stop", `"stop"`},
+		{"null then done", `null`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "text/event-stream")
+				w.Write([]byte(`data: {"choices":[{"index":0,"delta":{"tool_calls":[{"index":0,"id":"call_1","type":"function","function":{"name":"git_status","arguments":"{}"}}]}}]}` + "\n\n"))
+				w.Write([]byte(`data: {"choices":[{"index":0,"delta":{},"finish_reason":` + tt.finish + `}]}` + "\n\n"))
+				w.Write([]byte("data: [DONE]\n\n"))
+			}))
+			defer server.Close()
+
+			client, err := NewCompatible(CompatibleConfig{Name: "vllm", BaseURL: server.URL + "/v1"})
+			if err != nil {
+				t.Fatalf("NewCompatible() error = %v", err)
+			}
+
+			events, err := client.Chat(context.Background(), &providers.ChatRequest{
+				Model: "qwen2.5-coder",
+				Messages: []providers.Message{
+					{Role: providers.RoleUser, Content: []providers.ContentBlock{&providers.TextContent{Text: "Status?"}}},
+				},
+				Tools: []providers.Tool{{Name: "git_status", InputSchema: map[string]interface{}{"type": "object"}}},
+			})
+			if err != nil {
+				t.Fatalf("Chat() error = %v", err)
+			}
+
+			var toolUses []*providers.ToolUseContent
+			for event := range events {
+				switch event.Type {
+				case providers.EventTypeToolUse:
+					toolUses = append(toolUses, event.ToolUse)
+				case providers.EventTypeError:
+					t.Fatalf("unexpected error: %v", event.Error)
+				}
+			}
+			if len(toolUses) != 1 || toolUses[0].ID != "call_1" || toolUses[0].Name != "git_status" {
+				t.Errorf("tool uses = %+v, want call_1 git_status", toolUses)
+			}
+		})
+	}
+}
+
+func TestCompatible_ListModels(t *testing.T) {
+	t.Run("configured models", func(t *testing.T) {
+		client, _ := NewCompatible(CompatibleConfig{
+			Name:    "vllm",
+			BaseURL: "http://localhost:8000/v1",
+			Models: []providers.Model{
+				{ID: "qwen2.5-coder-32b", ContextSize: 32768},
+			},
+		})
+
+		models, err := client.ListModels(context.Background())
+		if err != nil {
+			t.Fatalf("ListModels() error = %v", err)
+		}
+		if len(models) != 1 || models[0].Name != "qwen2.5-coder-32b" || models[0].Provider != "vllm" {
+			t.Errorf("models = %+v", models)
+		}
+	})
+
+	t.Run("models endpoint", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path != "/v1/models" {
+				t.Errorf("URL path = %s, want /v1/models", r.URL.Path)
+			}
+			if r.Header.Get("Authorization") != "Bearer secret" {
+				t.Error("Missing or incorrect Authorization header")
+			}
+			w.Write([]byte(`{"object":"list","data":[{"id":"lmstudio-community/qwen2.5-7b","object":"model"}]}`))
+		}))
+		defer server.Close()
+
+		client, _ := NewCompatible(CompatibleConfig{
+			Name:    "lmstudio",
+			BaseURL: server.URL + "/v1",
+			APIKey:  "secret",
+		})
+
+		models, err := client.ListModels(context.Background())
+		if err != nil {
+			t.Fatalf("ListModels() error = %v", err)
+		}
+		if len(models) != 1 || models[0].ID != "lmstudio-community/qwen2.5-7b" {
+			t.Errorf("models = %+v", models)
+		}
+	})
+}
@@ -0,0 +1,465 @@
+package openai
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/taaha3244/potus/internal/providers"
+)
+
+// reasoningModelPrefixes lists the model families sent to the Responses API.
+// Reasoning is kept between turns as encrypted items, which chat completions
+// can't carry.
+var reasoningModelPrefixes = []string{"gpt-5", "o1", "o3", "o4"}
+
+// Values of the api setting, which picks the API a model is sent to.
+// Left empty, OpenAI reasoning models use the Responses API and
+// everything else, including Azure and compatible servers, chat
+// completions.
+const (
+	APIChat      = "chat"
+	APIResponses = "responses"
+)
+
+// SetAPI overrides the API choice for all models of the provider, and per
+// model ID in modelAPIs.
+func (c *Client) SetAPI(api string, modelAPIs map[string]string) error {
+	for _, value := range append([]string{api}, slices.Collect(maps.Values(modelAPIs))...) {
+		if value != "" && value != APIChat && value != APIResponses {
+			return fmt.Errorf("unknown api %q (supported: %s, %s)", value, APIChat, APIResponses)
+		}
+	}
+	c.api = api
+	c.modelAPIs = modelAPIs
+	return nil
+}
+
+func (c *Client) usesResponses(model string) bool {
+	api := c.api
+	if override := c.modelAPIs[model]; override != "" {
+		api = override
+	}
+	switch api {
+	case APIResponses:
+		return true
+	case APIChat:
+		return false
+	}
+	// The model name heuristic only holds for OpenAI's own models
+	return c.name == "" && c.responsesEndpoint != "" && usesResponsesAPI(model)
+}
+
+func usesResponsesAPI(model string) bool {
+	for _, prefix := range reasoningModelPrefixes {
+		if model == prefix || strings.HasPrefix(model, prefix+"-") || strings.HasPrefix(model, prefix+".") {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *Client) chatResponses(ctx context.Context, req *providers.ChatRequest) (<-chan providers.ChatEvent, error) {
+	apiReq := c.buildResponsesRequest(req)
+
+	endpoint := c.responsesEndpoint
+	if c.azure != nil {
+		// The v1 API takes the deployment as the model and no api-version
+		if req.Model == "" {
+			if c.azure.deployment == "" {
+				return nil, fmt.Errorf("no Azure deployment configured (set providers.azure.deployment or use --model azure/<deployment>)")
+			}
+			apiReq["model"] = c.azure.deployment
+		}
+		endpoint = c.azure.endpoint + "/openai/v1/responses"
+	}
+
+	body, err := json.Marshal(apiReq)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", endpoint, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	httpReq.Header.Set("Content-Type", "application/json")
+	c.setHeaders(httpReq)
+
+	resp, err := c.client.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send request: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, providers.NewAPIError(resp)
+	}
+
+	eventChan := make(chan providers.ChatEvent, 10)
+
+	go c.streamResponses(resp.Body, eventChan)
+
+	return eventChan, nil
+}
+
+// buildResponsesRequest converts the conversation into Responses API input
+// items. Nothing is stored server side, so reasoning is requested in
+// encrypted form and replayed from the history on every turn.
+func (c *Client) buildResponsesRequest(req *providers.ChatRequest) map[string]interface{} {
+	effort := req.ReasoningEffort
+	if effort == "" {
+		effort = "medium"
+	}
+
+	apiReq := map[string]interface{}{
+		"model":   req.Model,
+		"stream":  true,
+		"store":   false,
+		"include": []string{"reasoning.encrypted_content"},
+		"reasoning": map[string]interface{}{
+			"effort":  effort,
+			"summary": "auto",
+		},
+	}
+
+	if req.System != "" {
+		apiReq["instructions"] = req.System
+	}
+
+	if req.MaxTokens > 0 {
+		apiReq["max_output_tokens"] = req.MaxTokens
+	}
+
+	input := make([]map[string]interface{}, 0, len(req.Messages))
+	for _, msg := range req.Messages {
+		input = append(input, c.responsesInputItems(msg)...)
+	}
+	apiReq["input"] = input
+
+	if len(req.Tools) > 0 {
+		tools := make([]map[string]interface{}, 0, len(req.Tools))
+		for _, tool := range req.Tools {
+			tools = append(tools, map[string]interface{}{
+				"type":        "function",
+				"name":        tool.Name,
+				"description": tool.Description,
+				"parameters":  tool.InputSchema,
+			})
+		}
+		apiReq["tools"] = tools
+
+		if name := req.ToolChoice.ForcedTool(); name != "" {
+			apiReq["tool_choice"] = map[string]interface{}{"type": "function", "name": name}
+		} else if req.ToolChoice != "" {
+			apiReq["tool_choice"] = string(req.ToolChoice)
+		}
+	}
+
+	if req.ResponseFormat != nil {
+		apiReq["text"] = map[string]interface{}{
+			"format": map[string]interface{}{
+				"type":   "json_schema",
+				"name":   req.ResponseFormat.Name,
+				"schema": req.ResponseFormat.Schema,
+			},
+		}
+	}
+
+	return apiReq
+}
+
+func (c *Client) responsesInputItems(msg providers.Message) []map[string]interface{} {
+	var items []map[string]interface{}
+	var content []map[string]interface{}
+	var toolImages []map[string]interface{}
+
+	textType := "input_text"
+	if msg.Role == providers.RoleAssistant {
+		textType = "output_text"
+	}
+
+	// Flush pending text and images as one message item so that the order
+	// of text, reasoning and function calls is preserved
+	flush := func() {
+		if len(content) == 0 {
+			return
+		}
+		items = append(items, map[string]interface{}{
+			"type":    "message",
+			"role":    string(msg.Role),
+			"content": content,
+		})
+		content = nil
+	}
+
+	for _, block := range msg.Content {
+		switch b := block.(type) {
+		case *providers.TextContent:
+			content = append(content, map[string]interface{}{
+				"type": textType,
+				"text": b.Text,
+			})
+		case *providers.ImageContent:
+			content = append(content, map[string]interface{}{
+				"type":      "input_image",
+				"image_url": fmt.Sprintf("data:%s;base64,%s", b.Source.MediaType, b.Source.Data),
+			})
+		case *providers.ReasoningContent:
+			flush()
+			summary := []map[string]interface{}{}
+			if b.Text != "" {
+				summary = append(summary, map[string]interface{}{
+					"type": "summary_text",
+					"text": b.Text,
+				})
+			}
+			item := map[string]interface{}{
+				"type":    "reasoning",
+				"summary": summary,
+			}
+			if b.ID != "" {
+				item["id"] = b.ID
+			}
+			if b.Encrypted != "" {
+				item["encrypted_content"] = b.Encrypted
+			}
+			items = append(items, item)
+		case *providers.ToolUseContent:
+			flush()
+			args, _ := json.Marshal(b.Input)
+			items = append(items, map[string]interface{}{
+				"type":      "function_call",
+				"call_id":   b.ID,
+				"name":      b.Name,
+				"arguments": string(args),
+			})
+		case *providers.ToolResultContent:
+			flush()
+			items = append(items, map[string]interface{}{
+				"type":    "function_call_output",
+				"call_id": b.ToolUseID,
+				"output":  b.Content,
+			})
+			for _, img := range b.Images {
+				toolImages = append(toolImages, map[string]interface{}{
+					"type":      "input_image",
+					"image_url": fmt.Sprintf("data:%s;base64,%s", img.Source.MediaType, img.Source.Data),
+				})
+			}
+		}
+	}
+	flush()
+
+	// Function outputs are text, so images of tool results follow in a
+	// user message
+	if len(toolImages) > 0 {
+		items = append(items, map[string]interface{}{
+			"type":    "message",
+			"role":    "user",
+			"content": toolImages,
+		})
+	}
+
+	return items
+}
+
+func (c *Client) streamResponses(body io.ReadCloser, eventChan chan<- providers.ChatEvent) {
+	defer close(eventChan)
+	defer body.Close()
+
+	eventChan <- providers.ChatEvent{Type: providers.EventTypeMessageStart}
+
+	scanner := bufio.NewScanner(body)
+	scanner.Buffer(make([]byte, 0, 64*1024), 4*1024*1024)
+
+	for scanner.Scan() {
+		line := scanner.Text()
+		if !strings.HasPrefix(line, "data: ") {
+			continue
+		}
+
+		var event responsesEvent
+		if err := json.Unmarshal([]byte(strings.TrimPrefix(line, "data: ")), &event); err != nil {
+			eventChan <- providers.ChatEvent{
+				Type:  providers.EventTypeError,
+				Error: fmt.Errorf("failed to parse event: %w", err),
+			}
+			return
+		}
+
+		done, err := handleResponsesEvent(&event, eventChan)
+		if err != nil {
+			eventChan <- providers.ChatEvent{
+				Type:  providers.EventTypeError,
+				Error: err,
+			}
+			return
+		}
+		if done {
+			return
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		eventChan <- providers.ChatEvent{
+			Type:  providers.EventTypeError,
+			Error: fmt.Errorf("scanner error: %w", err),
+		}
+		return
+	}
+
+	eventChan <- providers.ChatEvent{
+		Type:  providers.EventTypeError,
+		Error: fmt.Errorf("stream ended before the response completed"),
+	}
+}
+
+type responsesEvent struct {
+	Type         string         `json:"type"`
+	Delta        string         `json:"delta"`
+	SummaryIndex int            `json:"summary_index"`
+	Item         *responsesItem `json:"item"`
+	Response     *responsesBody `json:"response"`
+	Code         string         `json:"code"`
+	Message      string         `json:"message"`
+}
+
+type responsesItem struct {
+	Type             string `json:"type"`
+	ID               string `json:"id"`
+	CallID           string `json:"call_id"`
+	Name             string `json:"name"`
+	Arguments        string `json:"arguments"`
+	EncryptedContent string `json:"encrypted_content"`
+	Summary          []struct {
+		Text string `json:"text"`
+	} `json:"summary"`
+}
+
+type responsesBody struct {
+	Usage *struct {
+		InputTokens  int `json:"input_tokens"`
+		OutputTokens int `json:"output_tokens"`
+		TotalTokens  int `json:"total_tokens"`
+	} `json:"usage"`
+	Error *struct {
+		Code    string `json:"code"`
+		Message string `json:"message"`
+	} `json:"error"`
+	IncompleteDetails *struct {
+		Reason string `json:"reason"`
+	} `json:"incomplete_details"`
+}
+
+// handleResponsesEvent translates one stream event. It reports done once
+// the response has finished, successfully or not.
+func handleResponsesEvent(event *responsesEvent, eventChan chan<- providers.ChatEvent) (bool, error) {
+	switch event.Type {
+	case "response.output_text.delta":
+		if event.Delta != "" {
+			eventChan <- providers.ChatEvent{
+				Type:    providers.EventTypeTextDelta,
+				Content: event.Delta,
+			}
+		}
+
+	case "response.reasoning_summary_part.added":
+		if event.SummaryIndex > 0 {
+			eventChan <- providers.ChatEvent{
+				Type:    providers.EventTypeReasoningDelta,
+				Content: "\n\n",
+			}
+		}
+
+	case "response.reasoning_summary_text.delta":
+		if event.Delta != "" {
+			eventChan <- providers.ChatEvent{
+				Type:    providers.EventTypeReasoningDelta,
+				Content: event.Delta,
+			}
+		}
+
+	case "response.output_item.done":
+		if event.Item == nil {
+			return false, nil
+		}
+		return false, emitResponsesItem(event.Item, eventChan)
+
+	case "response.completed", "response.incomplete":
+		var usage *providers.Usage
+		if event.Response != nil && event.Response.Usage != nil {
+			u := event.Response.Usage
+			usage = &providers.Usage{
+				InputTokens:  u.InputTokens,
+				OutputTokens: u.OutputTokens,
+				TotalTokens:  u.TotalTokens,
+			}
+		}
+		if event.Type == "response.incomplete" && event.Response != nil && event.Response.IncompleteDetails != nil {
+			eventChan <- providers.ChatEvent{
+				Type:    providers.EventTypeTextDelta,
+				Content: fmt.Sprintf("\n[response incomplete: %s]", event.Response.IncompleteDetails.Reason),
+			}
+		}
+		eventChan <- providers.ChatEvent{
+			Type:  providers.EventTypeMessageDone,
+			Usage: usage,
+		}
+		return true, nil
+
+	case "response.failed":
+		if event.Response != nil && event.Response.Error != nil {
+			return true, fmt.Errorf("response failed: %s: %s", event.Response.Error.Code, event.Response.Error.Message)
+		}
+		return true, fmt.Errorf("response failed")
+
+	case "error":
+		return true, fmt.Errorf("stream error: %s", strings.TrimPrefix(event.Code+": "+event.Message, ": "))
+	}
+
+	return false, nil
+}
+
+func emitResponsesItem(item *responsesItem, eventChan chan<- providers.ChatEvent) error {
+	switch item.Type {
+	case "reasoning":
+		summaries := make([]string, 0, len(item.Summary))
+		for _, s := range item.Summary {
+			summaries = append(summaries, s.Text)
+		}
+		eventChan <- providers.ChatEvent{
+			Type: providers.EventTypeReasoning,
+			Reasoning: &providers.ReasoningContent{
+				ID:        item.ID,
+				Text:      strings.Join(summaries, "\n\n"),
+				Encrypted: item.EncryptedContent,
+			},
+		}
+
+	case "function_call":
+		input := map[string]interface{}{}
+		if raw := strings.TrimSpace(item.Arguments); raw != "" {
+			if err := json.Unmarshal([]byte(raw), &input); err != nil {
+				return fmt.Errorf("invalid arguments for tool %s (%s): %w", item.Name, item.CallID, err)
+			}
+		}
+		eventChan <- providers.ChatEvent{
+			Type: providers.EventTypeToolUse,
+			ToolUse: &providers.ToolUseContent{
+				ID:    item.CallID,
+				Name:  item.Name,
+				Input: input,
+			},
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,376 @@
+package openai
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/taaha3244/potus/internal/providers"
+)
+
+func TestUsesResponsesAPI(t *testing.T) {
+	tests := []struct {
+		model string
+		want  bool
+	}{
+		{"gpt-5", true},
+		{"gpt-5.2", true},
+		{"gpt-5-mini", true},
+		{"o4-mini", true},
+		{"o3", true},
+		{"gpt-4.1", false},
+		{"gpt-4o", false},
+		{"o30-custom", false},
+	}
+
+	for _, tt := range tests {
+		if got := usesResponsesAPI(tt.model); got != tt.want {
+			t.Errorf("usesResponsesAPI(%q) = %v, want %v", tt.model, got, tt.want)
+		}
+	}
+}
+
+func TestClient_BuildResponsesRequest(t *testing.T) {
+	client := &Client{}
+
+	req := &providers.ChatRequest{
+		Model:     "o4-mini",
+		System:    "You are helpful",
+		MaxTokens: 2048,
+		Messages: []providers.Message{
+			{Role: providers.RoleUser, Content: []providers.ContentBlock{&providers.TextContent{Text: "What module is this?"}}},
+			{
+				Role: providers.RoleAssistant,
+				Content: []providers.ContentBlock{
+					&providers.ReasoningContent{ID: "rs_1", Text: "Read go.mod", Encrypted: "gAAAA"},
+					&providers.ToolUseContent{ID: "call_1", Name: "file_read", Input: map[string]interface{}{"path": "go.mod"}},
+				},
+			},
+			{Role: providers.RoleTool, Content: []providers.ContentBlock{&providers.ToolResultContent{ToolUseID: "call_1", Content: "module example.com/x"}}},
+			{Role: providers.RoleAssistant, Content: []providers.ContentBlock{&providers.TextContent{Text: "It is example.com/x."}}},
+		},
+		Tools: []providers.Tool{
+			{Name: "file_read", Description: "Read a file", InputSchema: map[string]interface{}{"type": "object"}},
+		},
+	}
+
+	apiReq := client.buildResponsesRequest(req)
+
+	if apiReq["instructions"] != "You are helpful" {
+		t.Errorf("instructions = %v", apiReq["instructions"])
+	}
+	if apiReq["store"] != false {
+		t.Error("store should be false")
+	}
+	if apiReq["max_output_tokens"] != 2048 {
+		t.Errorf("max_output_tokens = %v", apiReq["max_output_tokens"])
+	}
+	reasoning := apiReq["reasoning"].(map[string]interface{})
+	if reasoning["effort"] != "medium" {
+		t.Errorf("reasoning.effort = %v, want medium", reasoning["effort"])
+	}
+
+	input := apiReq["input"].([]map[string]interface{})
+	wantTypes := []string{"message", "reasoning", "function_call", "function_call_output", "message"}
+	if len(input) != len(wantTypes) {
+		t.Fatalf("input has %d items, want %d", len(input), len(wantTypes))
+	}
+	for i, want := range wantTypes {
+		if input[i]["type"] != want {
+			t.Errorf("input[%d].type = %v, want %s", i, input[i]["type"], want)
+		}
+	}
+
+	if input[1]["encrypted_content"] != "gAAAA" || input[1]["id"] != "rs_1" {
+		t.Errorf("reasoning item = %v", input[1])
+	}
+	if input[2]["call_id"] != "call_1" || input[2]["arguments"] != `{"path":"go.mod"}` {
+		t.Errorf("function_call item = %v", input[2])
+	}
+	if input[3]["call_id"] != "call_1" || input[3]["output"] != "module example.com/x" {
+		t.Errorf("function_call_output item = %v", input[3])
+	}
+	assistant := input[4]["content"].([]map[string]interface{})
+	if assistant[0]["type"] != "output_text" {
+		t.Errorf("assistant text type = %v, want output_text", assistant[0]["type"])
+	}
+
+	tools := apiReq["tools"].([]map[string]interface{})
+	if tools[0]["name"] != "file_read" || tools[0]["type"] != "function" {
+		t.Errorf("tools = %v", tools)
+	}
+
+	req.ReasoningEffort = "high"
+	reasoning = client.buildResponsesRequest(req)["reasoning"].(map[string]interface{})
+	if reasoning["effort"] != "high" {
+		t.Errorf("reasoning.effort = %v, want high", reasoning["effort"])
+	}
+
+	if _, ok := apiReq["tool_choice"]; ok {
+		t.Error("tool_choice should be omitted by default")
+	}
+	req.ToolChoice = "file_read"
+	choice := client.buildResponsesRequest(req)["tool_choice"].(map[string]interface{})
+	if choice["type"] != "function" || choice["name"] != "file_read" {
+		t.Errorf("tool_choice = %v", choice)
+	}
+	req.ToolChoice = providers.ToolChoiceNone
+	if got := client.buildResponsesRequest(req)["tool_choice"]; got != "none" {
+		t.Errorf("tool_choice = %v, want none", got)
+	}
+	req.ToolChoice = ""
+
+	if _, ok := apiReq["text"]; ok {
+		t.Error("text should be omitted without a response format")
+	}
+	req.ResponseFormat = &providers.ResponseFormat{Name: "answer", Schema: map[string]interface{}{"type": "object"}}
+	format := client.buildResponsesRequest(req)["text"].(map[string]interface{})["format"].(map[string]interface{})
+	if format["type"] != "json_schema" || format["name"] != "answer" || format["schema"] == nil {
+		t.Errorf("text.format = %v", format)
+	}
+}
+
+func TestClient_StreamResponses(t *testing.T) {
+	f, err := os.Open(filepath.Join("testdata", "responses_tool_call.sse"))
+	if err != nil {
+		t.Fatalf("failed to open fixture: %v", err)
+	}
+
+	client := &Client{}
+	eventChan := make(chan providers.ChatEvent, 100)
+	client.streamResponses(f, eventChan)
+
+	var summary string
+	var reasoning []*providers.ReasoningContent
+	var toolUses []*providers.ToolUseContent
+	var done *providers.ChatEvent
+
+	for event := range eventChan {
+		switch event.Type {
+		case providers.EventTypeReasoningDelta:
+			summary += event.Content
+		case providers.EventTypeReasoning:
+			reasoning = append(reasoning, event.Reasoning)
+		case providers.EventTypeToolUse:
+			toolUses = append(toolUses, event.ToolUse)
+		case providers.EventTypeMessageDone:
+			done = &event
+		case providers.EventTypeError:
+			t.Fatalf("unexpected error: %v", event.Error)
+		}
+	}
+
+	wantSummary := "**Checking the module**\n\nI should read go.mod before answering.\n\nAnd the git status."
+	if summary != wantSummary {
+		t.Errorf("summary = %q, want %q", summary, wantSummary)
+	}
+
+	if len(reasoning) != 1 {
+		t.Fatalf("got %d reasoning blocks, want 1", len(reasoning))
+	}
+	if reasoning[0].ID != "rs_68a1c2f1a0d48190" || reasoning[0].Encrypted == "" || reasoning[0].Text != wantSummary {
+		t.Errorf("reasoning = %+v", reasoning[0])
+	}
+
+	if len(toolUses) != 2 {
+		t.Fatalf("got %d tool uses, want 2", len(toolUses))
+	}
+	if toolUses[0].ID != "call_Qx3f9TmzP2aL" || toolUses[0].Input["path"] != "go.mod" {
+		t.Errorf("tool 0 = %+v", toolUses[0])
+	}
+	if toolUses[1].Name != "git_status" || toolUses[1].Input == nil {
+		t.Errorf("tool 1 = %+v", toolUses[1])
+	}
+
+	if done == nil || done.Usage == nil {
+		t.Fatal("expected message_done with usage")
+	}
+	if done.Usage.InputTokens != 2114 || done.Usage.OutputTokens != 187 {
+		t.Errorf("usage = %+v", *done.Usage)
+	}
+}
+
+func TestClient_StreamResponses_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		stream  string
+		wantErr string
+	}{
+		{
+			"failed response",
+			`data: {"type":"response.failed","response":{"error":{"code":"server_error","message":"The model failed"}}}`,
+			"response failed: server_error: The model failed",
+		},
+		{
+			"invalid arguments",
+			`data: {"type":"response.output_item.done","item":{"type":"function_call","call_id":"call_1","name":"bash","arguments":"{\"command\":"}}`,
+			"invalid arguments for tool bash (call_1)",
+		},
+		{
+			"truncated stream",
+			`data: {"type":"response.output_text.delta","delta":"Hel"}`,
+			"stream ended before the response completed",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &Client{}
+			eventChan := make(chan providers.ChatEvent, 10)
+			client.streamResponses(io.NopCloser(strings.NewReader(tt.stream+"\n")), eventChan)
+
+			var last providers.ChatEvent
+			for event := range eventChan {
+				last = event
+			}
+			if last.Type != providers.EventTypeError {
+				t.Fatalf("last event = %v, want error", last.Type)
+			}
+			if !strings.Contains(last.Error.Error(), tt.wantErr) {
+				t.Errorf("error = %v, want %q", last.Error, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestClient_Chat_RoutesReasoningModels(t *testing.T) {
+	var paths []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.Path)
+
+		var req map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&req)
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		if r.URL.Path == "/v1/responses" {
+			if _, ok := req["input"]; !ok {
+				t.Error("responses request should have input")
+			}
+			w.Write([]byte(`data: {"type":"response.completed","response":{"usage":{"input_tokens":1,"output_tokens":1,"total_tokens":2}}}` + "\n"))
+			return
+		}
+		w.Write([]byte("data: [DONE]\n"))
+	}))
+	defer server.Close()
+
+	client := &Client{
+		apiKey:            "test-key",
+		endpoint:          server.URL + "/v1/chat/completions",
+		responsesEndpoint: server.URL + "/v1/responses",
+		client:            &http.Client{},
+	}
+
+	for _, model := range []string{"o4-mini", "gpt-4.1"} {
+		events, err := client.Chat(context.Background(), &providers.ChatRequest{
+			Model:    model,
+			Messages: []providers.Message{{Role: providers.RoleUser, Content: []providers.ContentBlock{&providers.TextContent{Text: "hi"}}}},
+		})
+		if err != nil {
+			t.Fatalf("Chat(%s) error = %v", model, err)
+		}
+		for range events {
+		}
+	}
+
+	if len(paths) != 2 || paths[0] != "/v1/responses" || paths[1] != "/v1/chat/completions" {
+		t.Errorf("paths = %v", paths)
+	}
+}
+
+func TestClient_SetAPI(t *testing.T) {
+	openAI, _ := New("key", "")
+	compatible, _ := NewCompatible(CompatibleConfig{Name: "vllm", BaseURL: "http://localhost:8000/v1"})
+	azure, _ := NewAzure(AzureConfig{Endpoint: "https://res.openai.azure.com", APIKey: "key"})
+
+	tests := []struct {
+		name      string
+		client    *Client
+		api       string
+		modelAPIs map[string]string
+		model     string
+		want      bool
+	}{
+		{"openai heuristic", openAI, "", nil, "o4-mini", true},
+		{"openai chat for all", openAI, APIChat, nil, "o4-mini", false},
+		{"openai model to chat", openAI, "", map[string]string{"gpt-5": APIChat}, "gpt-5", false},
+		{"openai model to responses", openAI, "", map[string]string{"ft:gpt-4.1:acme": APIResponses}, "ft:gpt-4.1:acme", true},
+		{"model overrides provider", openAI, APIResponses, map[string]string{"gpt-4o": APIChat}, "gpt-4o", false},
+		{"compatible heuristic off", compatible, "", nil, "o4-mini", false},
+		{"compatible responses", compatible, APIResponses, nil, "gpt-oss-120b", true},
+		{"azure heuristic off", azure, "", nil, "o4-mini", false},
+		{"azure model to responses", azure, "", map[string]string{"prod-o4": APIResponses}, "prod-o4", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.client.SetAPI(tt.api, tt.modelAPIs); err != nil {
+				t.Fatalf("SetAPI() error = %v", err)
+			}
+			if got := tt.client.usesResponses(tt.model); got != tt.want {
+				t.Errorf("usesResponses(%q) = %v, want %v", tt.model, got, tt.want)
+			}
+		})
+	}
+
+	if err := openAI.SetAPI("assistants", nil); err == nil {
+		t.Error("expected an error for an unknown api")
+	}
+	if err := openAI.SetAPI("", map[string]string{"gpt-5": "completions"}); err == nil {
+		t.Error("expected an error for an unknown model api")
+	}
+}
+
+func TestClient_Chat_ResponsesEndpoints(t *testing.T) {
+	var path, model string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.Path
+		var req map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&req)
+		model, _ = req["model"].(string)
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Write([]byte(`data: {"type":"response.completed","response":{"usage":{"input_tokens":1,"output_tokens":1,"total_tokens":2}}}` + "\n"))
+	}))
+	defer server.Close()
+
+	compatible, _ := NewCompatible(CompatibleConfig{Name: "vllm", BaseURL: server.URL + "/v1"})
+	azure, _ := NewAzure(AzureConfig{Endpoint: server.URL, APIKey: "key", Deployment: "prod-o4"})
+
+	tests := []struct {
+		name      string
+		client    *Client
+		model     string
+		wantPath  string
+		wantModel string
+	}{
+		{"compatible", compatible, "gpt-oss-120b", "/v1/responses", "gpt-oss-120b"},
+		{"azure deployment", azure, "", "/openai/v1/responses", "prod-o4"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.client.SetAPI(APIResponses, nil)
+			events, err := tt.client.Chat(context.Background(), &providers.ChatRequest{
+				Model:    tt.model,
+				Messages: []providers.Message{{Role: providers.RoleUser, Content: []providers.ContentBlock{&providers.TextContent{Text: "hi"}}}},
+			})
+			if err != nil {
+				t.Fatalf("Chat() error = %v", err)
+			}
+			for event := range events {
+				if event.Type == providers.EventTypeError {
+					t.Fatalf("unexpected error: %v", event.Error)
+				}
+			}
+			if path != tt.wantPath || model != tt.wantModel {
+				t.Errorf("sent model %q to %s, want %q to %s", model, path, tt.wantModel, tt.wantPath)
+			}
+		})
+	}
+}
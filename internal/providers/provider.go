@@ -2,6 +2,8 @@ package providers
 
 import (
 	"context"
+	"crypto/rand"
+	"encoding/hex"
 	"time"
 )
 
@@ -15,18 +17,58 @@ type Provider interface {
 	Name() string
 }
 
+// ModelSelector is implemented by providers whose capabilities depend on
+// the model in use. SelectModel is called once the model is known, before
+// SupportsTools or SupportsVision are consulted.
+type ModelSelector interface {
+	SelectModel(ctx context.Context, model string) error
+}
+
 type ChatRequest struct {
-	Messages    []Message
-	Tools       []Tool
-	MaxTokens   int
-	Temperature float64
-	Model       string
-	System      string
+	Messages        []Message
+	Tools           []Tool
+	MaxTokens       int
+	Temperature     float64
+	Model           string
+	System          string
+	ReasoningEffort string // low, medium or high; ignored by models that don't reason
+	ThinkingBudget  int    // tokens for extended thinking; 0 disables it
+	ResponseFormat  *ResponseFormat
+	ToolChoice      ToolChoice // ignored when no tools are sent
+}
+
+// ToolChoice controls tool use: the model may call tools (auto, the
+// default), must not (none), must call at least one (required), or must
+// call the named tool.
+type ToolChoice string
+
+const (
+	ToolChoiceAuto     ToolChoice = "auto"
+	ToolChoiceNone     ToolChoice = "none"
+	ToolChoiceRequired ToolChoice = "required"
+)
+
+// ForcedTool returns the tool the choice names, or "" for auto, none and
+// required.
+func (c ToolChoice) ForcedTool() string {
+	switch c {
+	case "", ToolChoiceAuto, ToolChoiceNone, ToolChoiceRequired:
+		return ""
+	}
+	return string(c)
+}
+
+// ResponseFormat asks for a final answer that is JSON matching Schema.
+// Name identifies the format to the provider and may only contain
+// letters, digits, underscores and dashes.
+type ResponseFormat struct {
+	Name   string                 `json:"name"`
+	Schema map[string]interface{} `json:"schema"`
 }
 
 type Message struct {
-	Role    MessageRole
-	Content []ContentBlock
+	Role    MessageRole    `json:"role"`
+	Content []ContentBlock `json:"content"`
 }
 
 type MessageRole string
@@ -49,82 +91,138 @@ const (
 	ContentTypeImage      ContentType = "image"
 	ContentTypeToolUse    ContentType = "tool_use"
 	ContentTypeToolResult ContentType = "tool_result"
+	ContentTypeReasoning  ContentType = "reasoning"
+	ContentTypeThinking   ContentType = "thinking"
 )
 
 type TextContent struct {
-	Text string
+	Text string `json:"text"`
 }
 
 func (t *TextContent) Type() ContentType { return ContentTypeText }
 
 type ImageContent struct {
-	Source ImageSource
+	Source ImageSource `json:"source"`
 }
 
 func (i *ImageContent) Type() ContentType { return ContentTypeImage }
 
 type ImageSource struct {
-	Type      string
-	MediaType string
-	Data      string
+	Type      string `json:"type"`
+	MediaType string `json:"media_type"`
+	Data      string `json:"data"`
 }
 
+// ToolUseContent is a tool call. Signature is an opaque provider token,
+// such as a Gemini thought signature, that is sent back with the call.
 type ToolUseContent struct {
-	ID    string
-	Name  string
-	Input map[string]interface{}
+	ID        string                 `json:"id"`
+	Name      string                 `json:"name"`
+	Input     map[string]interface{} `json:"input"`
+	Signature string                 `json:"signature,omitempty"`
 }
 
 func (t *ToolUseContent) Type() ContentType { return ContentTypeToolUse }
 
+// NewToolCallID makes an ID for a tool call from a provider, such as
+// Gemini or Ollama, that doesn't assign one.
+func NewToolCallID() string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return "call_" + hex.EncodeToString(b)
+}
+
+// ToolResultContent answers a tool call. Images, such as a file the tool
+// read, accompany the text for models that accept them.
 type ToolResultContent struct {
-	ToolUseID string
-	Content   string
-	IsError   bool
+	ToolUseID string          `json:"tool_use_id"`
+	Content   string          `json:"content"`
+	IsError   bool            `json:"is_error"`
+	Images    []*ImageContent `json:"images,omitempty"`
 }
 
 func (t *ToolResultContent) Type() ContentType { return ContentTypeToolResult }
 
+// ReasoningContent is model reasoning that has to be sent back on later
+// turns. Text is a readable summary and may be empty; Encrypted is an
+// opaque provider payload that is returned unchanged.
+type ReasoningContent struct {
+	ID        string `json:"id,omitempty"`
+	Text      string `json:"text,omitempty"`
+	Encrypted string `json:"encrypted,omitempty"`
+}
+
+func (r *ReasoningContent) Type() ContentType { return ContentTypeReasoning }
+
+// ThinkingContent is an extended thinking block. It must be sent back
+// unchanged, Signature included, when a conversation continues after a
+// tool call. Redacted blocks carry only the encrypted Data.
+type ThinkingContent struct {
+	Thinking  string `json:"thinking,omitempty"`
+	Signature string `json:"signature,omitempty"`
+	Data      string `json:"data,omitempty"`
+}
+
+func (t *ThinkingContent) Type() ContentType { return ContentTypeThinking }
+
+// Redacted reports whether the thinking was encrypted by the provider.
+func (t *ThinkingContent) Redacted() bool { return t.Data != "" }
+
 type Tool struct {
-	Name        string
-	Description string
-	InputSchema map[string]interface{}
+	Name        string                 `json:"name"`
+	Description string                 `json:"description"`
+	InputSchema map[string]interface{} `json:"input_schema"`
 }
 
 type ChatEvent struct {
-	Type    EventType
-	Content string
-	ToolUse *ToolUseContent
-	Usage   *Usage
-	Error   error
+	Type      EventType
+	Content   string
+	ToolUse   *ToolUseContent
+	Reasoning *ReasoningContent
+	Thinking  *ThinkingContent
+	Usage     *Usage
+	Model     string // set on message_done when a fallback model answered
+	Error     error
 }
 
 type EventType string
 
 const (
-	EventTypeTextDelta    EventType = "text_delta"
-	EventTypeToolUse      EventType = "tool_use"
-	EventTypeMessageStart EventType = "message_start"
-	EventTypeMessageDone  EventType = "message_done"
-	EventTypeError        EventType = "error"
+	EventTypeTextDelta      EventType = "text_delta"
+	EventTypeReasoningDelta EventType = "reasoning_delta" // Content is summary text
+	EventTypeReasoning      EventType = "reasoning"       // Reasoning is the complete block
+	EventTypeThinkingDelta  EventType = "thinking_delta"  // Content is thinking text
+	EventTypeThinking       EventType = "thinking"        // Thinking is the complete block
+	EventTypeToolUse        EventType = "tool_use"
+	EventTypeMessageStart   EventType = "message_start"
+	EventTypeMessageDone    EventType = "message_done"
+	EventTypeRetry          EventType = "retry" // Content says what failed and what happens next
+	EventTypeError          EventType = "error"
 )
 
+// Usage counts the tokens of one response. InputTokens excludes prompt
+// cache activity, which is reported separately.
 type Usage struct {
-	InputTokens  int
-	OutputTokens int
-	TotalTokens  int
+	InputTokens              int `json:"input_tokens"`
+	OutputTokens             int `json:"output_tokens"`
+	TotalTokens              int `json:"total_tokens"`
+	CacheCreationInputTokens int `json:"cache_creation_input_tokens,omitempty"`
+	CacheReadInputTokens     int `json:"cache_read_input_tokens,omitempty"`
 }
 
 type Model struct {
-	ID          string
-	Name        string
-	Provider    string
-	ContextSize int
-	Pricing     ModelPricing
+	ID             string       `json:"id"`
+	Name           string       `json:"name"`
+	Provider       string       `json:"provider"`
+	ContextSize    int          `json:"context_size,omitempty"`
+	Pricing        ModelPricing `json:"pricing"`
+	SupportsTools  bool         `json:"supports_tools,omitempty"`
+	SupportsVision bool         `json:"supports_vision,omitempty"`
 }
 
 type ModelPricing struct {
-	InputPer1M  float64
-	OutputPer1M float64
-	CachedPer1M float64
+	InputPer1M      float64 `json:"input_per_1m,omitempty"`
+	OutputPer1M     float64 `json:"output_per_1m,omitempty"`
+	CachedPer1M     float64 `json:"cached_per_1m,omitempty"`
+	CacheWritePer1M float64 `json:"cache_write_per_1m,omitempty"` // 0 charges cache writes as regular input
 }
@@ -0,0 +1,316 @@
+package providers
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+)
+
+// A fixture file holds one recorded exchange per line: the request, its
+// hash and the events the provider answered with. The hash covers the
+// system prompt, messages and tools but not the model, sampling settings
+// or tool order, so a fixture replays under any configuration. It is
+// computed again when a fixture is loaded, so recordings can be edited by
+// hand.
+type recording struct {
+	Hash    string          `json:"hash"`
+	Request recordedRequest `json:"request"`
+	Events  []recordedEvent `json:"events"`
+}
+
+type recordedRequest struct {
+	System   string    `json:"system,omitempty"`
+	Messages []Message `json:"messages"`
+	Tools    []Tool    `json:"tools,omitempty"`
+
+	ResponseFormat *ResponseFormat `json:"response_format,omitempty"`
+	ToolChoice     ToolChoice      `json:"tool_choice,omitempty"`
+}
+
+type recordedEvent struct {
+	Type      EventType         `json:"type"`
+	Content   string            `json:"content,omitempty"`
+	ToolUse   *ToolUseContent   `json:"tool_use,omitempty"`
+	Reasoning *ReasoningContent `json:"reasoning,omitempty"`
+	Thinking  *ThinkingContent  `json:"thinking,omitempty"`
+	Usage     *Usage            `json:"usage,omitempty"`
+	Model     string            `json:"model,omitempty"`
+	Error     string            `json:"error,omitempty"`
+}
+
+func newRecordedRequest(req *ChatRequest) recordedRequest {
+	return recordedRequest{
+		System:   req.System,
+		Messages: req.Messages,
+		Tools:    req.Tools,
+
+		ResponseFormat: req.ResponseFormat,
+		ToolChoice:     req.ToolChoice,
+	}
+}
+
+// sortedTools orders tools by name, since registries list them in no
+// particular order.
+func sortedTools(tools []Tool) []Tool {
+	sorted := make([]Tool, len(tools))
+	copy(sorted, tools)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Name < sorted[j].Name })
+	return sorted
+}
+
+func (r recordedRequest) hash() (string, error) {
+	r.Tools = sortedTools(r.Tools)
+	data, err := json.Marshal(r)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode request: %w", err)
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// RequestHash identifies a request in fixture files.
+func RequestHash(req *ChatRequest) (string, error) {
+	return newRecordedRequest(req).hash()
+}
+
+func newRecordedEvent(event ChatEvent) recordedEvent {
+	recorded := recordedEvent{
+		Type:      event.Type,
+		Content:   event.Content,
+		ToolUse:   event.ToolUse,
+		Reasoning: event.Reasoning,
+		Thinking:  event.Thinking,
+		Usage:     event.Usage,
+		Model:     event.Model,
+	}
+	if event.Error != nil {
+		recorded.Error = event.Error.Error()
+	}
+	return recorded
+}
+
+func (r recordedEvent) chatEvent() ChatEvent {
+	event := ChatEvent{
+		Type:      r.Type,
+		Content:   r.Content,
+		ToolUse:   r.ToolUse,
+		Reasoning: r.Reasoning,
+		Thinking:  r.Thinking,
+		Usage:     r.Usage,
+		Model:     r.Model,
+	}
+	if r.Error != "" {
+		event.Error = errors.New(r.Error)
+	}
+	return event
+}
+
+// Recorder wraps a provider and appends every exchange to a fixture file
+// that Replay can serve back.
+type Recorder struct {
+	Provider
+	path string
+	mu   sync.Mutex
+}
+
+func NewRecorder(provider Provider, path string) *Recorder {
+	return &Recorder{Provider: provider, path: path}
+}
+
+// SelectModel forwards to the wrapped provider so model capabilities are
+// still loaded.
+func (r *Recorder) SelectModel(ctx context.Context, model string) error {
+	if selector, ok := r.Provider.(ModelSelector); ok {
+		return selector.SelectModel(ctx, model)
+	}
+	return nil
+}
+
+// Chat forwards the request and its events unchanged. The exchange is
+// written once the stream ends; a request that fails outright is recorded
+// as a single error event.
+func (r *Recorder) Chat(ctx context.Context, req *ChatRequest) (<-chan ChatEvent, error) {
+	rec := recording{Request: newRecordedRequest(req)}
+	hash, err := rec.Request.hash()
+	if err != nil {
+		return nil, err
+	}
+	rec.Hash = hash
+
+	events, err := r.Provider.Chat(ctx, req)
+	if err != nil {
+		rec.Events = []recordedEvent{{Type: EventTypeError, Error: err.Error()}}
+		if saveErr := r.save(rec); saveErr != nil {
+			return nil, errors.Join(err, saveErr)
+		}
+		return nil, err
+	}
+
+	eventChan := make(chan ChatEvent, 10)
+	go func() {
+		defer close(eventChan)
+		for event := range events {
+			rec.Events = append(rec.Events, newRecordedEvent(event))
+			eventChan <- event
+		}
+		if err := r.save(rec); err != nil {
+			eventChan <- ChatEvent{Type: EventTypeError, Error: err}
+		}
+	}()
+	return eventChan, nil
+}
+
+func (r *Recorder) save(rec recording) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	data, err := json.Marshal(rec)
+	if err != nil {
+		return fmt.Errorf("failed to encode recording: %w", err)
+	}
+
+	if dir := filepath.Dir(r.path); dir != "." {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return fmt.Errorf("failed to create fixture directory: %w", err)
+		}
+	}
+	f, err := os.OpenFile(r.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open fixture: %w", err)
+	}
+	defer f.Close()
+
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("failed to write fixture: %w", err)
+	}
+	return nil
+}
+
+// Replay is a provider that answers from fixture files written by
+// Recorder, without network access. The model names the fixture file;
+// ".jsonl" is added when it has no extension. Requests are matched by
+// hash, and a request recorded several times is answered in the recorded
+// order.
+type Replay struct {
+	mu       sync.Mutex
+	fixtures map[string]*replayFixture
+}
+
+type replayFixture struct {
+	recordings map[string][]recording
+	served     map[string]int
+}
+
+func NewReplay() *Replay {
+	return &Replay{fixtures: make(map[string]*replayFixture)}
+}
+
+func (r *Replay) Name() string {
+	return "replay"
+}
+
+func (r *Replay) SupportsTools() bool {
+	return true
+}
+
+func (r *Replay) SupportsVision() bool {
+	return true
+}
+
+func (r *Replay) ListModels(ctx context.Context) ([]Model, error) {
+	return nil, nil
+}
+
+func (r *Replay) Chat(ctx context.Context, req *ChatRequest) (<-chan ChatEvent, error) {
+	hash, err := RequestHash(req)
+	if err != nil {
+		return nil, err
+	}
+
+	rec, err := r.next(req.Model, hash)
+	if err != nil {
+		return nil, err
+	}
+
+	eventChan := make(chan ChatEvent, len(rec.Events))
+	for _, event := range rec.Events {
+		eventChan <- event.chatEvent()
+	}
+	close(eventChan)
+	return eventChan, nil
+}
+
+func (r *Replay) next(name, hash string) (recording, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	path := name
+	if filepath.Ext(path) == "" {
+		path += ".jsonl"
+	}
+
+	fixture, ok := r.fixtures[path]
+	if !ok {
+		var err error
+		fixture, err = loadReplayFixture(path)
+		if err != nil {
+			return recording{}, err
+		}
+		r.fixtures[path] = fixture
+	}
+
+	recs := fixture.recordings[hash]
+	if len(recs) == 0 {
+		return recording{}, fmt.Errorf("no recording in %s matches request %s", path, hash[:12])
+	}
+
+	// Once every recording of a request has been served, the last repeats
+	i := fixture.served[hash]
+	if i >= len(recs) {
+		i = len(recs) - 1
+	}
+	fixture.served[hash]++
+	return recs[i], nil
+}
+
+func loadReplayFixture(path string) (*replayFixture, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open fixture: %w", err)
+	}
+	defer f.Close()
+
+	fixture := &replayFixture{
+		recordings: make(map[string][]recording),
+		served:     make(map[string]int),
+	}
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 64*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var rec recording
+		if err := json.Unmarshal(scanner.Bytes(), &rec); err != nil {
+			return nil, fmt.Errorf("%s:%d: invalid recording: %w", path, line, err)
+		}
+		rec.Hash, err = rec.Request.hash()
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", path, line, err)
+		}
+		fixture.recordings[rec.Hash] = append(fixture.recordings[rec.Hash], rec)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read fixture: %w", err)
+	}
+	return fixture, nil
+}
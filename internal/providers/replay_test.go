@@ -0,0 +1,150 @@
+package providers
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRecorderReplay(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "fixtures", "session.jsonl")
+
+	first := &ChatRequest{
+		Model:  "claude-sonnet-4-5",
+		System: "You are helpful",
+		Messages: []Message{
+			{Role: RoleUser, Content: []ContentBlock{&TextContent{Text: "what changed?"}}},
+		},
+		Tools: []Tool{{Name: "git_status", Description: "Show status", InputSchema: map[string]interface{}{"type": "object"}}},
+	}
+	toolUse := &ToolUseContent{ID: "toolu_01", Name: "git_status", Input: map[string]interface{}{}}
+	inner := &scriptedProvider{events: []ChatEvent{
+		{Type: EventTypeMessageStart},
+		{Type: EventTypeThinking, Thinking: &ThinkingContent{Thinking: "check status", Signature: "sig"}},
+		{Type: EventTypeTextDelta, Content: "Let me check."},
+		{Type: EventTypeToolUse, ToolUse: toolUse},
+		{Type: EventTypeMessageDone, Usage: &Usage{InputTokens: 10, OutputTokens: 5, TotalTokens: 15}},
+	}}
+
+	recorder := NewRecorder(inner, path)
+	events, err := recorder.Chat(context.Background(), first)
+	if err != nil {
+		t.Fatalf("Chat() error = %v", err)
+	}
+	recorded := collect(t, events)
+
+	// The second request continues the conversation and fails mid-stream
+	second := *first
+	second.Messages = append(append([]Message{}, first.Messages...),
+		Message{Role: RoleAssistant, Content: []ContentBlock{&TextContent{Text: "Let me check."}, toolUse}},
+		Message{Role: RoleTool, Content: []ContentBlock{&ToolResultContent{ToolUseID: "toolu_01", Content: "clean"}}},
+	)
+	inner.events = []ChatEvent{{Type: EventTypeError, Error: errors.New("stream error: overloaded_error: Overloaded")}}
+	events, _ = recorder.Chat(context.Background(), &second)
+	collect(t, events)
+
+	replay := NewReplay()
+	// The model names the fixture; the recorded model doesn't matter
+	first.Model = strings.TrimSuffix(path, ".jsonl")
+
+	events, err = replay.Chat(context.Background(), first)
+	if err != nil {
+		t.Fatalf("replay Chat() error = %v", err)
+	}
+	replayed := collect(t, events)
+
+	if len(replayed) != len(recorded) {
+		t.Fatalf("replayed %d events, want %d", len(replayed), len(recorded))
+	}
+	if replayed[1].Thinking.Signature != "sig" || replayed[2].Content != "Let me check." {
+		t.Errorf("replayed events = %+v", replayed)
+	}
+	if replayed[3].ToolUse.ID != "toolu_01" || replayed[4].Usage.TotalTokens != 15 {
+		t.Errorf("replayed tool use %+v, usage %+v", replayed[3].ToolUse, replayed[4].Usage)
+	}
+
+	second.Model = path
+	events, _ = replay.Chat(context.Background(), &second)
+	got := collect(t, events)
+	if len(got) != 1 || got[0].Type != EventTypeError || got[0].Error.Error() != "stream error: overloaded_error: Overloaded" {
+		t.Errorf("replayed error = %+v", got)
+	}
+}
+
+func TestReplay_Unmatched(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "empty.jsonl")
+	os.WriteFile(path, nil, 0644)
+
+	_, err := NewReplay().Chat(context.Background(), &ChatRequest{
+		Model:    path,
+		Messages: []Message{{Role: RoleUser, Content: []ContentBlock{&TextContent{Text: "hi"}}}},
+	})
+	if err == nil || !strings.Contains(err.Error(), "no recording in "+path+" matches request") {
+		t.Errorf("Chat() error = %v", err)
+	}
+
+	if _, err := NewReplay().Chat(context.Background(), &ChatRequest{Model: "missing"}); err == nil {
+		t.Error("expected an error for a missing fixture")
+	}
+}
+
+func TestRequestHash_ToolOrder(t *testing.T) {
+	read := Tool{Name: "file_read", InputSchema: map[string]interface{}{"type": "object"}}
+	search := Tool{Name: "search_content", InputSchema: map[string]interface{}{"type": "object"}}
+
+	a, _ := RequestHash(&ChatRequest{Tools: []Tool{read, search}})
+	b, _ := RequestHash(&ChatRequest{Tools: []Tool{search, read}})
+	if a != b {
+		t.Errorf("hash depends on tool order: %s != %s", a, b)
+	}
+}
+
+func TestReplay_RepeatedRequest(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "repeat.jsonl")
+	req := &ChatRequest{Messages: []Message{{Role: RoleUser, Content: []ContentBlock{&TextContent{Text: "roll a die"}}}}}
+
+	inner := &scriptedProvider{}
+	recorder := NewRecorder(inner, path)
+	for _, answer := range []string{"4", "2"} {
+		inner.events = []ChatEvent{{Type: EventTypeTextDelta, Content: answer}, {Type: EventTypeMessageDone}}
+		events, _ := recorder.Chat(context.Background(), req)
+		collect(t, events)
+	}
+
+	replay := NewReplay()
+	req.Model = path
+	var answers []string
+	for i := 0; i < 3; i++ {
+		events, err := replay.Chat(context.Background(), req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		answers = append(answers, collect(t, events)[0].Content)
+	}
+	if strings.Join(answers, " ") != "4 2 2" {
+		t.Errorf("answers = %v, want the recorded order, then the last repeated", answers)
+	}
+}
+
+func TestRecorder_ChatError(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "error.jsonl")
+	req := &ChatRequest{Messages: []Message{{Role: RoleUser, Content: []ContentBlock{&TextContent{Text: "hi"}}}}}
+
+	_, err := NewRecorder(&scriptedProvider{chatErr: &APIError{StatusCode: 401, Body: "invalid x-api-key"}}, path).Chat(context.Background(), req)
+	if err == nil {
+		t.Fatal("expected the provider's error")
+	}
+
+	req.Model = path
+	events, err := NewReplay().Chat(context.Background(), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := collect(t, events)
+	if len(got) != 1 || got[0].Error.Error() != "API error (status 401): invalid x-api-key" {
+		t.Errorf("replayed = %+v", got)
+	}
+}
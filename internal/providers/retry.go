@@ -0,0 +1,172 @@
+package providers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"net/http"
+	"time"
+)
+
+const (
+	DefaultMaxRetries   = 3
+	DefaultInitialDelay = time.Second
+	DefaultMaxDelay     = 30 * time.Second
+)
+
+// RetryConfig bounds how often and how long a failed request is retried.
+type RetryConfig struct {
+	MaxRetries   int
+	InitialDelay time.Duration
+	MaxDelay     time.Duration
+}
+
+func DefaultRetryConfig() RetryConfig {
+	return RetryConfig{
+		MaxRetries:   DefaultMaxRetries,
+		InitialDelay: DefaultInitialDelay,
+		MaxDelay:     DefaultMaxDelay,
+	}
+}
+
+// WithRetry wraps a provider so that Chat requests failing before the
+// response stream starts are retried with exponential backoff. Each retry
+// is announced with an EventTypeRetry event on the returned stream.
+func WithRetry(provider Provider, cfg RetryConfig) Provider {
+	if cfg.InitialDelay <= 0 {
+		cfg.InitialDelay = DefaultInitialDelay
+	}
+	if cfg.MaxDelay <= 0 {
+		cfg.MaxDelay = DefaultMaxDelay
+	}
+	return &retryProvider{
+		Provider: provider,
+		cfg:      cfg,
+		sleep:    sleepContext,
+		jitter:   rand.Float64,
+	}
+}
+
+type retryProvider struct {
+	Provider
+	cfg    RetryConfig
+	sleep  func(ctx context.Context, d time.Duration) error
+	jitter func() float64
+}
+
+// SelectModel forwards to the wrapped provider so model capabilities are
+// still loaded.
+func (r *retryProvider) SelectModel(ctx context.Context, model string) error {
+	if selector, ok := r.Provider.(ModelSelector); ok {
+		return selector.SelectModel(ctx, model)
+	}
+	return nil
+}
+
+func (r *retryProvider) Chat(ctx context.Context, req *ChatRequest) (<-chan ChatEvent, error) {
+	events, err := r.Provider.Chat(ctx, req)
+	if err == nil || r.cfg.MaxRetries <= 0 || !IsRetryable(err) {
+		return events, err
+	}
+	if err := r.checkRetryAfter(err); err != nil {
+		return nil, err
+	}
+
+	eventChan := make(chan ChatEvent, 10)
+	go r.retry(ctx, req, err, eventChan)
+	return eventChan, nil
+}
+
+func (r *retryProvider) retry(ctx context.Context, req *ChatRequest, err error, eventChan chan<- ChatEvent) {
+	defer close(eventChan)
+
+	for attempt := 1; attempt <= r.cfg.MaxRetries; attempt++ {
+		delay := r.backoff(attempt, err)
+		eventChan <- ChatEvent{
+			Type:    EventTypeRetry,
+			Content: fmt.Sprintf("%s; retrying in %ds (%d/%d)", retryReason(err), int(math.Ceil(delay.Seconds())), attempt, r.cfg.MaxRetries),
+		}
+
+		if sleepErr := r.sleep(ctx, delay); sleepErr != nil {
+			eventChan <- ChatEvent{Type: EventTypeError, Error: sleepErr}
+			return
+		}
+
+		var events <-chan ChatEvent
+		events, err = r.Provider.Chat(ctx, req)
+		if err == nil {
+			for event := range events {
+				eventChan <- event
+			}
+			return
+		}
+		if !IsRetryable(err) {
+			break
+		}
+		if limitErr := r.checkRetryAfter(err); limitErr != nil {
+			eventChan <- ChatEvent{Type: EventTypeError, Error: limitErr}
+			return
+		}
+	}
+
+	eventChan <- ChatEvent{
+		Type:  EventTypeError,
+		Error: fmt.Errorf("giving up after %d retries: %w", r.cfg.MaxRetries, err),
+	}
+}
+
+// checkRetryAfter returns an error if the server asked for a longer wait
+// than MaxDelay. Waiting that long, e.g. an hour for a daily quota, would
+// stall a headless run without a word.
+func (r *retryProvider) checkRetryAfter(err error) error {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) && apiErr.RetryAfter > r.cfg.MaxDelay {
+		return fmt.Errorf("not retrying: the provider asked to wait %s, longer than the %s limit: %w",
+			apiErr.RetryAfter.Round(time.Second), r.cfg.MaxDelay, err)
+	}
+	return nil
+}
+
+// backoff doubles the delay on every attempt up to MaxDelay and picks a
+// random point in its upper half. A delay requested by the server wins;
+// checkRetryAfter keeps it within MaxDelay.
+func (r *retryProvider) backoff(attempt int, err error) time.Duration {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) && apiErr.RetryAfter > 0 {
+		return apiErr.RetryAfter
+	}
+
+	delay := r.cfg.InitialDelay << (attempt - 1)
+	if delay <= 0 || delay > r.cfg.MaxDelay {
+		delay = r.cfg.MaxDelay
+	}
+	return delay/2 + time.Duration(r.jitter()*float64(delay/2))
+}
+
+func retryReason(err error) string {
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		return "connection failed"
+	}
+	switch apiErr.StatusCode {
+	case http.StatusTooManyRequests:
+		return "rate limited"
+	case 529:
+		return "provider overloaded"
+	}
+	return fmt.Sprintf("provider returned status %d", apiErr.StatusCode)
+}
+
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
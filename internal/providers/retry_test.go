@@ -0,0 +1,245 @@
+package providers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// flakyProvider fails Chat with the queued errors before succeeding.
+type flakyProvider struct {
+	mockProvider
+	errs  []error
+	calls int
+}
+
+func (f *flakyProvider) Chat(ctx context.Context, req *ChatRequest) (<-chan ChatEvent, error) {
+	f.calls++
+	if len(f.errs) > 0 {
+		err := f.errs[0]
+		f.errs = f.errs[1:]
+		return nil, err
+	}
+
+	ch := make(chan ChatEvent, 2)
+	ch <- ChatEvent{Type: EventTypeTextDelta, Content: "ok"}
+	ch <- ChatEvent{Type: EventTypeMessageDone}
+	close(ch)
+	return ch, nil
+}
+
+func newTestRetry(p Provider, maxRetries int) (*retryProvider, *[]time.Duration) {
+	var slept []time.Duration
+	r := WithRetry(p, RetryConfig{MaxRetries: maxRetries, InitialDelay: time.Second, MaxDelay: 4 * time.Second}).(*retryProvider)
+	r.sleep = func(ctx context.Context, d time.Duration) error {
+		slept = append(slept, d)
+		return ctx.Err()
+	}
+	r.jitter = func() float64 { return 1 }
+	return r, &slept
+}
+
+func collect(t *testing.T, events <-chan ChatEvent) []ChatEvent {
+	t.Helper()
+	var out []ChatEvent
+	for event := range events {
+		out = append(out, event)
+	}
+	return out
+}
+
+func TestWithRetry_RetriesUntilSuccess(t *testing.T) {
+	inner := &flakyProvider{errs: []error{
+		&APIError{StatusCode: 529, Body: "overloaded"},
+		&APIError{StatusCode: http.StatusTooManyRequests, RetryAfter: 3 * time.Second},
+	}}
+	r, slept := newTestRetry(inner, 3)
+
+	events, err := r.Chat(context.Background(), &ChatRequest{})
+	if err != nil {
+		t.Fatalf("Chat() error = %v", err)
+	}
+	got := collect(t, events)
+
+	if inner.calls != 3 {
+		t.Errorf("calls = %d, want 3", inner.calls)
+	}
+	if want := []time.Duration{time.Second, 3 * time.Second}; fmt.Sprint(*slept) != fmt.Sprint(want) {
+		t.Errorf("slept %v, want %v", *slept, want)
+	}
+
+	wantTypes := []EventType{EventTypeRetry, EventTypeRetry, EventTypeTextDelta, EventTypeMessageDone}
+	if len(got) != len(wantTypes) {
+		t.Fatalf("got %d events, want %d: %+v", len(got), len(wantTypes), got)
+	}
+	for i, want := range wantTypes {
+		if got[i].Type != want {
+			t.Errorf("event %d = %s, want %s", i, got[i].Type, want)
+		}
+	}
+	if got[0].Content != "provider overloaded; retrying in 1s (1/3)" {
+		t.Errorf("first retry = %q", got[0].Content)
+	}
+	if got[1].Content != "rate limited; retrying in 3s (2/3)" {
+		t.Errorf("second retry = %q", got[1].Content)
+	}
+}
+
+func TestWithRetry_GivesUp(t *testing.T) {
+	overloaded := &APIError{StatusCode: 529}
+	inner := &flakyProvider{errs: []error{overloaded, overloaded, overloaded}}
+	r, slept := newTestRetry(inner, 2)
+
+	events, err := r.Chat(context.Background(), &ChatRequest{})
+	if err != nil {
+		t.Fatalf("Chat() error = %v", err)
+	}
+	got := collect(t, events)
+
+	if inner.calls != 3 {
+		t.Errorf("calls = %d, want 3", inner.calls)
+	}
+	// Delays double: 1s, then 2s
+	if want := []time.Duration{time.Second, 2 * time.Second}; fmt.Sprint(*slept) != fmt.Sprint(want) {
+		t.Errorf("slept %v, want %v", *slept, want)
+	}
+
+	last := got[len(got)-1]
+	if last.Type != EventTypeError || !strings.Contains(last.Error.Error(), "giving up after 2 retries") {
+		t.Errorf("last event = %+v, want giving-up error", last)
+	}
+}
+
+func TestWithRetry_NotRetryable(t *testing.T) {
+	inner := &flakyProvider{errs: []error{&APIError{StatusCode: http.StatusBadRequest, Body: "bad"}}}
+	r, _ := newTestRetry(inner, 3)
+
+	_, err := r.Chat(context.Background(), &ChatRequest{})
+	if err == nil || err.Error() != "API error (status 400): bad" {
+		t.Errorf("Chat() error = %v, want the API error", err)
+	}
+	if inner.calls != 1 {
+		t.Errorf("calls = %d, want 1", inner.calls)
+	}
+}
+
+func TestWithRetry_RetryAfterTooLong(t *testing.T) {
+	quota := &APIError{StatusCode: http.StatusTooManyRequests, RetryAfter: time.Hour}
+
+	t.Run("first attempt", func(t *testing.T) {
+		inner := &flakyProvider{errs: []error{quota}}
+		r, slept := newTestRetry(inner, 3)
+
+		_, err := r.Chat(context.Background(), &ChatRequest{})
+		if !errors.Is(err, quota) || !strings.Contains(err.Error(), "asked to wait 1h0m0s") {
+			t.Errorf("Chat() error = %v, want the rate limit error", err)
+		}
+		if inner.calls != 1 || len(*slept) != 0 {
+			t.Errorf("calls = %d, slept %v, want one call and no wait", inner.calls, *slept)
+		}
+	})
+
+	t.Run("later attempt", func(t *testing.T) {
+		inner := &flakyProvider{errs: []error{&APIError{StatusCode: 503}, quota}}
+		r, slept := newTestRetry(inner, 3)
+
+		events, err := r.Chat(context.Background(), &ChatRequest{})
+		if err != nil {
+			t.Fatalf("Chat() error = %v", err)
+		}
+		got := collect(t, events)
+
+		if inner.calls != 2 || len(*slept) != 1 {
+			t.Errorf("calls = %d, slept %v, want two calls and one wait", inner.calls, *slept)
+		}
+		last := got[len(got)-1]
+		if last.Type != EventTypeError || !errors.Is(last.Error, quota) {
+			t.Errorf("last event = %+v, want the rate limit error", last)
+		}
+	})
+}
+
+func TestWithRetry_Canceled(t *testing.T) {
+	inner := &flakyProvider{errs: []error{&APIError{StatusCode: 503}}}
+	r, _ := newTestRetry(inner, 3)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	events, err := r.Chat(ctx, &ChatRequest{})
+	if err != nil {
+		t.Fatalf("Chat() error = %v", err)
+	}
+	got := collect(t, events)
+
+	if inner.calls != 1 {
+		t.Errorf("calls = %d, want 1", inner.calls)
+	}
+	last := got[len(got)-1]
+	if last.Type != EventTypeError || last.Error != context.Canceled {
+		t.Errorf("last event = %+v, want context.Canceled", last)
+	}
+}
+
+func TestIsRetryable(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"rate limited", &APIError{StatusCode: 429}, true},
+		{"overloaded", &APIError{StatusCode: 529}, true},
+		{"server error", fmt.Errorf("wrapped: %w", &APIError{StatusCode: 502}), true},
+		{"bad request", &APIError{StatusCode: 400}, false},
+		{"unauthorized", &APIError{StatusCode: 401}, false},
+		{"connection refused", fmt.Errorf("failed to send request: %w", &url.Error{Op: "Post", URL: "http://x", Err: &net.OpError{Op: "dial", Net: "tcp", Err: syscall.ECONNREFUSED}}), true},
+		{"connection reset", &url.Error{Op: "Post", URL: "http://x", Err: &net.OpError{Op: "read", Net: "tcp", Err: syscall.ECONNRESET}}, true},
+		{"server closed connection", &url.Error{Op: "Post", URL: "http://x", Err: io.EOF}, true},
+		{"timeout", &url.Error{Op: "Post", URL: "http://x", Err: &net.DNSError{Err: "i/o timeout", IsTimeout: true}}, true},
+		{"unknown host", &url.Error{Op: "Post", URL: "http://x", Err: &net.OpError{Op: "dial", Net: "tcp", Err: &net.DNSError{Err: "no such host", Name: "x", IsNotFound: true}}}, false},
+		{"unsupported scheme", &url.Error{Op: "Post", URL: "htp://x", Err: errors.New(`unsupported protocol scheme "htp"`)}, false},
+		{"canceled", fmt.Errorf("failed to send request: %w", &url.Error{Op: "Post", URL: "http://x", Err: context.Canceled}), false},
+		{"other", fmt.Errorf("boom"), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsRetryable(tt.err); got != tt.want {
+				t.Errorf("IsRetryable() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	now := time.Date(2026, 1, 2, 15, 4, 5, 0, time.UTC)
+
+	tests := []struct {
+		name   string
+		header http.Header
+		want   time.Duration
+	}{
+		{"none", http.Header{}, 0},
+		{"seconds", http.Header{"Retry-After": {"12"}}, 12 * time.Second},
+		{"milliseconds", http.Header{"Retry-After-Ms": {"250"}, "Retry-After": {"1"}}, 250 * time.Millisecond},
+		{"http date", http.Header{"Retry-After": {now.Add(30 * time.Second).Format(http.TimeFormat)}}, 30 * time.Second},
+		{"past date", http.Header{"Retry-After": {now.Add(-time.Minute).Format(http.TimeFormat)}}, 0},
+		{"garbage", http.Header{"Retry-After": {"soon"}}, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := retryAfter(tt.header, now); got != tt.want {
+				t.Errorf("retryAfter() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
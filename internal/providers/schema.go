@@ -0,0 +1,250 @@
+package providers
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Validate checks that text is a JSON document matching the schema.
+// Providers only constrain generation on a best-effort basis, so the
+// final answer is checked before it is handed to another program.
+//
+// The supported keywords are type, enum, const, properties, required,
+// additionalProperties, items, minItems, maxItems, minLength, maxLength,
+// pattern, minimum, maximum and anyOf; others are ignored.
+func (f *ResponseFormat) Validate(text string) error {
+	var value interface{}
+	if err := json.Unmarshal([]byte(strings.TrimSpace(text)), &value); err != nil {
+		return fmt.Errorf("response is not valid JSON: %w", err)
+	}
+	if err := validateSchema(f.Schema, value, "$"); err != nil {
+		return fmt.Errorf("response does not match the schema: %w", err)
+	}
+	return nil
+}
+
+func validateSchema(schema map[string]interface{}, value interface{}, path string) error {
+	if len(schema) == 0 {
+		return nil
+	}
+
+	if types := schemaTypes(schema["type"]); len(types) > 0 {
+		matched := false
+		for _, t := range types {
+			if hasJSONType(value, t) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return fmt.Errorf("%s: expected %s, got %s", path, strings.Join(types, " or "), jsonType(value))
+		}
+	}
+
+	if enum, ok := schema["enum"].([]interface{}); ok {
+		found := false
+		for _, allowed := range enum {
+			if reflect.DeepEqual(allowed, value) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return fmt.Errorf("%s: value is not one of the allowed values", path)
+		}
+	}
+
+	if constant, ok := schema["const"]; ok && !reflect.DeepEqual(constant, value) {
+		return fmt.Errorf("%s: value does not equal the constant", path)
+	}
+
+	if anyOf, ok := schema["anyOf"].([]interface{}); ok {
+		var errs []string
+		for _, sub := range anyOf {
+			subSchema, _ := sub.(map[string]interface{})
+			err := validateSchema(subSchema, value, path)
+			if err == nil {
+				errs = nil
+				break
+			}
+			errs = append(errs, err.Error())
+		}
+		if len(errs) > 0 {
+			return fmt.Errorf("%s: value matches none of anyOf (%s)", path, strings.Join(errs, "; "))
+		}
+	}
+
+	switch v := value.(type) {
+	case map[string]interface{}:
+		return validateObject(schema, v, path)
+	case []interface{}:
+		return validateArray(schema, v, path)
+	case string:
+		return validateString(schema, v, path)
+	case float64:
+		return validateNumber(schema, v, path)
+	}
+	return nil
+}
+
+func validateObject(schema map[string]interface{}, obj map[string]interface{}, path string) error {
+	for _, key := range schemaStrings(schema["required"]) {
+		if _, ok := obj[key]; !ok {
+			return fmt.Errorf("%s: missing required property %q", path, key)
+		}
+	}
+
+	properties, _ := schema["properties"].(map[string]interface{})
+
+	// Sorted so the first error reported is the same on every run
+	keys := make([]string, 0, len(obj))
+	for key := range obj {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		propPath := path + "." + key
+		if prop, ok := properties[key]; ok {
+			propSchema, _ := prop.(map[string]interface{})
+			if err := validateSchema(propSchema, obj[key], propPath); err != nil {
+				return err
+			}
+			continue
+		}
+
+		switch additional := schema["additionalProperties"].(type) {
+		case bool:
+			if !additional {
+				return fmt.Errorf("%s: unexpected property", propPath)
+			}
+		case map[string]interface{}:
+			if err := validateSchema(additional, obj[key], propPath); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func validateArray(schema map[string]interface{}, arr []interface{}, path string) error {
+	if min, ok := schemaInt(schema["minItems"]); ok && len(arr) < min {
+		return fmt.Errorf("%s: expected at least %d items, got %d", path, min, len(arr))
+	}
+	if max, ok := schemaInt(schema["maxItems"]); ok && len(arr) > max {
+		return fmt.Errorf("%s: expected at most %d items, got %d", path, max, len(arr))
+	}
+
+	items, _ := schema["items"].(map[string]interface{})
+	for i, item := range arr {
+		if err := validateSchema(items, item, path+"["+strconv.Itoa(i)+"]"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func validateString(schema map[string]interface{}, s string, path string) error {
+	length := len([]rune(s))
+	if min, ok := schemaInt(schema["minLength"]); ok && length < min {
+		return fmt.Errorf("%s: expected at least %d characters, got %d", path, min, length)
+	}
+	if max, ok := schemaInt(schema["maxLength"]); ok && length > max {
+		return fmt.Errorf("%s: expected at most %d characters, got %d", path, max, length)
+	}
+	if pattern, ok := schema["pattern"].(string); ok {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return fmt.Errorf("%s: invalid pattern %q in schema: %w", path, pattern, err)
+		}
+		if !re.MatchString(s) {
+			return fmt.Errorf("%s: value does not match pattern %q", path, pattern)
+		}
+	}
+	return nil
+}
+
+func validateNumber(schema map[string]interface{}, n float64, path string) error {
+	if min, ok := schemaNumber(schema["minimum"]); ok && n < min {
+		return fmt.Errorf("%s: expected at least %v, got %v", path, min, n)
+	}
+	if max, ok := schemaNumber(schema["maximum"]); ok && n > max {
+		return fmt.Errorf("%s: expected at most %v, got %v", path, max, n)
+	}
+	return nil
+}
+
+// schemaTypes reads a type keyword, which is a name or a list of names.
+func schemaTypes(raw interface{}) []string {
+	if t, ok := raw.(string); ok {
+		return []string{t}
+	}
+	return schemaStrings(raw)
+}
+
+// schemaStrings reads a list of strings as decoded from JSON or written
+// in Go.
+func schemaStrings(raw interface{}) []string {
+	switch list := raw.(type) {
+	case []string:
+		return list
+	case []interface{}:
+		var strs []string
+		for _, item := range list {
+			if s, ok := item.(string); ok {
+				strs = append(strs, s)
+			}
+		}
+		return strs
+	}
+	return nil
+}
+
+// schemaNumber reads a numeric keyword, which is a float64 when the schema
+// was decoded from JSON and usually an int when it was written in Go.
+func schemaNumber(raw interface{}) (float64, bool) {
+	switch n := raw.(type) {
+	case float64:
+		return n, true
+	case int:
+		return float64(n), true
+	}
+	return 0, false
+}
+
+func schemaInt(raw interface{}) (int, bool) {
+	n, ok := schemaNumber(raw)
+	return int(n), ok
+}
+
+func hasJSONType(value interface{}, t string) bool {
+	if t == "integer" {
+		n, ok := value.(float64)
+		return ok && n == math.Trunc(n)
+	}
+	return jsonType(value) == t
+}
+
+func jsonType(value interface{}) string {
+	switch value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case float64:
+		return "number"
+	case string:
+		return "string"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	}
+	return fmt.Sprintf("%T", value)
+}
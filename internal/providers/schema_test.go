@@ -0,0 +1,95 @@
+package providers
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+const testSchema = `{
+	"type": "object",
+	"properties": {
+		"verdict": {"type": "string", "enum": ["pass", "fail"]},
+		"score": {"type": "integer", "minimum": 0, "maximum": 10},
+		"files": {
+			"type": "array",
+			"minItems": 1,
+			"items": {"type": "string", "pattern": "\\.go$"}
+		},
+		"note": {"type": ["string", "null"], "maxLength": 10},
+		"ref": {"anyOf": [{"type": "integer"}, {"type": "string", "minLength": 3}]}
+	},
+	"required": ["verdict", "score"],
+	"additionalProperties": false
+}`
+
+func TestResponseFormat_Validate(t *testing.T) {
+	var schema map[string]interface{}
+	if err := json.Unmarshal([]byte(testSchema), &schema); err != nil {
+		t.Fatalf("invalid test schema: %v", err)
+	}
+	format := &ResponseFormat{Name: "review", Schema: schema}
+
+	tests := []struct {
+		name    string
+		text    string
+		wantErr string
+	}{
+		{"minimal", `{"verdict": "pass", "score": 7}`, ""},
+		{"all fields", `{"verdict": "fail", "score": 0, "files": ["a.go"], "note": null, "ref": "abc"}`, ""},
+		{"surrounding whitespace", "\n  {\"verdict\": \"pass\", \"score\": 10}\n", ""},
+		{"not JSON", `The verdict is pass`, "response is not valid JSON"},
+		{"missing required", `{"verdict": "pass"}`, `$: missing required property "score"`},
+		{"wrong type", `{"verdict": "pass", "score": "7"}`, "$.score: expected integer, got string"},
+		{"not an integer", `{"verdict": "pass", "score": 7.5}`, "$.score: expected integer, got number"},
+		{"above maximum", `{"verdict": "pass", "score": 11}`, "$.score: expected at most 10, got 11"},
+		{"not in enum", `{"verdict": "maybe", "score": 1}`, "$.verdict: value is not one of the allowed values"},
+		{"unexpected property", `{"verdict": "pass", "score": 1, "extra": true}`, "$.extra: unexpected property"},
+		{"too few items", `{"verdict": "pass", "score": 1, "files": []}`, "$.files: expected at least 1 items, got 0"},
+		{"item pattern", `{"verdict": "pass", "score": 1, "files": ["a.go", "b.py"]}`, `$.files[1]: value does not match pattern`},
+		{"too long", `{"verdict": "pass", "score": 1, "note": "far too long"}`, "$.note: expected at most 10 characters, got 12"},
+		{"anyOf", `{"verdict": "pass", "score": 1, "ref": "ab"}`, "$.ref: value matches none of anyOf"},
+		{"wrong root type", `["pass"]`, "$: expected object, got array"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := format.Validate(tt.text)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatalf("expected error containing %q", tt.wantErr)
+			}
+			if !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("error = %q, want it to contain %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestResponseFormat_ValidateGoSchema(t *testing.T) {
+	format := &ResponseFormat{
+		Name: "list",
+		Schema: map[string]interface{}{
+			"type":     "object",
+			"required": []string{"items"},
+			"properties": map[string]interface{}{
+				"items": map[string]interface{}{"type": "array", "maxItems": 2},
+			},
+		},
+	}
+
+	if err := format.Validate(`{"items": [1, 2]}`); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if err := format.Validate(`{}`); err == nil {
+		t.Error("expected an error for a missing required property")
+	}
+	if err := format.Validate(`{"items": [1, 2, 3]}`); err == nil {
+		t.Error("expected an error for too many items")
+	}
+}
@@ -0,0 +1,105 @@
+package session
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/taaha3244/potus/internal/providers"
+)
+
+// FormatVersion is written into every session file so that older files
+// can be recognized if the format changes.
+const FormatVersion = 1
+
+const maxTitleLength = 60
+
+// Session is a persisted conversation.
+type Session struct {
+	Version   int                 `json:"version"`
+	ID        string              `json:"id"`
+	Title     string              `json:"title"`
+	Model     string              `json:"model"`
+	CreatedAt time.Time           `json:"created_at"`
+	UpdatedAt time.Time           `json:"updated_at"`
+	Messages  []providers.Message `json:"messages"`
+	Usage     Usage               `json:"usage"`
+
+	// ForkedFrom and ForkedAt record the parent session and the message
+	// index it was forked at, if any.
+	ForkedFrom string `json:"forked_from,omitempty"`
+	ForkedAt   int    `json:"forked_at,omitempty"`
+}
+
+// Usage is the token and cost accounting for a session, restored into the
+// context budget when the session is resumed.
+type Usage struct {
+	InputTokens      int     `json:"input_tokens"`
+	OutputTokens     int     `json:"output_tokens"`
+	ContextTokens    int     `json:"context_tokens"`
+	Cost             float64 `json:"cost"`
+	CacheReadTokens  int     `json:"cache_read_tokens,omitempty"`
+	CacheWriteTokens int     `json:"cache_write_tokens,omitempty"`
+}
+
+func New(model string) *Session {
+	now := time.Now()
+	return &Session{
+		Version:   FormatVersion,
+		ID:        NewID(now),
+		Model:     model,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+}
+
+// Fork starts a new session holding the parent's messages before index at.
+// The message at that index must be a user turn, so the fork never ends
+// between a tool call and its result. Usage is carried over since the kept
+// messages were paid for in the parent.
+func Fork(parent *Session, at int) (*Session, error) {
+	if at < 0 || at > len(parent.Messages) {
+		return nil, fmt.Errorf("message index %d out of range (session has %d messages)", at, len(parent.Messages))
+	}
+	if at < len(parent.Messages) && parent.Messages[at].Role != providers.RoleUser {
+		return nil, fmt.Errorf("message %d is not a user message; fork at a user turn", at)
+	}
+
+	fork := New(parent.Model)
+	fork.Messages = make([]providers.Message, at)
+	copy(fork.Messages, parent.Messages[:at])
+	fork.Usage = parent.Usage
+	fork.ForkedFrom = parent.ID
+	fork.ForkedAt = at
+	return fork, nil
+}
+
+// NewID returns a sortable identifier such as "20260102-150405-9f86d0".
+func NewID(t time.Time) string {
+	suffix := make([]byte, 3)
+	rand.Read(suffix)
+	return t.Format("20060102-150405") + "-" + hex.EncodeToString(suffix)
+}
+
+// DeriveTitle returns the first line of the first user text, truncated.
+func DeriveTitle(messages []providers.Message) string {
+	for _, msg := range messages {
+		if msg.Role != providers.RoleUser {
+			continue
+		}
+		for i := len(msg.Content) - 1; i >= 0; i-- {
+			text, ok := msg.Content[i].(*providers.TextContent)
+			if !ok || strings.TrimSpace(text.Text) == "" {
+				continue
+			}
+			title := strings.TrimSpace(strings.SplitN(strings.TrimSpace(text.Text), "\n", 2)[0])
+			if runes := []rune(title); len(runes) > maxTitleLength {
+				title = string(runes[:maxTitleLength-3]) + "..."
+			}
+			return title
+		}
+	}
+	return ""
+}
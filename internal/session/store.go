@@ -0,0 +1,193 @@
+package session
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+const fileExt = ".json"
+
+// Store keeps sessions as one JSON file each under .potus/sessions in the
+// project directory.
+type Store struct {
+	dir string
+}
+
+func NewStore(workDir string) *Store {
+	return &Store{
+		dir: filepath.Join(workDir, ".potus", "sessions"),
+	}
+}
+
+func NewStoreWithDir(dir string) *Store {
+	return &Store{dir: dir}
+}
+
+func (s *Store) Dir() string {
+	return s.dir
+}
+
+// Save writes the session atomically, filling in its title from the first
+// user message if it has none.
+func (s *Store) Save(sess *Session) error {
+	if sess.Title == "" {
+		sess.Title = DeriveTitle(sess.Messages)
+	}
+	if sess.Version == 0 {
+		sess.Version = FormatVersion
+	}
+
+	if err := os.MkdirAll(s.dir, 0755); err != nil {
+		return fmt.Errorf("failed to create sessions directory: %w", err)
+	}
+
+	data, err := json.MarshalIndent(sess, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal session: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(s.dir, sess.ID+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to write session: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write session: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write session: %w", err)
+	}
+
+	if err := os.Rename(tmp.Name(), s.path(sess.ID)); err != nil {
+		return fmt.Errorf("failed to write session: %w", err)
+	}
+	return nil
+}
+
+// Load reads a session by ID or by a prefix that matches exactly one ID.
+func (s *Store) Load(id string) (*Session, error) {
+	resolved, err := s.resolve(id)
+	if err != nil {
+		return nil, err
+	}
+	return s.read(s.path(resolved))
+}
+
+// List returns every session, most recently updated first. Files that
+// cannot be parsed are skipped.
+func (s *Store) List() ([]*Session, error) {
+	ids, err := s.ids()
+	if err != nil {
+		return nil, err
+	}
+
+	sessions := make([]*Session, 0, len(ids))
+	for _, id := range ids {
+		sess, err := s.read(s.path(id))
+		if err != nil {
+			continue
+		}
+		sessions = append(sessions, sess)
+	}
+
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].UpdatedAt.After(sessions[j].UpdatedAt)
+	})
+	return sessions, nil
+}
+
+// Latest returns the most recently updated session.
+func (s *Store) Latest() (*Session, error) {
+	sessions, err := s.List()
+	if err != nil {
+		return nil, err
+	}
+	if len(sessions) == 0 {
+		return nil, fmt.Errorf("no saved sessions in %s", s.dir)
+	}
+	return sessions[0], nil
+}
+
+func (s *Store) Delete(id string) error {
+	resolved, err := s.resolve(id)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(s.path(resolved)); err != nil {
+		return fmt.Errorf("failed to delete session: %w", err)
+	}
+	return nil
+}
+
+func (s *Store) path(id string) string {
+	return filepath.Join(s.dir, id+fileExt)
+}
+
+func (s *Store) read(path string) (*Session, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read session: %w", err)
+	}
+
+	var sess Session
+	if err := json.Unmarshal(data, &sess); err != nil {
+		return nil, fmt.Errorf("failed to parse session %s: %w", filepath.Base(path), err)
+	}
+	return &sess, nil
+}
+
+func (s *Store) ids() ([]string, error) {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read sessions directory: %w", err)
+	}
+
+	var ids []string
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(name, fileExt) {
+			continue
+		}
+		ids = append(ids, strings.TrimSuffix(name, fileExt))
+	}
+	return ids, nil
+}
+
+func (s *Store) resolve(id string) (string, error) {
+	if id == "" || strings.ContainsAny(id, `/\`) {
+		return "", fmt.Errorf("invalid session ID: %q", id)
+	}
+
+	ids, err := s.ids()
+	if err != nil {
+		return "", err
+	}
+
+	var matches []string
+	for _, candidate := range ids {
+		if candidate == id {
+			return id, nil
+		}
+		if strings.HasPrefix(candidate, id) {
+			matches = append(matches, candidate)
+		}
+	}
+
+	switch len(matches) {
+	case 0:
+		return "", fmt.Errorf("session not found: %s", id)
+	case 1:
+		return matches[0], nil
+	default:
+		return "", fmt.Errorf("session ID %q is ambiguous (%d matches)", id, len(matches))
+	}
+}
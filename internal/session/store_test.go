@@ -0,0 +1,195 @@
+package session
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/taaha3244/potus/internal/providers"
+)
+
+func sampleMessages() []providers.Message {
+	return []providers.Message{
+		{Role: providers.RoleUser, Content: []providers.ContentBlock{
+			&providers.TextContent{Text: "[resource: docs://guide]\n# Guide"},
+			&providers.TextContent{Text: "Fix the failing test\nin package foo"},
+		}},
+		{Role: providers.RoleAssistant, Content: []providers.ContentBlock{
+			&providers.ToolUseContent{ID: "t1", Name: "bash", Input: map[string]interface{}{"command": "go test ./..."}},
+		}},
+		{Role: providers.RoleTool, Content: []providers.ContentBlock{
+			&providers.ToolResultContent{ToolUseID: "t1", Content: "FAIL", IsError: true},
+		}},
+	}
+}
+
+func TestStore_SaveLoad(t *testing.T) {
+	store := NewStoreWithDir(t.TempDir())
+
+	sess := New("anthropic/claude-sonnet-4-5")
+	sess.Messages = sampleMessages()
+	sess.Usage = Usage{InputTokens: 100, OutputTokens: 20, ContextTokens: 80, Cost: 0.01}
+
+	if err := store.Save(sess); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	if sess.Title != "Fix the failing test" {
+		t.Errorf("Title = %q", sess.Title)
+	}
+
+	loaded, err := store.Load(sess.ID)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	if loaded.Model != sess.Model || loaded.Usage != sess.Usage {
+		t.Errorf("loaded = %+v, want %+v", loaded, sess)
+	}
+	if len(loaded.Messages) != 3 {
+		t.Fatalf("expected 3 messages, got %d", len(loaded.Messages))
+	}
+	toolUse, ok := loaded.Messages[1].Content[0].(*providers.ToolUseContent)
+	if !ok || toolUse.Input["command"] != "go test ./..." {
+		t.Errorf("tool use not restored: %#v", loaded.Messages[1].Content[0])
+	}
+	toolResult, ok := loaded.Messages[2].Content[0].(*providers.ToolResultContent)
+	if !ok || !toolResult.IsError || toolResult.ToolUseID != "t1" {
+		t.Errorf("tool result not restored: %#v", loaded.Messages[2].Content[0])
+	}
+
+	entries, _ := os.ReadDir(store.Dir())
+	if len(entries) != 1 {
+		t.Errorf("expected only the session file, found %d entries", len(entries))
+	}
+}
+
+func TestStore_ListAndLatest(t *testing.T) {
+	store := NewStoreWithDir(t.TempDir())
+
+	if _, err := store.Latest(); err == nil {
+		t.Error("expected error when there are no sessions")
+	}
+
+	older := &Session{ID: "20260101-000000-aaaaaa", UpdatedAt: time.Now().Add(-time.Hour)}
+	newer := &Session{ID: "20260102-000000-bbbbbb", UpdatedAt: time.Now()}
+	for _, sess := range []*Session{newer, older} {
+		if err := store.Save(sess); err != nil {
+			t.Fatalf("Save() error = %v", err)
+		}
+	}
+	os.WriteFile(filepath.Join(store.Dir(), "broken.json"), []byte("{"), 0644)
+
+	sessions, err := store.List()
+	if err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+	if len(sessions) != 2 || sessions[0].ID != newer.ID {
+		t.Fatalf("List() = %+v", sessions)
+	}
+
+	latest, err := store.Latest()
+	if err != nil || latest.ID != newer.ID {
+		t.Errorf("Latest() = %v, %v", latest, err)
+	}
+}
+
+func TestStore_ResolvePrefix(t *testing.T) {
+	store := NewStoreWithDir(t.TempDir())
+	store.Save(&Session{ID: "20260101-000000-aaaaaa"})
+	store.Save(&Session{ID: "20260101-000000-abbbbb"})
+
+	if _, err := store.Load("20260101-000000-aa"); err != nil {
+		t.Errorf("unique prefix should resolve: %v", err)
+	}
+	if _, err := store.Load("20260101-000000-a"); err == nil {
+		t.Error("ambiguous prefix should fail")
+	}
+	if _, err := store.Load("missing"); err == nil {
+		t.Error("unknown ID should fail")
+	}
+	if _, err := store.Load("../etc/passwd"); err == nil {
+		t.Error("path traversal should fail")
+	}
+}
+
+func TestStore_Delete(t *testing.T) {
+	store := NewStoreWithDir(t.TempDir())
+	sess := New("ollama/qwen")
+	store.Save(sess)
+
+	if err := store.Delete(sess.ID); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	if _, err := store.Load(sess.ID); err == nil {
+		t.Error("session should be gone")
+	}
+	if err := store.Delete(sess.ID); err == nil {
+		t.Error("deleting twice should fail")
+	}
+}
+
+func TestDeriveTitle(t *testing.T) {
+	long := "Please refactor the configuration loader so that it supports multiple files"
+	wide := strings.Repeat("設定ファイルを読み込む処理を直してください", 4)
+	exact := strings.Repeat("é", maxTitleLength)
+
+	tests := []struct {
+		name     string
+		messages []providers.Message
+		want     string
+	}{
+		{"empty", nil, ""},
+		{"first line", sampleMessages(), "Fix the failing test"},
+		{"truncated", []providers.Message{{Role: providers.RoleUser, Content: []providers.ContentBlock{&providers.TextContent{Text: long}}}}, long[:57] + "..."},
+		{"truncated by rune", []providers.Message{{Role: providers.RoleUser, Content: []providers.ContentBlock{&providers.TextContent{Text: wide}}}}, string([]rune(wide)[:57]) + "..."},
+		{"multibyte at the limit", []providers.Message{{Role: providers.RoleUser, Content: []providers.ContentBlock{&providers.TextContent{Text: exact}}}}, exact},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := DeriveTitle(tt.messages); got != tt.want {
+				t.Errorf("DeriveTitle() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFork(t *testing.T) {
+	parent := New("anthropic/claude-sonnet-4-5")
+	parent.Messages = append(sampleMessages(), providers.Message{
+		Role:    providers.RoleUser,
+		Content: []providers.ContentBlock{&providers.TextContent{Text: "Now try another way"}},
+	})
+	parent.Usage = Usage{InputTokens: 10, Cost: 0.5}
+
+	fork, err := Fork(parent, 3)
+	if err != nil {
+		t.Fatalf("Fork() error = %v", err)
+	}
+	if fork.ID == parent.ID {
+		t.Error("fork should get a new ID")
+	}
+	if len(fork.Messages) != 3 || fork.ForkedFrom != parent.ID || fork.ForkedAt != 3 {
+		t.Errorf("fork = %+v", fork)
+	}
+	if fork.Usage != parent.Usage {
+		t.Errorf("usage = %+v, want %+v", fork.Usage, parent.Usage)
+	}
+
+	fork.Messages[0] = providers.Message{Role: providers.RoleUser}
+	if parent.Messages[0].Content == nil {
+		t.Error("modifying the fork changed the parent")
+	}
+
+	if _, err := Fork(parent, 2); err == nil {
+		t.Error("forking at a tool message should fail")
+	}
+	if _, err := Fork(parent, 9); err == nil {
+		t.Error("forking past the end should fail")
+	}
+	if fork, err := Fork(parent, len(parent.Messages)); err != nil || len(fork.Messages) != 4 {
+		t.Errorf("forking at the end should copy everything: %v", err)
+	}
+}
@@ -6,6 +6,8 @@ import (
 	"os"
 	"strings"
 
+	"github.com/taaha3244/potus/internal/images"
+	"github.com/taaha3244/potus/internal/providers"
 	"github.com/taaha3244/potus/internal/tools"
 )
 
@@ -22,7 +24,7 @@ func (t *ReadTool) Name() string {
 }
 
 func (t *ReadTool) Description() string {
-	return "Read the contents of a file. Optionally specify line range."
+	return "Read the contents of a file. Optionally specify line range. Image files (PNG, JPEG, GIF, WebP) are returned as images."
 }
 
 func (t *ReadTool) Schema() map[string]interface{} {
@@ -54,6 +56,10 @@ func (t *ReadTool) Execute(ctx context.Context, params map[string]interface{}) (
 
 	fullPath := resolvePath(t.workDir, path)
 
+	if images.IsImagePath(fullPath) {
+		return t.readImage(path, fullPath), nil
+	}
+
 	content, err := os.ReadFile(fullPath)
 	if err != nil {
 		return tools.NewErrorResult(fmt.Errorf("failed to read file: %w", err)), nil
@@ -89,3 +95,19 @@ func (t *ReadTool) Execute(ctx context.Context, params map[string]interface{}) (
 
 	return tools.NewResult(numbered.String()), nil
 }
+
+func (t *ReadTool) readImage(path, fullPath string) *tools.Result {
+	img, err := images.Load(fullPath)
+	if err != nil {
+		return tools.NewErrorResult(err)
+	}
+
+	output := fmt.Sprintf("Image %s (%s)", path, img.Source.MediaType)
+	if w, h, err := images.Size(img); err == nil {
+		output = fmt.Sprintf("Image %s (%dx%d %s)", path, w, h, img.Source.MediaType)
+	}
+
+	result := tools.NewResult(output)
+	result.Images = []*providers.ImageContent{img}
+	return result
+}
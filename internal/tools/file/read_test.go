@@ -1,7 +1,10 @@
 package file
 
 import (
+	"bytes"
 	"context"
+	"image"
+	"image/png"
 	"os"
 	"path/filepath"
 	"strings"
@@ -78,6 +81,42 @@ func TestReadTool_Execute(t *testing.T) {
 	}
 }
 
+func TestReadTool_Image(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, 40, 30))); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(tmpDir, "shot.png"), buf.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(tmpDir, "fake.png"), []byte("not an image"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tool := NewReadTool(tmpDir)
+
+	result, err := tool.Execute(context.Background(), map[string]interface{}{"path": "shot.png"})
+	if err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	if !result.Success {
+		t.Fatalf("unexpected error: %v", result.Error)
+	}
+	if result.Output != "Image shot.png (40x30 image/png)" {
+		t.Errorf("output = %q", result.Output)
+	}
+	if len(result.Images) != 1 || result.Images[0].Source.MediaType != "image/png" {
+		t.Errorf("images = %v, want one PNG", result.Images)
+	}
+
+	result, _ = tool.Execute(context.Background(), map[string]interface{}{"path": "fake.png"})
+	if result.Success {
+		t.Error("expected an error for a file that is not an image")
+	}
+}
+
 func TestReadTool_Schema(t *testing.T) {
 	tool := NewReadTool(".")
 	schema := tool.Schema()
@@ -2,6 +2,8 @@ package tools
 
 import (
 	"context"
+
+	"github.com/taaha3244/potus/internal/providers"
 )
 
 type Tool interface {
@@ -11,10 +13,13 @@ type Tool interface {
 	Execute(ctx context.Context, params map[string]interface{}) (*Result, error)
 }
 
+// Result is the outcome of a tool call. Images are sent along with Output
+// to models that accept them.
 type Result struct {
 	Success bool
 	Output  string
 	Error   error
+	Images  []*providers.ImageContent
 }
 
 func NewResult(output string) *Result {
@@ -10,6 +10,8 @@ import (
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 	"github.com/taaha3244/potus/internal/agent"
+	"github.com/taaha3244/potus/internal/mcp"
+	"github.com/taaha3244/potus/internal/providers"
 	"github.com/taaha3244/potus/internal/tui/styles"
 )
 
@@ -27,6 +29,9 @@ type Model struct {
 	processingMsg  bool
 	confirmChan    chan<- agent.Decision
 	pendingPreview bool
+	mcp            *mcp.Manager
+	prompts        []mcp.Prompt
+	showThinking   bool
 }
 
 type Message struct {
@@ -59,7 +64,7 @@ type startStreamMsg struct {
 	events <-chan agent.Event
 }
 
-func New(ag *agent.Agent, model string, confirmChan chan<- agent.Decision) Model {
+func New(ag *agent.Agent, model string, confirmChan chan<- agent.Decision, mcpManager *mcp.Manager) Model {
 	ta := textarea.New()
 	ta.Placeholder = "Type your message... (Ctrl+C to quit)"
 	ta.Focus()
@@ -68,15 +73,26 @@ func New(ag *agent.Agent, model string, confirmChan chan<- agent.Decision) Model
 	ta.SetHeight(3)
 	ta.ShowLineNumbers = false
 	ta.KeyMap.InsertNewline.SetEnabled(false)
+	// Ctrl+T expands and collapses thinking instead
+	ta.KeyMap.TransposeCharacterBackward.SetEnabled(false)
 
 	vp := viewport.New(100, 20)
 
+	messages := historyMessages(ag.GetMemory().GetMessages())
+	if len(messages) > 0 && ag.Session() != nil {
+		messages = append(messages, Message{
+			Role:    "system",
+			Content: fmt.Sprintf("Resumed session %s", ag.Session().ID),
+		})
+	}
+
 	return Model{
 		agent:       ag,
 		viewport:    vp,
 		textarea:    ta,
-		messages:    make([]Message, 0),
+		messages:    messages,
 		confirmChan: confirmChan,
+		mcp:         mcpManager,
 		status: StatusInfo{
 			Model: model,
 		},
@@ -84,7 +100,7 @@ func New(ag *agent.Agent, model string, confirmChan chan<- agent.Decision) Model
 }
 
 func (m Model) Init() tea.Cmd {
-	return textarea.Blink
+	return tea.Batch(textarea.Blink, m.loadPrompts())
 }
 
 func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
@@ -158,6 +174,18 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		case tea.KeyCtrlC, tea.KeyEsc:
 			return m, tea.Quit
 
+		case tea.KeyCtrlT:
+			m.showThinking = !m.showThinking
+			m.updateViewport()
+			return m, nil
+
+		case tea.KeyCtrlV:
+			// The textarea pastes text; an image on the clipboard is
+			// attached instead
+			if m.agent.SupportsVision() {
+				return m, tea.Batch(tiCmd, m.pasteImage(true))
+			}
+
 		case tea.KeyEnter:
 			if m.processingMsg {
 				return m, nil
@@ -168,6 +196,21 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				return m, nil
 			}
 
+			if m.isCommand(userInput) {
+				m.textarea.Reset()
+				return m.handleCommand(strings.TrimSpace(userInput))
+			}
+
+			// Keep the input on failure so that the path can be fixed
+			paths, err := m.attachImagePaths(userInput)
+			if err != nil {
+				m.addErrorMessage(fmt.Sprintf("attaching image: %v", err))
+				return m, nil
+			}
+			for _, path := range paths {
+				m.addSystemMessage(fmt.Sprintf("Attached %s", path))
+			}
+
 			m.messages = append(m.messages, Message{
 				Role:    "user",
 				Content: userInput,
@@ -193,6 +236,18 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		}
 		return m, nil
 
+	case resourcesMsg:
+		return m.handleResources(msg)
+
+	case promptsMsg:
+		return m.handlePrompts(msg)
+
+	case attachMsg:
+		return m.handleAttach(msg)
+
+	case promptExpandedMsg:
+		return m.handlePromptExpanded(msg)
+
 	case errMsg:
 		m.err = msg.err
 		m.processingMsg = false
@@ -296,6 +351,9 @@ func (m *Model) updateViewport() {
 			content.WriteString(styles.UserMessage.Render("You: ") + msg.Content + "\n\n")
 		case "assistant":
 			content.WriteString(styles.AssistantMessage.Render("POTUS: ") + msg.Content + "\n\n")
+		case "reasoning":
+			reasoningStyle := lipgloss.NewStyle().Foreground(styles.Muted).Italic(true)
+			content.WriteString(reasoningStyle.Render(m.renderThinking(msg.Content)) + "\n\n")
 		case "tool_call":
 			content.WriteString(styles.ToolCall.Render("-> " + msg.Content) + "\n")
 		case "tool_result":
@@ -314,6 +372,24 @@ func (m *Model) updateViewport() {
 	m.viewport.GotoBottom()
 }
 
+// renderThinking shows reasoning in full, or folded to its first line
+// while thinking is collapsed.
+func (m *Model) renderThinking(content string) string {
+	content = strings.TrimSpace(content)
+	if m.showThinking {
+		return "Thinking: " + content + "\n(ctrl+t to collapse)"
+	}
+
+	preview, _, _ := strings.Cut(content, "\n")
+	if runes := []rune(preview); len(runes) > 80 {
+		preview = string(runes[:80])
+	}
+	if preview != content {
+		preview += "..."
+	}
+	return "Thinking: " + preview + " (ctrl+t to expand)"
+}
+
 func (m *Model) renderDiff(content string) string {
 	var result strings.Builder
 	lines := strings.Split(content, "\n")
@@ -342,6 +418,48 @@ func (m *Model) renderDiff(content string) string {
 	return result.String()
 }
 
+// historyMessages renders a restored conversation the way it was shown
+// while it happened.
+func historyMessages(msgs []providers.Message) []Message {
+	messages := make([]Message, 0, len(msgs))
+
+	for _, msg := range msgs {
+		for _, block := range msg.Content {
+			switch b := block.(type) {
+			case *providers.TextContent:
+				role := "assistant"
+				if msg.Role == providers.RoleUser {
+					role = "user"
+				}
+				messages = append(messages, Message{Role: role, Content: b.Text})
+			case *providers.ImageContent:
+				messages = append(messages, Message{Role: "system", Content: "[image attached]"})
+			case *providers.ReasoningContent:
+				if b.Text != "" {
+					messages = append(messages, Message{Role: "reasoning", Content: b.Text})
+				}
+			case *providers.ThinkingContent:
+				if b.Thinking != "" {
+					messages = append(messages, Message{Role: "reasoning", Content: b.Thinking})
+				}
+			case *providers.ToolUseContent:
+				messages = append(messages, Message{
+					Role:    "tool_call",
+					Content: fmt.Sprintf("Calling tool: %s", b.Name),
+				})
+			case *providers.ToolResultContent:
+				result := b.Content
+				if len(result) > 200 {
+					result = result[:200] + "..."
+				}
+				messages = append(messages, Message{Role: "tool_result", Content: result})
+			}
+		}
+	}
+
+	return messages
+}
+
 func (m Model) processUserMessage(input string) tea.Cmd {
 	return func() tea.Msg {
 		ctx := context.Background()
@@ -395,6 +513,18 @@ func (m *Model) handleAgentEvent(event agent.Event) (tea.Model, tea.Cmd) {
 		m.updateViewport()
 		return m, m.waitForNextEvent()
 
+	case agent.EventTypeReasoningDelta:
+		if len(m.messages) == 0 || m.messages[len(m.messages)-1].Role != "reasoning" {
+			m.messages = append(m.messages, Message{
+				Role:    "reasoning",
+				Content: event.Content,
+			})
+		} else {
+			m.messages[len(m.messages)-1].Content += event.Content
+		}
+		m.updateViewport()
+		return m, m.waitForNextEvent()
+
 	case agent.EventTypeToolCall:
 		toolMsg := fmt.Sprintf("Calling tool: %s", event.ToolUse.Name)
 		m.messages = append(m.messages, Message{
@@ -430,6 +560,14 @@ func (m *Model) handleAgentEvent(event agent.Event) (tea.Model, tea.Cmd) {
 		}
 		return m, m.waitForNextEvent()
 
+	case agent.EventTypeRetry:
+		m.messages = append(m.messages, Message{
+			Role:    "system",
+			Content: event.Content,
+		})
+		m.updateViewport()
+		return m, m.waitForNextEvent()
+
 	case agent.EventTypeContextUpdate:
 		m.status.ContextStatus = "Compacted"
 		m.messages = append(m.messages, Message{
@@ -452,6 +590,9 @@ func (m *Model) handleAgentEvent(event agent.Event) (tea.Model, tea.Cmd) {
 	case agent.EventTypeMessageDone:
 		m.processingMsg = false
 		m.eventChan = nil
+		if event.Model != "" {
+			m.status.Model = event.Model
+		}
 		if m.status.UsagePercent < 80 {
 			m.status.ContextStatus = ""
 		}
@@ -467,9 +608,9 @@ func (m Model) waitForNextEvent() tea.Cmd {
 	}
 }
 
-func Run(ag *agent.Agent, model string, confirmChan chan agent.Decision) error {
+func Run(ag *agent.Agent, model string, confirmChan chan agent.Decision, mcpManager *mcp.Manager) error {
 	p := tea.NewProgram(
-		New(ag, model, confirmChan),
+		New(ag, model, confirmChan, mcpManager),
 		tea.WithAltScreen(),
 		tea.WithMouseCellMotion(),
 	)
@@ -0,0 +1,409 @@
+package tui
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/taaha3244/potus/internal/images"
+	"github.com/taaha3244/potus/internal/mcp"
+	"github.com/taaha3244/potus/internal/providers"
+)
+
+type resourcesMsg struct {
+	resources []mcp.Resource
+	err       error
+}
+
+type promptsMsg struct {
+	prompts []mcp.Prompt
+	err     error
+	show    bool
+}
+
+type attachMsg struct {
+	uri   string
+	text  string
+	image *providers.ImageContent
+	err   error
+	// quiet suppresses errors, for attempts the user didn't ask for
+	quiet bool
+}
+
+type promptExpandedMsg struct {
+	command string
+	text    string
+	err     error
+}
+
+const commandHelp = `Commands:
+  /resources          list resources published by MCP servers
+  /attach <uri>       attach a resource to your next message
+  /paste              attach the image on the clipboard (also ctrl+v)
+  /prompts            list MCP prompt templates
+  /<prompt> [args]    run an MCP prompt template, e.g. /mcp__docs__summarize
+  /rewind [n]         list your turns, or go back to turn n and edit it
+  /help               show this help
+
+Mention an image file as @path/to/image.png to attach it.`
+
+// commands lists the built-in slash commands.
+var commands = map[string]bool{
+	"/help":      true,
+	"/resources": true,
+	"/attach":    true,
+	"/paste":     true,
+	"/prompts":   true,
+	"/rewind":    true,
+}
+
+// isCommand reports whether the input starts with a slash command or MCP
+// prompt. Other input starting with "/", such as a path, is a message.
+func (m Model) isCommand(input string) bool {
+	fields := strings.Fields(input)
+	if len(fields) == 0 || !strings.HasPrefix(fields[0], "/") {
+		return false
+	}
+	name := fields[0]
+	if commands[name] {
+		return true
+	}
+	// Prompts load in the background, so an MCP name counts even before
+	// they arrive
+	return mcp.IsToolName(strings.TrimPrefix(name, "/"))
+}
+
+func (m Model) handleCommand(input string) (tea.Model, tea.Cmd) {
+	fields := strings.Fields(input)
+	name, args := fields[0], fields[1:]
+
+	switch name {
+	case "/help":
+		m.addSystemMessage(commandHelp)
+		return m, nil
+
+	case "/resources":
+		return m, m.loadResources()
+
+	case "/attach":
+		if len(args) != 1 {
+			m.addSystemMessage("Usage: /attach <uri>")
+			return m, nil
+		}
+		return m, m.attachResource(args[0])
+
+	case "/paste":
+		if !m.agent.SupportsVision() {
+			m.addErrorMessage("The model does not accept images.")
+			return m, nil
+		}
+		return m, m.pasteImage(false)
+
+	case "/prompts":
+		return m, m.listPrompts()
+
+	case "/rewind":
+		return m.rewind(args)
+	}
+
+	if mcp.IsToolName(strings.TrimPrefix(name, "/")) {
+		for _, prompt := range m.prompts {
+			if prompt.Command() == name {
+				return m, m.expandPrompt(prompt, args)
+			}
+		}
+	}
+
+	m.addSystemMessage(fmt.Sprintf("Unknown command %s. Type /help for a list of commands.", name))
+	return m, nil
+}
+
+func (m Model) loadResources() tea.Cmd {
+	return func() tea.Msg {
+		if m.mcp == nil {
+			return resourcesMsg{}
+		}
+		resources, err := m.mcp.Resources(context.Background())
+		return resourcesMsg{resources: resources, err: err}
+	}
+}
+
+func (m Model) loadPrompts() tea.Cmd {
+	return m.fetchPrompts(false)
+}
+
+func (m Model) listPrompts() tea.Cmd {
+	return m.fetchPrompts(true)
+}
+
+func (m Model) fetchPrompts(show bool) tea.Cmd {
+	return func() tea.Msg {
+		if m.mcp == nil {
+			return promptsMsg{show: show}
+		}
+		prompts, err := m.mcp.Prompts(context.Background())
+		return promptsMsg{prompts: prompts, err: err, show: show}
+	}
+}
+
+// attachResource reads a resource by URI, or by name if no URI matches, and
+// queues its contents for the next message.
+func (m Model) attachResource(ref string) tea.Cmd {
+	return func() tea.Msg {
+		if m.mcp == nil {
+			return attachMsg{uri: ref, err: fmt.Errorf("no MCP servers connected")}
+		}
+
+		ctx := context.Background()
+		resources, err := m.mcp.Resources(ctx)
+
+		var match *mcp.Resource
+		for i := range resources {
+			if resources[i].URI == ref {
+				match = &resources[i]
+				break
+			}
+		}
+		if match == nil {
+			for i := range resources {
+				if resources[i].Name == ref {
+					match = &resources[i]
+					break
+				}
+			}
+		}
+		if match == nil {
+			if err != nil {
+				return attachMsg{uri: ref, err: err}
+			}
+			return attachMsg{uri: ref, err: fmt.Errorf("resource not found: %s", ref)}
+		}
+
+		text, err := m.mcp.ReadResource(ctx, match.Server, match.URI)
+		if err != nil {
+			return attachMsg{uri: match.URI, err: err}
+		}
+		return attachMsg{uri: match.URI, text: fmt.Sprintf("[resource: %s]\n%s", match.URI, text)}
+	}
+}
+
+// pasteImage queues the image on the clipboard for the next message.
+func (m Model) pasteImage(quiet bool) tea.Cmd {
+	return func() tea.Msg {
+		img, err := images.Clipboard(context.Background())
+		return attachMsg{uri: "clipboard image", image: img, err: err, quiet: quiet}
+	}
+}
+
+// attachImagePaths queues the image files mentioned as @path in the input.
+// Nothing is attached unless every image loads.
+func (m Model) attachImagePaths(input string) ([]string, error) {
+	var paths []string
+	var loaded []*providers.ImageContent
+	for _, word := range strings.Fields(input) {
+		path, ok := strings.CutPrefix(word, "@")
+		if !ok || !images.IsImagePath(path) {
+			continue
+		}
+		if !m.agent.SupportsVision() {
+			return nil, fmt.Errorf("the model does not accept images")
+		}
+		// Resolved the way the file tools resolve paths
+		file := path
+		if !filepath.IsAbs(file) {
+			file = filepath.Join(m.agent.WorkDir(), file)
+		}
+		img, err := images.Load(file)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		paths = append(paths, path)
+		loaded = append(loaded, img)
+	}
+
+	for _, img := range loaded {
+		m.agent.GetMemory().AttachImage(img)
+	}
+	return paths, nil
+}
+
+func (m Model) expandPrompt(prompt mcp.Prompt, words []string) tea.Cmd {
+	return func() tea.Msg {
+		args, err := mcp.PromptArguments(prompt.PromptInfo, words)
+		if err != nil {
+			return promptExpandedMsg{command: prompt.Command(), err: err}
+		}
+		text, err := m.mcp.GetPrompt(context.Background(), prompt.Server, prompt.Name, args)
+		return promptExpandedMsg{command: prompt.Command(), text: text, err: err}
+	}
+}
+
+func (m Model) handleResources(msg resourcesMsg) (tea.Model, tea.Cmd) {
+	if msg.err != nil {
+		m.addErrorMessage(fmt.Sprintf("listing resources: %v", msg.err))
+	}
+	if len(msg.resources) == 0 {
+		m.addSystemMessage("No MCP resources available.")
+		return m, nil
+	}
+
+	var b strings.Builder
+	b.WriteString("MCP resources (attach with /attach <uri>):")
+	for _, r := range msg.resources {
+		b.WriteString(fmt.Sprintf("\n  %s  %s [%s]", r.URI, r.Name, r.Server))
+		if r.Description != "" {
+			b.WriteString(" - " + r.Description)
+		}
+	}
+	m.addSystemMessage(b.String())
+	return m, nil
+}
+
+func (m Model) handlePrompts(msg promptsMsg) (tea.Model, tea.Cmd) {
+	m.prompts = msg.prompts
+	if msg.err != nil && (msg.show || len(msg.prompts) == 0) {
+		m.addErrorMessage(fmt.Sprintf("listing prompts: %v", msg.err))
+	}
+	if !msg.show {
+		return m, nil
+	}
+
+	if len(msg.prompts) == 0 {
+		m.addSystemMessage("No MCP prompts available.")
+		return m, nil
+	}
+
+	var b strings.Builder
+	b.WriteString("MCP prompts:")
+	for _, p := range msg.prompts {
+		b.WriteString("\n  " + p.Command())
+		for _, arg := range p.Arguments {
+			if arg.Required {
+				b.WriteString(" <" + arg.Name + ">")
+			} else {
+				b.WriteString(" [" + arg.Name + "]")
+			}
+		}
+		if p.Description != "" {
+			b.WriteString(" - " + p.Description)
+		}
+	}
+	m.addSystemMessage(b.String())
+	return m, nil
+}
+
+func (m Model) handleAttach(msg attachMsg) (tea.Model, tea.Cmd) {
+	if msg.err != nil {
+		if !msg.quiet {
+			m.addErrorMessage(fmt.Sprintf("attaching %s: %v", msg.uri, msg.err))
+		}
+		return m, nil
+	}
+
+	if msg.image != nil {
+		m.agent.GetMemory().AttachImage(msg.image)
+		size := msg.image.Source.MediaType
+		if w, h, err := images.Size(msg.image); err == nil {
+			size = fmt.Sprintf("%dx%d %s", w, h, size)
+		}
+		m.addSystemMessage(fmt.Sprintf("Attached %s (%s); it will be sent with your next message.", msg.uri, size))
+		return m, nil
+	}
+
+	m.agent.GetMemory().Attach(msg.text)
+	m.addSystemMessage(fmt.Sprintf("Attached %s (%d chars); it will be sent with your next message.", msg.uri, len(msg.text)))
+	return m, nil
+}
+
+func (m Model) handlePromptExpanded(msg promptExpandedMsg) (tea.Model, tea.Cmd) {
+	if msg.err != nil {
+		m.addErrorMessage(fmt.Sprintf("%s: %v", msg.command, msg.err))
+		return m, nil
+	}
+	if m.processingMsg {
+		m.addErrorMessage(fmt.Sprintf("%s: a message is already being processed", msg.command))
+		return m, nil
+	}
+
+	m.messages = append(m.messages, Message{
+		Role:    "user",
+		Content: msg.text,
+	})
+	m.updateViewport()
+	m.processingMsg = true
+
+	return m, m.processUserMessage(msg.text)
+}
+
+// rewind lists the user turns, or truncates the conversation to before
+// the chosen turn and puts its text back in the input for editing.
+func (m Model) rewind(args []string) (tea.Model, tea.Cmd) {
+	turns := m.agent.UserTurns()
+	if len(turns) == 0 {
+		m.addSystemMessage("Nothing to rewind.")
+		return m, nil
+	}
+
+	if len(args) == 0 {
+		var b strings.Builder
+		b.WriteString("Your turns (rewind with /rewind <n>):")
+		for i, text := range turns {
+			line := strings.SplitN(strings.TrimSpace(text), "\n", 2)[0]
+			if runes := []rune(line); len(runes) > 60 {
+				line = string(runes[:57]) + "..."
+			}
+			b.WriteString(fmt.Sprintf("\n  %d. %s", i+1, line))
+		}
+		m.addSystemMessage(b.String())
+		return m, nil
+	}
+
+	n, err := strconv.Atoi(args[0])
+	if err != nil || len(args) > 1 {
+		m.addSystemMessage("Usage: /rewind [n]")
+		return m, nil
+	}
+
+	text, err := m.agent.Rewind(n)
+	if err != nil {
+		m.addErrorMessage(err.Error())
+		return m, nil
+	}
+
+	m.messages = historyMessages(m.agent.GetMemory().GetMessages())
+	note := fmt.Sprintf("Rewound to turn %d. Edit the message and press Enter to continue.", n)
+	if sess := m.agent.Session(); sess != nil && sess.ForkedFrom != "" {
+		note += fmt.Sprintf(" Continuing in session %s; %s is unchanged.", sess.ID, sess.ForkedFrom)
+	}
+	m.addSystemMessage(note)
+	m.textarea.SetValue(text)
+
+	if info := m.agent.TokenInfo(); info != nil {
+		m.status.Tokens = info.CurrentTokens
+		m.status.MaxTokens = info.MaxTokens
+		m.status.UsagePercent = info.UsagePercent
+		m.status.Cost = info.Cost
+		m.status.AtWarning = info.AtWarning
+	}
+
+	return m, nil
+}
+
+func (m *Model) addSystemMessage(content string) {
+	m.messages = append(m.messages, Message{
+		Role:    "system",
+		Content: content,
+	})
+	m.updateViewport()
+}
+
+func (m *Model) addErrorMessage(content string) {
+	m.messages = append(m.messages, Message{
+		Role:    "error",
+		Content: content,
+	})
+	m.updateViewport()
+}